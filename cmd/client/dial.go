@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"strings"
+
+	"google.golang.org/grpc"
+
+	grpcClient "github.com/anfilat/final-stats/internal/grpc"
+)
+
+var serverAddr string
+var clientTLS grpcClient.ClientTLS
+var token string
+
+func init() {
+	flag.StringVar(&serverAddr, "addr", ":8000", "Server address: host:port, tcp://host:port or unix:///path")
+	flag.BoolVar(&clientTLS.Enabled, "tls", false, "Connect over TLS, verifying the server with system CAs if -cacert is empty")
+	flag.StringVar(&clientTLS.CA, "cacert", "", "Path to CA certificate the server certificate is signed with. Enables TLS")
+	flag.StringVar(&clientTLS.Cert, "cert", "", "Path to client certificate for mutual TLS. Enables TLS")
+	flag.StringVar(&clientTLS.Key, "key", "", "Path to client private key for mutual TLS")
+	flag.StringVar(&clientTLS.ServerName, "servername", "", "Server name to verify the certificate against")
+	flag.StringVar(&token, "token", "", "Bearer token from the server access list")
+}
+
+// dial подключается к серверу с учетом настроек TLS и токена.
+func dial() (*grpc.ClientConn, error) {
+	opt, err := clientTLS.DialOption()
+	if err != nil {
+		return nil, err
+	}
+	opts := []grpc.DialOption{opt}
+	if token != "" {
+		opts = append(opts, grpcClient.WithToken(token, serverAddr))
+	}
+	// unix:///path grpc понимает сам, а схемы tcp у него нет
+	return grpc.Dial(strings.TrimPrefix(serverAddr, "tcp://"), opts...)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	grpcClient "github.com/anfilat/final-stats/internal/grpc"
+)
+
+// runExport выгружает посекундные точки из памяти сервера. Такой дамп загружается командой symo -import.
+func runExport(args []string) error {
+	var output, format string
+	var seconds int
+
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.StringVar(&output, "o", "", "Path to the output file. Stdout if empty")
+	flags.StringVar(&format, "format", "json", "Dump format. Possible values: json|csv")
+	flags.IntVar(&seconds, "s", 0, "Export last S seconds. All stored seconds if 0")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var dumpFormat grpcClient.DumpFormat
+	switch format {
+	case "json":
+		dumpFormat = grpcClient.DumpFormat_DUMP_JSON
+	case "csv":
+		dumpFormat = grpcClient.DumpFormat_DUMP_CSV
+	default:
+		return fmt.Errorf("unknown dump format %q", format)
+	}
+
+	conn, err := dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	client := grpcClient.NewAdminClient(conn)
+	reqClient, err := client.ExportPoints(context.Background(), &grpcClient.ExportRequest{
+		Seconds: int32(seconds),
+		Format:  dumpFormat,
+	})
+	if err != nil {
+		return fmt.Errorf("client request fail: %w", err)
+	}
+
+	// файл создается после первого ответа, чтобы ошибка запроса не оставляла пустой файл
+	var out io.Writer = os.Stdout
+	for first := true; ; first = false {
+		chunk, err := reqClient.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error: %w", err)
+		}
+		if first && output != "" {
+			file, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			out = file
+		}
+		if _, err := out.Write(chunk.Data); err != nil {
+			return err
+		}
+	}
+}
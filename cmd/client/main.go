@@ -7,8 +7,8 @@ import (
 	"fmt"
 	"io"
 	"log"
-
-	"google.golang.org/grpc"
+	"strings"
+	"time"
 
 	grpcClient "github.com/anfilat/final-stats/internal/grpc"
 )
@@ -16,26 +16,79 @@ import (
 var metric string
 var n int
 var m int
+var aggs string
+var policy string
+var dropLimit int
+var milliseconds bool
 
 func init() {
-	flag.StringVar(&metric, "show", "la", "Show metrics. Possible values: la|cpu|disk|fs")
+	flag.StringVar(&metric, "show", "la", "Show metrics. Possible values: la|cpu|disk|fs|symo. "+
+		"symo shows the server's own metrics every N")
 	flag.IntVar(&n, "n", 1, "Send stats every N seconds")
 	flag.IntVar(&m, "m", 1, "Send stats for last M seconds")
+	flag.StringVar(&aggs, "agg", "", "Show aggregations besides the mean, comma separated. "+
+		"Possible values: min|max|p50|p95|p99|last")
+	flag.StringVar(&policy, "policy", "", "What to do when the client is too slow. "+
+		"Possible values: drop-newest|drop-oldest|disconnect. Server default if empty")
+	flag.IntVar(&dropLimit, "droplimit", 0, "How many stats in a row may be dropped before disconnect")
+	flag.BoolVar(&milliseconds, "ms", false, "Treat N and M as milliseconds. Needs a server tick shorter than a second")
 }
 
 func main() {
 	flag.Parse()
 
-	var err error
+	switch flag.Arg(0) {
+	case "record":
+		if err := runRecord(flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "export":
+		if err := runExport(flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "reload":
+		if err := runReload(); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	aggregations, err := parseAggregations(aggs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dropPolicy, err := parsePolicy(policy)
+	if err != nil {
+		log.Fatal(err)
+	}
+	req := &grpcClient.StatsRequest{
+		Aggregations: aggregations,
+		Policy:       dropPolicy,
+		DropLimit:    int32(dropLimit),
+	}
+	if milliseconds {
+		req.NMs, req.MMs = int32(n), int32(m)
+	} else {
+		req.N, req.M = int32(n), int32(m)
+	}
+
 	switch metric {
 	case "la":
-		err = runClient(printHeaderLA, printLA)
+		err = runClient(req, printHeaderLA, printLA)
 	case "cpu":
-		err = runClient(printHeaderCPU, printCPU)
+		err = runClient(req, printHeaderCPU, printCPU)
 	case "disk":
-		err = runClient(printHeaderDisk, printDisks)
+		err = runClient(req, printHeaderDisk, printDisks)
 	case "fs":
-		err = runClient(printHeaderFS, printFS)
+		err = runClient(req, printHeaderFS, printFS)
+	case "symo":
+		every := time.Duration(n) * time.Second
+		if milliseconds {
+			every = time.Duration(n) * time.Millisecond
+		}
+		err = runSelf(every)
 	default:
 		flag.Usage()
 	}
@@ -48,10 +101,37 @@ func main() {
 type printHeader func()
 type printStats func(stats *grpcClient.Stats)
 
-func runClient(ph printHeader, ps printStats) error {
+func parseAggregations(list string) ([]grpcClient.Aggregation, error) {
+	var result []grpcClient.Aggregation
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		value, ok := grpcClient.Aggregation_value["AGG_"+strings.ToUpper(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown aggregation %q", name)
+		}
+		result = append(result, grpcClient.Aggregation(value))
+	}
+	return result, nil
+}
+
+func parsePolicy(name string) (grpcClient.DropPolicy, error) {
+	if name == "" {
+		return grpcClient.DropPolicy_POLICY_DEFAULT, nil
+	}
+	value, ok := grpcClient.DropPolicy_value["POLICY_"+strings.ToUpper(strings.ReplaceAll(name, "-", "_"))]
+	if !ok {
+		return 0, fmt.Errorf("unknown drop policy %q", name)
+	}
+	return grpcClient.DropPolicy(value), nil
+}
+
+func runClient(req *grpcClient.StatsRequest, ph printHeader, ps printStats) error {
 	ph()
 
-	conn, err := grpc.Dial(":8000", grpc.WithInsecure())
+	conn, err := dial()
 	if err != nil {
 		return err
 	}
@@ -60,10 +140,6 @@ func runClient(ph printHeader, ps printStats) error {
 	ctx := context.Background()
 
 	client := grpcClient.NewSymoClient(conn)
-	req := &grpcClient.StatsRequest{
-		N: int32(n),
-		M: int32(m),
-	}
 	reqClient, err := client.GetStats(ctx, req)
 	if err != nil {
 		return fmt.Errorf("client request fail: %w", err)
@@ -77,35 +153,50 @@ func runClient(ph printHeader, ps printStats) error {
 		if err != nil {
 			return fmt.Errorf("error: %w", err)
 		}
+		if stats.Dropped > 0 {
+			fmt.Printf("%s | dropped %d stats\n", formatTime(stats), stats.Dropped)
+		}
 		ps(stats)
 	}
 }
 
 func printHeaderLA() {
 	fmt.Println("Load Average")
-	fmt.Println("  time   | load1 | load5 | load15")
+	fmt.Println("  time   | load1 | load5 | load15 | state")
 }
 
 func printLA(stats *grpcClient.Stats) {
-	data := stats.LoadAvg
+	printLAValues(formatTime(stats), stats.LoadAvg, formatState(stats.LoadAvgState))
+	for _, agg := range stats.Aggregated {
+		printLAValues(formatAggregation(agg), agg.LoadAvg, "")
+	}
+}
+
+func printLAValues(label string, data *grpcClient.LoadAvg, state string) {
 	if data != nil {
-		fmt.Printf("%s | %5.2f | %5.2f | %5.2f\n", formatTime(stats), data.Load1, data.Load5, data.Load15)
+		fmt.Printf("%s | %5.2f | %5.2f | %5.2f  | %s\n", label, data.Load1, data.Load5, data.Load15, state)
 	} else {
-		fmt.Printf("%s |   -   |   -   |   -\n", formatTime(stats))
+		fmt.Printf("%s |   -   |   -   |   -    | %s\n", label, state)
 	}
 }
 
 func printHeaderCPU() {
 	fmt.Println("Load CPU")
-	fmt.Println("  time   | user  | system| idle")
+	fmt.Println("  time   | user  | system| idle  | state")
 }
 
 func printCPU(stats *grpcClient.Stats) {
-	data := stats.Cpu
+	printCPUValues(formatTime(stats), stats.Cpu, formatState(stats.CpuState))
+	for _, agg := range stats.Aggregated {
+		printCPUValues(formatAggregation(agg), agg.Cpu, "")
+	}
+}
+
+func printCPUValues(label string, data *grpcClient.CPU, state string) {
 	if data != nil {
-		fmt.Printf("%s | %5.2f | %5.2f | %5.2f\n", formatTime(stats), data.User, data.System, data.Idle)
+		fmt.Printf("%s | %5.2f | %5.2f | %5.2f | %s\n", label, data.User, data.System, data.Idle, state)
 	} else {
-		fmt.Printf("%s |   -   |   -   |   -\n", formatTime(stats))
+		fmt.Printf("%s |   -   |   -   |   -   | %s\n", label, state)
 	}
 }
 
@@ -115,9 +206,16 @@ func printHeaderDisk() {
 }
 
 func printDisks(stats *grpcClient.Stats) {
-	fmt.Printf("%s |          |          |          |\n", formatTime(stats))
-	for _, disk := range stats.LoadDisks {
-		fmt.Printf("         | %8.2f | %8.2f | %8.2f | %s\n", disk.Tps, disk.KBRead, disk.KBWrite, disk.Name)
+	fmt.Printf("%s |          |          |          | %s\n", formatTime(stats), formatState(stats.LoadDisksState))
+	printDisksValues("        ", stats.LoadDisks)
+	for _, agg := range stats.Aggregated {
+		printDisksValues(formatAggregation(agg), agg.LoadDisks)
+	}
+}
+
+func printDisksValues(label string, disks []*grpcClient.LoadDisk) {
+	for _, disk := range disks {
+		fmt.Printf("%s | %8.2f | %8.2f | %8.2f | %s\n", label, disk.Tps, disk.KBRead, disk.KBWrite, disk.Name)
 	}
 }
 
@@ -127,12 +225,37 @@ func printHeaderFS() {
 }
 
 func printFS(stats *grpcClient.Stats) {
-	fmt.Printf("%s |       |       |\n", formatTime(stats))
-	for _, fs := range stats.UsedFs {
-		fmt.Printf("         | %5.2f | %5.2f | %s\n", fs.UsedSpace, fs.UsedInode, fs.Path)
+	fmt.Printf("%s |       |       | %s\n", formatTime(stats), formatState(stats.UsedFsState))
+	printFSValues("        ", stats.UsedFs)
+	for _, agg := range stats.Aggregated {
+		printFSValues(formatAggregation(agg), agg.UsedFs)
+	}
+}
+
+func printFSValues(label string, fss []*grpcClient.UsedFS) {
+	for _, fs := range fss {
+		fmt.Printf("%s | %5.2f | %5.2f | %s\n", label, fs.UsedSpace, fs.UsedInode, fs.Path)
 	}
 }
 
 func formatTime(stats *grpcClient.Stats) string {
 	return stats.Time.AsTime().Format("15:04:05")
 }
+
+// название агрегации выравнивается по ширине колонки времени.
+func formatAggregation(agg *grpcClient.Aggregated) string {
+	name := strings.ToLower(strings.TrimPrefix(agg.Aggregation.String(), "AGG_"))
+	return fmt.Sprintf("%8s", name)
+}
+
+func formatState(state *grpcClient.MetricState) string {
+	if state == nil {
+		return ""
+	}
+
+	result := strings.ToLower(strings.TrimPrefix(state.Status.String(), "STATUS_"))
+	if state.Message != "" {
+		result += ": " + state.Message
+	}
+	return result
+}
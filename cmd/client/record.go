@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	grpcClient "github.com/anfilat/final-stats/internal/grpc"
+)
+
+// runRecord пишет поток статистики в файл, который воспроизводится командой symo replay.
+// По умолчанию пишется каждая секунда, такая запись воспроизводится точно. Запись идет до Ctrl+C.
+func runRecord(args []string) error {
+	var output string
+	recordN, recordM := 1, 1
+
+	flags := flag.NewFlagSet("record", flag.ContinueOnError)
+	flags.StringVar(&output, "o", "", "Path to the output file")
+	flags.IntVar(&recordN, "n", recordN, "Record stats every N seconds")
+	flags.IntVar(&recordM, "m", recordM, "Record stats for last M seconds")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if output == "" {
+		return errors.New("output file is required")
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	conn, err := dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+		<-signals
+		cancel()
+	}()
+
+	client := grpcClient.NewSymoClient(conn)
+	reqClient, err := client.GetStats(ctx, &grpcClient.StatsRequest{
+		N: int32(recordN),
+		M: int32(recordM),
+	})
+	if err != nil {
+		return fmt.Errorf("client request fail: %w", err)
+	}
+
+	writer := grpcClient.NewRecordWriter(file)
+	count := 0
+	defer func() {
+		log.Printf("%d stats recorded to %s", count, output)
+	}()
+	for {
+		stats, err := reqClient.Recv()
+		if errors.Is(err, io.EOF) || status.Code(err) == codes.Canceled {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error: %w", err)
+		}
+		if err := writer.Write(stats); err != nil {
+			return err
+		}
+		count++
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	grpcClient "github.com/anfilat/final-stats/internal/grpc"
+)
+
+// runReload просит сервер перечитать файл конфига, как по сигналу SIGHUP.
+func runReload() error {
+	conn, err := dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	client := grpcClient.NewAdminClient(conn)
+	resp, err := client.ReloadConfig(context.Background(), &grpcClient.ReloadRequest{})
+	if err != nil {
+		return fmt.Errorf("client request fail: %w", err)
+	}
+
+	fmt.Println("configuration is reloaded")
+	if len(resp.RestartRequired) > 0 {
+		fmt.Println("changes in", strings.Join(resp.RestartRequired, ", "), "are applied after restart")
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	grpcClient "github.com/anfilat/final-stats/internal/grpc"
+)
+
+// runSelf каждые every запрашивает метрики самого сервера и выводит их.
+func runSelf(every time.Duration) error {
+	if every <= 0 {
+		return errors.New("N must be greater than 0")
+	}
+	fmt.Println("Symo")
+
+	conn, err := dial()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	client := grpcClient.NewSymoClient(conn)
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		stats, err := client.GetSelfStats(context.Background(), &grpcClient.SelfStatsRequest{})
+		if err != nil {
+			return fmt.Errorf("client request fail: %w", err)
+		}
+		printSelf(time.Now(), stats)
+		<-ticker.C
+	}
+}
+
+func printSelf(now time.Time, stats *grpcClient.SelfStats) {
+	fmt.Printf("%s | ticks %d, dropped %d, last %v, lag %v\n", now.Format("15:04:05"),
+		stats.Ticks, stats.DroppedTicks, stats.TickDuration.AsDuration(), stats.TickLag.AsDuration())
+	for _, sampler := range stats.Samplers {
+		fmt.Printf("         | %-9s samples %d, errors %d, timeouts %d, last %v\n", sampler.Metric,
+			sampler.Samples, sampler.Errors, sampler.Timeouts, sampler.Latency.AsDuration())
+	}
+	fmt.Printf("         | clients %d, dropped sends %d, last send %v\n",
+		stats.Clients, stats.DroppedSends, stats.SendDuration.AsDuration())
+	for _, interval := range stats.Intervals {
+		fmt.Printf("         |   every %v for %v: %d\n",
+			interval.N.AsDuration(), interval.M.AsDuration(), interval.Clients)
+	}
+	fmt.Printf("         | points %d, ticks %d, dropped writes %d, heap %.1f MB, goroutines %d\n",
+		stats.PointsRetained, stats.TicksRetained, stats.DroppedWrites, float64(stats.HeapBytes)/(1<<20), stats.Goroutines)
+}
@@ -25,6 +25,10 @@ var mFrom int
 var mTo int
 var count int
 var loadTime int
+var serverAddr string
+var serverConfig string
+var clientTLS grpcClient.ClientTLS
+var token string
 
 func init() {
 	flag.IntVar(&nFrom, "nf", 1, "Send stats every N seconds. Low limit")
@@ -33,6 +37,14 @@ func init() {
 	flag.IntVar(&mTo, "mt", 30, "Send stats for last M seconds. High limit")
 	flag.IntVar(&count, "count", 100_000, "Count of clients")
 	flag.IntVar(&loadTime, "time", 600, "Wait stats for time seconds")
+	flag.StringVar(&serverAddr, "addr", ":8000", "Server address: host:port or unix:///path")
+	flag.StringVar(&serverConfig, "config", "", "Path to configuration file the server is started with")
+	flag.BoolVar(&clientTLS.Enabled, "tls", false, "Connect over TLS, verifying the server with system CAs if -cacert is empty")
+	flag.StringVar(&clientTLS.CA, "cacert", "", "Path to CA certificate the server certificate is signed with. Enables TLS")
+	flag.StringVar(&clientTLS.Cert, "cert", "", "Path to client certificate for mutual TLS. Enables TLS")
+	flag.StringVar(&clientTLS.Key, "key", "", "Path to client private key for mutual TLS")
+	flag.StringVar(&clientTLS.ServerName, "servername", "", "Server name to verify the certificate against")
+	flag.StringVar(&token, "token", "", "Bearer token from the server access list")
 }
 
 func main() {
@@ -40,7 +52,11 @@ func main() {
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	cmd := exec.CommandContext(ctx, "./bin/symo")
+	var args []string
+	if serverConfig != "" {
+		args = append(args, "-config", serverConfig)
+	}
+	cmd := exec.CommandContext(ctx, "./bin/symo", args...)
 	cmd.Env = append(os.Environ(),
 		"LOG_LEVEL=INFO",
 	)
@@ -69,7 +85,15 @@ func main() {
 }
 
 func load() {
-	conn, err := grpc.Dial(":8000", grpc.WithInsecure())
+	opt, err := clientTLS.DialOption()
+	if err != nil {
+		log.Fatal(err)
+	}
+	opts := []grpc.DialOption{opt}
+	if token != "" {
+		opts = append(opts, grpcClient.WithToken(token, serverAddr))
+	}
+	conn, err := grpc.Dial(serverAddr, opts...)
 	if err != nil {
 		log.Fatal(err)
 	}
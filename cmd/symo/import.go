@@ -0,0 +1,24 @@
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/anfilat/final-stats/internal/dump"
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+// importPoints загружает в хранилище дамп, выгруженный командой client export.
+func importPoints(points symo.PointsStore, path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	source, err := dump.NewReader(file)
+	if err != nil {
+		return 0, err
+	}
+	return dump.Seed(points, source, time.Now().Truncate(time.Second))
+}
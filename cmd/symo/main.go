@@ -7,24 +7,32 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/benbjohnson/clock"
 
 	"github.com/anfilat/final-stats/internal/clients"
 	"github.com/anfilat/final-stats/internal/collector"
 	"github.com/anfilat/final-stats/internal/cpu"
+	"github.com/anfilat/final-stats/internal/exporter"
+	"github.com/anfilat/final-stats/internal/gateway"
 	"github.com/anfilat/final-stats/internal/grpc"
 	"github.com/anfilat/final-stats/internal/loadavg"
 	"github.com/anfilat/final-stats/internal/loaddisks"
 	"github.com/anfilat/final-stats/internal/logger"
+	"github.com/anfilat/final-stats/internal/prometheus"
+	"github.com/anfilat/final-stats/internal/selfstats"
+	"github.com/anfilat/final-stats/internal/store"
 	"github.com/anfilat/final-stats/internal/symo"
 	"github.com/anfilat/final-stats/internal/usedfs"
 )
 
 var configFile string
+var importFile string
 
 func init() {
 	flag.StringVar(&configFile, "config", "", "Path to configuration file")
+	flag.StringVar(&importFile, "import", "", "Path to a dump made by client export to seed the store with")
 }
 
 func main() {
@@ -35,6 +43,11 @@ func main() {
 		os.Exit(0)
 	}
 
+	replayCmd, err := parseReplayCommand()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	mainCtx, cancel := context.WithCancel(context.Background())
 
 	go watchSignals(mainCtx, cancel)
@@ -44,13 +57,16 @@ func main() {
 		log.Fatal(err)
 	}
 
-	logg, err := logger.New(config.Log.Level)
+	logg, err := logger.New(config.Log)
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	logg.Info("starting system monitor")
 	logg.Info("time to keep metrics: ", config.App.MaxSeconds, " seconds")
+	for _, tier := range config.App.Tiers {
+		logg.Info("time to keep metrics averaged over ", tier.Resolution, ": ", tier.Retention)
+	}
 
 	stopper := newServiceStopper()
 
@@ -61,19 +77,88 @@ func main() {
 		UsedFS:    usedfs.Collect,
 	}
 
+	// при воспроизведении время сервисов идет по записи
+	clk := clock.New()
+	var replayClock *clock.Mock
+	var replaySource symo.PointsSource
+	if replayCmd != nil {
+		file, err := os.Open(replayCmd.input)
+		if err != nil {
+			logg.Fatal(err)
+		}
+		defer file.Close()
+
+		replaySource, replayClock, err = openReplay(grpc.NewRecordReader(file))
+		if err != nil {
+			logg.Fatal(err)
+		}
+		clk = replayClock
+	}
+
 	toClientsCh := make(symo.CollectorToClientsCh, 1)
 
-	clientsService := clients.NewClients(logg, clock.New())
+	clientsService := clients.NewClients(logg.With(symo.LogComponent, "clients"), clk, config)
 	clientsService.Start(mainCtx, toClientsCh)
 	stopper.add(clientsService.Stop)
 
-	collectorService := collector.NewCollector(logg, config)
+	points := store.NewTieredStore(config.App.MaxSeconds, config.App.Tiers)
+	var disk symo.StoreStater
+	if config.Store.Backend == "disk" && replayCmd != nil {
+		logg.Info("disk store is not used in replay mode")
+	}
+	if config.Store.Backend == "disk" && replayCmd == nil {
+		diskStore, err := store.NewDiskStore(logg.With(symo.LogComponent, "store"), config.Store.Dir, config.App.MaxSeconds, points)
+		if err != nil {
+			logg.Fatal(err)
+		}
+		stopper.add(diskStore.Stop)
+		points = diskStore
+		disk = diskStore
+	}
+	if importFile != "" && replayCmd != nil {
+		logg.Info("import is not used in replay mode")
+	}
+	if importFile != "" && replayCmd == nil {
+		count, err := importPoints(points, importFile)
+		if err != nil {
+			logg.Fatal(err)
+		}
+		logg.Info("imported ", count, " points from ", importFile)
+	}
+
+	// точки тиков короче секунды хранятся отдельно от посекундных
+	var ticks symo.PointsStore
+	if config.App.Tick < time.Second {
+		ticks = store.NewTickStore(config.App.Tick, symo.TickSeconds)
+		logg.Info("collector tick: ", config.App.Tick)
+	}
+	collectorService := collector.NewCollector(logg.With(symo.LogComponent, "collector"), config, points, ticks)
+	if replayCmd != nil {
+		logg.Info("replaying ", replayCmd.input, " at speed ", replayCmd.speed)
+		collectorService = collector.NewReplay(logg.With(symo.LogComponent, "replay"), points, replaySource, replayClock,
+			replayCmd.speed)
+	}
 	collectorService.Start(mainCtx, collectors, toClientsCh)
 	stopper.add(collectorService.Stop)
 
-	grpcServer := grpc.NewServer(logg, config)
+	selfStats := selfstats.NewSelfStats(collectorService, clientsService, points, ticks, disk)
+
+	reloader := newConfigReloader(logg, configFile, config, points)
+	reloader.add(collectorService)
+
+	for _, conf := range config.Exporters {
+		expLog := logg.With(symo.LogComponent, "exporter").With(symo.LogExporter, conf.Name)
+		exp := exporter.NewExporter(expLog, conf)
+		if err := exp.Start(clientsService); err != nil {
+			logg.Fatal(err)
+		}
+		stopper.add(exp.Stop)
+	}
+
+	grpcServer := grpc.NewServer(logg.With(symo.LogComponent, "grpc"), config, clk)
+	reloader.add(grpcServer)
 	go func() {
-		err := grpcServer.Start(":"+config.Server.Port, clientsService)
+		err := grpcServer.Start(config.Server.ListenAddrs(), clientsService, points, selfStats, reloader)
 		if err != nil {
 			logg.Error(err)
 			cancel()
@@ -82,6 +167,36 @@ func main() {
 	}()
 	stopper.add(grpcServer.Stop)
 
+	if config.HTTP.Enabled {
+		httpServer := gateway.NewServer(logg.With(symo.LogComponent, "http"), config)
+		reloader.add(httpServer)
+		go func() {
+			err := httpServer.Start(":"+config.HTTP.Port, clientsService)
+			if err != nil {
+				logg.Error(err)
+				cancel()
+				return
+			}
+		}()
+		stopper.add(httpServer.Stop)
+	}
+
+	if config.Prometheus.Enabled {
+		prometheusServer := prometheus.NewServer(logg.With(symo.LogComponent, "prometheus"), config, clk)
+		reloader.add(prometheusServer)
+		go func() {
+			err := prometheusServer.Start(":"+config.Prometheus.Port, points, selfStats)
+			if err != nil {
+				logg.Error(err)
+				cancel()
+				return
+			}
+		}()
+		stopper.add(prometheusServer.Stop)
+	}
+
+	go watchReload(mainCtx, reloader, logg)
+
 	logg.Info("system monitor is running...")
 
 	<-mainCtx.Done()
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+// configReloader перечитывает файл конфига по SIGHUP и по запросу сервиса администрирования.
+// Сразу применяются уровень логирования, время хранения посекундных метрик и набор собираемых метрик,
+// остальные секции - после перезапуска.
+type configReloader struct {
+	mutex      *sync.Mutex
+	configFile string
+	config     symo.Config // работающий конфиг
+	points     symo.PointsStore
+	services   []symo.Reloader
+	log        symo.LevelLogger
+}
+
+func newConfigReloader(log symo.LevelLogger, configFile string, config symo.Config,
+	points symo.PointsStore) *configReloader {
+	return &configReloader{
+		mutex:      &sync.Mutex{},
+		configFile: configFile,
+		config:     config,
+		points:     points,
+		log:        log,
+	}
+}
+
+func (r *configReloader) add(service symo.Reloader) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.services = append(r.services, service)
+}
+
+func (r *configReloader) ReloadConfig(ctx context.Context) ([]string, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.configFile == "" {
+		return nil, errors.New("configuration file is not set")
+	}
+	config, err := symo.NewConfig(r.configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	// новое время хранения проверяется вместе с работающими уровнями хранения и экспортерами
+	applied := r.config
+	applied.Log.Level = config.Log.Level
+	applied.App.MaxSeconds = config.App.MaxSeconds
+	applied.Metric = config.Metric
+	if err := applied.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to validate configuration: %w", err)
+	}
+
+	if err := r.log.SetLevel(applied.Log.Level); err != nil {
+		return nil, err
+	}
+	if applied.App.MaxSeconds != r.config.App.MaxSeconds {
+		r.points.Resize(applied.App.MaxSeconds)
+		r.log.Info("time to keep metrics: ", applied.App.MaxSeconds, " seconds")
+	}
+	for _, service := range r.services {
+		service.Reload(ctx, applied)
+	}
+	r.config = applied
+
+	restart := restartRequired(applied, config)
+	r.log.Info("configuration is reloaded")
+	if len(restart) > 0 {
+		r.log.Info("changes in ", strings.Join(restart, ", "), " are applied after restart")
+	}
+	return restart, nil
+}
+
+// restartRequired возвращает секции конфига, которые отличаются от работающих и применяются только перезапуском.
+func restartRequired(running, config symo.Config) []string {
+	sections := []struct {
+		name    string
+		running interface{}
+		config  interface{}
+	}{
+		{"log", running.Log, config.Log},
+		{"app.tick", running.App.Tick, config.App.Tick},
+		{"app.tiers", running.App.Tiers, config.App.Tiers},
+		{"store", running.Store, config.Store},
+		{"server", running.Server, config.Server},
+		{"auth", running.Auth, config.Auth},
+		{"clients", running.Clients, config.Clients},
+		{"http", running.HTTP, config.HTTP},
+		{"prometheus", running.Prometheus, config.Prometheus},
+		{"exporters", running.Exporters, config.Exporters},
+	}
+
+	var result []string
+	for _, section := range sections {
+		if !reflect.DeepEqual(section.running, section.config) {
+			result = append(result, section.name)
+		}
+	}
+	return result
+}
+
+// watchReload перечитывает конфиг по сигналу SIGHUP.
+func watchReload(mainCtx context.Context, reloader symo.ConfigReloader, log symo.Logger) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-mainCtx.Done():
+			return
+		case <-signals:
+			if _, err := reloader.ReloadConfig(mainCtx); err != nil {
+				log.Error(fmt.Errorf("failed to reload configuration: %w", err))
+			}
+		}
+	}
+}
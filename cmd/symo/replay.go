@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"time"
+
+	"github.com/benbjohnson/clock"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+// replayCommand содержит параметры режима воспроизведения записи: symo replay -i file [-speed X].
+type replayCommand struct {
+	input string
+	speed float64
+}
+
+// parseReplayCommand возвращает параметры воспроизведения или nil, если программа запущена не командой replay.
+func parseReplayCommand() (*replayCommand, error) {
+	args := flag.Args()
+	if len(args) == 0 || args[0] != "replay" {
+		return nil, nil
+	}
+
+	cmd := &replayCommand{}
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	flags.StringVar(&cmd.input, "i", "", "Path to the file recorded by `client record`")
+	flags.Float64Var(&cmd.speed, "speed", 1, "Replay speed, 1 is real time")
+	if err := flags.Parse(args[1:]); err != nil {
+		return nil, err
+	}
+
+	if cmd.input == "" {
+		return nil, errors.New("replay file is required")
+	}
+	if cmd.speed <= 0 {
+		return nil, errors.New("replay speed must be greater than zero")
+	}
+	return cmd, nil
+}
+
+// openReplay возвращает точки записи и часы воспроизведения, выставленные на время первой точки.
+// Тикеры сервисов создаются до начала воспроизведения, и с часами в начале эпохи первый перевод часов
+// на время записи отработал бы в них все секунды с 1970 года.
+func openReplay(source symo.PointsSource) (symo.PointsSource, *clock.Mock, error) {
+	replayClock := clock.NewMock()
+
+	tm, point, err := source.Next()
+	if errors.Is(err, io.EOF) {
+		return source, replayClock, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	replayClock.Set(tm)
+	return &peekedSource{source: source, tm: tm, point: point, peeked: true}, replayClock, nil
+}
+
+// peekedSource сначала возвращает уже прочитанную первую точку записи, затем остальные.
+type peekedSource struct {
+	source symo.PointsSource
+	tm     time.Time
+	point  symo.Point
+	peeked bool
+}
+
+func (s *peekedSource) Next() (time.Time, symo.Point, error) {
+	if s.peeked {
+		s.peeked = false
+		return s.tm, s.point, nil
+	}
+	return s.source.Next()
+}
@@ -0,0 +1,199 @@
+package clients
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+// snapshots кэширует снапшоты одного тика, чтобы для клиентов с одинаковыми M и агрегациями
+// они считались один раз. Посекундные значения читаются из хранилища только для агрегаций, кроме среднего.
+type snapshots struct {
+	data  *symo.MetricsData
+	means map[time.Duration]*symo.Stats
+	aggs  map[aggKey]symo.AggregatedData
+	stats map[statsKey]*symo.Stats
+	buf   []float64 // для сортировки значений при подсчете перцентилей
+}
+
+type aggKey struct {
+	m   time.Duration
+	agg symo.Aggregation
+}
+
+type statsKey struct {
+	m    time.Duration
+	aggs symo.Aggregations
+}
+
+func newSnapshots(data *symo.MetricsData) *snapshots {
+	return &snapshots{
+		data:  data,
+		means: make(map[time.Duration]*symo.Stats),
+		aggs:  make(map[aggKey]symo.AggregatedData),
+		stats: make(map[statsKey]*symo.Stats),
+	}
+}
+
+// get возвращает статистику за M с запрошенными агрегациями.
+func (s *snapshots) get(m time.Duration, aggs symo.Aggregations) *symo.Stats {
+	key := statsKey{m: m, aggs: aggs}
+	if stats, ok := s.stats[key]; ok {
+		return stats
+	}
+
+	stats := s.mean(m)
+	if list := aggs.List(); len(list) > 0 {
+		withAggs := *stats
+		withAggs.Aggregated = make([]symo.AggregatedData, 0, len(list))
+		for _, agg := range list {
+			withAggs.Aggregated = append(withAggs.Aggregated, s.aggregated(m, agg))
+		}
+		stats = &withAggs
+	}
+
+	s.stats[key] = stats
+	return stats
+}
+
+func (s *snapshots) mean(m time.Duration) *symo.Stats {
+	stats, ok := s.means[m]
+	if !ok {
+		stats = makeSnapshot(s.data, m)
+		s.means[m] = stats
+	}
+	return stats
+}
+
+func (s *snapshots) aggregated(m time.Duration, agg symo.Aggregation) symo.AggregatedData {
+	key := aggKey{m: m, agg: agg}
+	data, ok := s.aggs[key]
+	if !ok {
+		a := aggregator{agg: agg, buf: s.buf}
+		points, slots := pointsFor(s.data, m)
+		points.Window(s.data.Time, slots, func(window symo.Window) {
+			data = a.aggregated(window)
+		})
+		s.buf = a.buf
+		s.aggs[key] = data
+	}
+	return data
+}
+
+// aggregator сворачивает колонки окна, читая их из памяти хранилища.
+type aggregator struct {
+	agg symo.Aggregation
+	buf []float64
+}
+
+func (a *aggregator) aggregated(window symo.Window) symo.AggregatedData {
+	return symo.AggregatedData{
+		Aggregation: a.agg,
+		LoadAvg:     a.loadAvg(window.LoadAvg),
+		CPU:         a.cpu(window.CPU),
+		LoadDisks:   a.loadDisks(window.LoadDisks),
+		UsedFS:      a.usedFS(window.UsedFS),
+	}
+}
+
+func (a *aggregator) loadAvg(columns symo.Columns) *symo.LoadAvgData {
+	if columns == nil {
+		return nil
+	}
+	return &symo.LoadAvgData{
+		Load1:  a.aggregate(columns[0]),
+		Load5:  a.aggregate(columns[1]),
+		Load15: a.aggregate(columns[2]),
+	}
+}
+
+func (a *aggregator) cpu(columns symo.Columns) *symo.CPUData {
+	if columns == nil {
+		return nil
+	}
+	return &symo.CPUData{
+		User:   a.aggregate(columns[0]),
+		System: a.aggregate(columns[1]),
+		Idle:   a.aggregate(columns[2]),
+	}
+}
+
+func (a *aggregator) loadDisks(disks []symo.NamedColumns) symo.LoadDisksData {
+	var result symo.LoadDisksData
+	for _, disk := range disks {
+		result = append(result, symo.DiskData{
+			Name:    disk.Name,
+			Tps:     a.aggregate(disk.Columns[0]),
+			KBRead:  a.aggregate(disk.Columns[1]),
+			KBWrite: a.aggregate(disk.Columns[2]),
+		})
+	}
+	return result
+}
+
+func (a *aggregator) usedFS(fss []symo.NamedColumns) symo.UsedFSData {
+	var result symo.UsedFSData
+	for _, fs := range fss {
+		result = append(result, symo.FSData{
+			Path:      fs.Name,
+			UsedSpace: a.aggregate(fs.Columns[0]),
+			UsedInode: a.aggregate(fs.Columns[1]),
+		})
+	}
+	return result
+}
+
+// aggregate сворачивает непустую колонку. Значения копируются только для перцентилей, в переиспользуемый буфер.
+func (a *aggregator) aggregate(column symo.Column) float64 {
+	var p float64
+	switch a.agg {
+	case symo.AggP50:
+		p = 50
+	case symo.AggP95:
+		p = 95
+	case symo.AggP99:
+		p = 99
+	default:
+		return fold(column, a.agg)
+	}
+
+	a.buf = a.buf[:0]
+	column.Each(func(value float64) {
+		a.buf = append(a.buf, value)
+	})
+	sort.Float64s(a.buf)
+	return nearestRank(a.buf, p)
+}
+
+// fold сворачивает колонку за один проход.
+func fold(column symo.Column, agg symo.Aggregation) float64 {
+	var result, sum float64
+	count := 0
+	column.Each(func(value float64) {
+		switch {
+		case count == 0 || agg == symo.AggLast:
+			result = value
+		case agg == symo.AggMin:
+			result = math.Min(result, value)
+		case agg == symo.AggMax:
+			result = math.Max(result, value)
+		}
+		sum += value
+		count++
+	})
+	if agg == symo.AggMean {
+		return sum / float64(count)
+	}
+	return result
+}
+
+// nearestRank возвращает перцентиль отсортированного ряда, посчитанный методом ближайшего ранга.
+func nearestRank(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
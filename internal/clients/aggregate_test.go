@@ -0,0 +1,130 @@
+package clients
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+func TestAggregate(t *testing.T) {
+	// колонка из двух кусков кольцевого буфера с пропущенными секундами
+	column := symo.Column{
+		{5, 1, math.NaN(), 9, 3, 7},
+		{2, math.NaN(), 8, 4, 6, 10},
+	}
+
+	tests := []struct {
+		agg      symo.Aggregation
+		expected float64
+	}{
+		{agg: symo.AggMean, expected: 5.5},
+		{agg: symo.AggMin, expected: 1},
+		{agg: symo.AggMax, expected: 10},
+		{agg: symo.AggP50, expected: 5},
+		{agg: symo.AggP95, expected: 10},
+		{agg: symo.AggP99, expected: 10},
+		{agg: symo.AggLast, expected: 10},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.agg.String(), func(t *testing.T) {
+			a := aggregator{agg: tt.agg}
+			require.InDelta(t, tt.expected, a.aggregate(column), 0.001)
+		})
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := make([]float64, 0, 100)
+	for i := 100; i > 0; i-- {
+		values = append(values, float64(i))
+	}
+
+	column := symo.Column{values}
+
+	require.Equal(t, 50.0, (&aggregator{agg: symo.AggP50}).aggregate(column))
+	require.Equal(t, 95.0, (&aggregator{agg: symo.AggP95}).aggregate(column))
+	require.Equal(t, 99.0, (&aggregator{agg: symo.AggP99}).aggregate(column))
+	require.Equal(t, 7.0, (&aggregator{agg: symo.AggP99}).aggregate(symo.Column{{7}}))
+	// колонка в памяти хранилища не меняется
+	require.Equal(t, 100.0, values[0])
+}
+
+func TestSnapshotsWithAggregations(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	data := &symo.MetricsData{
+		Time: now,
+		Points: storeOf(points{
+			now.Add(-time.Second): {
+				LoadAvg:   &la1,
+				CPU:       &cpu1,
+				LoadDisks: ld1,
+				UsedFS:    fs1,
+			},
+			now.Add(-2 * time.Second): {
+				LoadAvg:   &la2,
+				CPU:       &cpu2,
+				LoadDisks: ld3,
+				UsedFS:    fs3,
+			},
+			now.Add(-3 * time.Second): {
+				CPU: &cpu1,
+			},
+		}),
+	}
+
+	results := newSnapshots(data)
+
+	stats := results.get(2*time.Second, symo.NewAggregations(symo.AggMin, symo.AggMax, symo.AggLast))
+	require.InEpsilon(t, laSum12.Load1, stats.LoadAvg.Load1, 0.001)
+	require.Len(t, stats.Aggregated, 3)
+
+	minData := stats.Aggregated[0]
+	require.Equal(t, symo.AggMin, minData.Aggregation)
+	require.Equal(t, &la1, minData.LoadAvg)
+	require.Equal(t, &cpu1, minData.CPU)
+	require.Equal(t, ld1[0], *findLoadDisk("sda", minData.LoadDisks))
+	require.Equal(t, ld3[2], *findLoadDisk("sdc", minData.LoadDisks))
+	require.Equal(t, fs1[0], *findUsedFS("/", minData.UsedFS))
+
+	maxData := stats.Aggregated[1]
+	require.Equal(t, symo.AggMax, maxData.Aggregation)
+	require.Equal(t, &la2, maxData.LoadAvg)
+	require.Equal(t, &cpu2, maxData.CPU)
+	require.Equal(t, ld3[0], *findLoadDisk("sda", maxData.LoadDisks))
+	require.Equal(t, fs3[2], *findUsedFS("/mount/c", maxData.UsedFS))
+
+	lastData := stats.Aggregated[2]
+	require.Equal(t, symo.AggLast, lastData.Aggregation)
+	require.Equal(t, &la1, lastData.LoadAvg)
+	require.Equal(t, &cpu1, lastData.CPU)
+	require.Equal(t, ld3[2], *findLoadDisk("sdc", lastData.LoadDisks))
+
+	// одинаковые запросы возвращают один и тот же снапшот
+	require.Same(t, stats, results.get(2*time.Second, symo.NewAggregations(symo.AggMin, symo.AggMax, symo.AggLast)))
+	// снапшот без агрегаций не содержит их
+	plain := results.get(2*time.Second, 0)
+	require.Nil(t, plain.Aggregated)
+	require.NotSame(t, stats, plain)
+
+	// окно в 3 секунды включает точку только с cpu
+	stats = results.get(3*time.Second, symo.NewAggregations(symo.AggMin))
+	require.Equal(t, &la1, stats.Aggregated[0].LoadAvg)
+	require.Equal(t, &cpu1, stats.Aggregated[0].CPU)
+}
+
+func TestAggregationsWithoutData(t *testing.T) {
+	a := aggregator{agg: symo.AggMax}
+	data := a.aggregated(symo.Window{})
+
+	require.Equal(t, symo.AggMax, data.Aggregation)
+	require.Nil(t, data.LoadAvg)
+	require.Nil(t, data.CPU)
+	require.Nil(t, data.LoadDisks)
+	require.Nil(t, data.UsedFS)
+}
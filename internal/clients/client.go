@@ -6,28 +6,58 @@ import (
 	"github.com/anfilat/final-stats/internal/symo"
 )
 
-const maxQueueLen = 100
-
 type clientsList []*grpcClient
 
 // данные клиента.
 type grpcClient struct {
-	n     int              // информация отправляется каждые N секунд
-	m     int              // информация усредняется за M секунд
-	ch    chan *symo.Stats // переданный клиенту канал
-	after time.Time        // когда отправлять следующий пакет данных
-	dead  bool             // контекст клиента закрыт, нужно удалить этого клиента из списка
+	n         time.Duration     // информация отправляется каждые N
+	m         time.Duration     // информация усредняется за M
+	step      time.Duration     // шаг расписания: секунда для клиентов с целыми N и M, иначе тик
+	aggs      symo.Aggregations // дополнительные агрегации за M секунд
+	policy    symo.DropPolicy   // что делать при переполнении очереди
+	dropLimit int               // сколько пакетов подряд можно пропустить до отключения
+	ch        chan *symo.Stats  // переданный клиенту канал
+	after     time.Time         // когда отправлять следующий пакет данных
+	seq       uint64            // номер последнего пакета
+	pending   uint64            // отброшенные пакеты, о которых клиент еще не узнал
+	overflows int               // сколько пакетов подряд не поместилось в очередь
+	once      bool              // после первого пакета клиент отключается
+	dead      bool              // контекст клиента закрыт, нужно удалить этого клиента из списка
+	peer      string            // адрес клиента
+	exporter  bool              // экспортер, не занимает места в ограничениях подключений
+	released  bool              // место клиента в ограничениях подключений освобождено
 }
 
-func newClient(cl symo.ClientData, now time.Time) *grpcClient {
-	ch := make(chan *symo.Stats, maxQueueLen)
+func newClient(cl symo.ClientData, conf symo.ClientsConf, now time.Time, tick time.Duration) *grpcClient {
+	ch := make(chan *symo.Stats, conf.QueueSize)
 	client := &grpcClient{
-		n:    cl.N,
-		m:    cl.M,
-		ch:   ch,
-		dead: false,
+		n:         cl.N,
+		m:         cl.M,
+		aggs:      cl.Aggs,
+		policy:    cl.Policy,
+		dropLimit: cl.DropLimit,
+		once:      cl.Once,
+		peer:      cl.Peer,
+		exporter:  cl.Exporter,
+		ch:        ch,
+		dead:      false,
+	}
+	if client.policy == symo.PolicyDefault {
+		client.policy, _ = symo.ParseDropPolicy(conf.Policy)
+	}
+	if client.dropLimit <= 0 {
+		client.dropLimit = conf.DropLimit
+	}
+	client.step = time.Second
+	if !cl.WholeSeconds() {
+		client.step = tick
+	}
+	now = now.Truncate(client.step)
+	client.after = now.Add(client.m - client.step)
+	if client.once {
+		// данные за M уже накоплены, пакет отправляется на ближайшем шаге
+		client.after = now
 	}
-	client.after = now.Add(time.Duration(client.m-1) * time.Second)
 	return client
 }
 
@@ -35,10 +65,86 @@ func (g *grpcClient) close() {
 	close(g.ch)
 }
 
+// isReady сообщает, пора ли отправлять клиенту пакет. Клиенту с целыми N и M пакеты отправляются
+// только в начале секунды, даже если тик короче.
 func (g *grpcClient) isReady(now time.Time) bool {
-	return now.After(g.after)
+	return now.Equal(now.Truncate(g.step)) && now.After(g.after)
 }
 
 func (g *grpcClient) setNextReady(now time.Time) {
-	g.after = now.Add(time.Duration(g.n-1) * time.Second)
+	g.after = now.Add(g.n - g.step)
+}
+
+// skipped возвращает, сколько отправок клиенту не состоялось из-за пропущенных тиков.
+func (g *grpcClient) skipped(now time.Time) uint64 {
+	due := g.after.Add(g.step)
+	if !now.After(due) {
+		return 0
+	}
+	return uint64(now.Sub(due) / g.n)
+}
+
+// send ставит пакет в очередь клиента. Если очередь заполнена, поступает согласно политике клиента.
+// Возвращает количество отброшенных пакетов и false, если клиент отключен.
+func (g *grpcClient) send(stats *symo.Stats, now time.Time) (uint64, bool) {
+	dropped := g.skipped(now)
+	g.seq += dropped + 1
+	g.pending += dropped
+
+	frame := *stats
+	frame.Seq = g.seq
+	frame.Dropped = g.pending
+
+	select {
+	case g.ch <- &frame:
+		g.overflows = 0
+		g.pending = 0
+		return dropped, true
+	default:
+	}
+
+	switch g.policy {
+	case symo.PolicyDropOldest:
+		// о вытесненном пакете и об отброшенных перед ним клиент узнает из нового
+		select {
+		case old := <-g.ch:
+			frame.Dropped += old.Dropped + 1
+		default:
+		}
+		select {
+		case g.ch <- &frame:
+			g.pending = 0
+		default:
+			g.pending = frame.Dropped + 1
+		}
+		return dropped + 1, true
+	case symo.PolicyDisconnect:
+		g.overflows++
+		g.pending++
+		if g.overflows >= g.dropLimit {
+			g.disconnect(symo.ErrOverflow)
+			return dropped + 1, false
+		}
+		return dropped + 1, true
+	default:
+		g.pending++
+		return dropped + 1, true
+	}
+}
+
+// disconnect освобождает очередь, отправляет клиенту ошибку и закрывает канал.
+func (g *grpcClient) disconnect(err error) {
+	for len(g.ch) > 0 {
+		select {
+		case <-g.ch:
+		default:
+		}
+	}
+
+	g.seq++
+	select {
+	case g.ch <- &symo.Stats{Seq: g.seq, Err: err}:
+	default:
+	}
+	g.close()
 }
@@ -2,6 +2,7 @@ package clients
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
@@ -11,21 +12,30 @@ import (
 )
 
 type clients struct {
-	ctx         context.Context // управление остановкой сервиса
-	ctxCancel   context.CancelFunc
-	closedCh    chan interface{}
-	mutex       *sync.Mutex
-	clients     clientsList // список клиентов
-	toClientsCh <-chan symo.MetricsData
-	log         symo.Logger
-	clock       clock.Clock
+	ctx          context.Context // управление остановкой сервиса
+	ctxCancel    context.CancelFunc
+	closedCh     chan interface{}
+	mutex        *sync.Mutex
+	clients      clientsList // список клиентов
+	toClientsCh  <-chan symo.MetricsData
+	droppedTicks uint64            // сколько тиков пропустил сервис сбора метрик, по последним полученным данным
+	droppedSends uint64            // сколько всего пакетов не попало к клиентам
+	sendDuration time.Duration     // сколько длилась рассылка последнего тика
+	count        int               // подключенные клиенты с адресом, без экспортеров
+	peers        map[string]int    // подключенные клиенты по адресам
+	rejected     map[string]uint64 // отклоненные подключения по причинам
+	subscribing  *limiter          // ограничение частоты новых подключений
+	config       symo.Config
+	log          symo.Logger
+	clock        clock.Clock
 }
 
 // NewClients возвращает сервис клиентов.
-func NewClients(log symo.Logger, clock clock.Clock) symo.Clients {
+func NewClients(log symo.Logger, clock clock.Clock, config symo.Config) symo.Clients {
 	return &clients{
-		log:   log,
-		clock: clock,
+		config: config,
+		log:    log,
+		clock:  clock,
 	}
 }
 
@@ -36,6 +46,13 @@ func (c *clients) Start(_ context.Context, toClientsCh <-chan symo.MetricsData)
 	c.closedCh = make(chan interface{})
 	c.mutex = &sync.Mutex{}
 	c.clients = nil
+	c.droppedTicks = 0
+	c.droppedSends = 0
+	c.sendDuration = 0
+	c.count = 0
+	c.peers = make(map[string]int)
+	c.rejected = make(map[string]uint64)
+	c.subscribing = newLimiter(c.config.Clients.SubscriptionRate, c.config.Clients.SubscriptionBurst)
 
 	go c.work()
 }
@@ -97,34 +114,121 @@ func (c *clients) NewClient(cl symo.ClientData) (<-chan *symo.Stats, func(), err
 	default:
 	}
 
-	now := c.clock.Now().Truncate(time.Second)
-	client := newClient(cl, now)
+	if err := c.admit(cl); err != nil {
+		return nil, nil, err
+	}
+
+	client := newClient(cl, c.config.Clients, c.clock.Now(), c.config.App.Tick)
 
 	c.clients = append(c.clients, client)
+	c.acquire(client)
 
 	delClient := func() {
 		c.mutex.Lock()
 		defer c.mutex.Unlock()
 
 		client.dead = true
+		c.release(client)
 	}
 
 	return client.ch, delClient, nil
 }
 
+// admit проверяет ограничения подключений из конфига. Экспортеры не ограничиваются.
+func (c *clients) admit(cl symo.ClientData) error {
+	if cl.Exporter {
+		return nil
+	}
+
+	conf := c.config.Clients
+	reason, err := "", error(nil)
+	switch {
+	case !cl.Once && cl.N < conf.MinN:
+		reason, err = "n", fmt.Errorf("%w: N must be at least %v", symo.ErrLimit, conf.MinN)
+	case conf.MaxClients > 0 && c.count >= conf.MaxClients:
+		reason, err = "clients", fmt.Errorf("%w: too many clients", symo.ErrLimit)
+	case conf.MaxPerPeer > 0 && c.peers[cl.Peer] >= conf.MaxPerPeer:
+		reason, err = "peer", fmt.Errorf("%w: too many clients from %s", symo.ErrLimit, cl.Peer)
+	case !c.subscribing.allow(c.clock.Now()):
+		reason, err = "rate", fmt.Errorf("%w: too many new clients, try later", symo.ErrLimit)
+	default:
+		return nil
+	}
+
+	c.rejected[reason]++
+	c.log.Debug("client from ", cl.Peer, " is rejected: ", err)
+	return err
+}
+
+func (c *clients) acquire(client *grpcClient) {
+	if client.exporter {
+		return
+	}
+	c.count++
+	c.peers[client.peer]++
+}
+
+// release освобождает место клиента в ограничениях. Вызывается и при отключении клиента сервисом,
+// и из функции отключения, поэтому место освобождается только один раз.
+func (c *clients) release(client *grpcClient) {
+	if client.exporter || client.released {
+		return
+	}
+	client.released = true
+	c.count--
+	c.peers[client.peer]--
+	if c.peers[client.peer] == 0 {
+		delete(c.peers, client.peer)
+	}
+}
+
+func (c *clients) ClientsStats() symo.ClientsStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	rejected := make(map[string]uint64, len(c.rejected))
+	for reason, count := range c.rejected {
+		rejected[reason] = count
+	}
+	// разовые запросы снапшота не подписываются на статистику
+	intervals := make(map[symo.ClientInterval]int)
+	for _, client := range c.clients {
+		if !client.dead && !client.once {
+			intervals[symo.ClientInterval{N: client.n, M: client.m}]++
+		}
+	}
+	return symo.ClientsStats{
+		Clients:      c.count,
+		Rejected:     rejected,
+		DroppedSends: c.droppedSends,
+		SendDuration: c.sendDuration,
+		Intervals:    intervals,
+	}
+}
+
 func (c *clients) sendStat(data *symo.MetricsData) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
+	if data.DroppedTicks > c.droppedTicks {
+		c.log.Info("collector dropped ", data.DroppedTicks-c.droppedTicks, " ticks, clients service is busy")
+		c.droppedTicks = data.DroppedTicks
+	}
+
 	if len(c.clients) == 0 {
+		c.sendDuration = 0
 		return
 	}
 
 	from := time.Now()
-	defer c.log.Debug("stats sent in ", time.Since(from))
+	defer func() {
+		c.sendDuration = time.Since(from)
+		c.log.Debug("stats sent in ", c.sendDuration)
+	}()
 
 	now := data.Time
-	results := make(map[int]*symo.Stats)
+	results := newSnapshots(data)
+	var droppedSends uint64
 
 	clients := make(clientsList, 0, len(c.clients))
 	for _, client := range c.clients {
@@ -139,16 +243,26 @@ func (c *clients) sendStat(data *symo.MetricsData) {
 		}
 		client.setNextReady(now)
 
-		stats, ok := results[client.m]
-		if !ok {
-			stats = makeSnapshot(data, client.m)
-			results[client.m] = stats
-		}
+		stats := results.get(client.m, client.aggs)
 
-		select {
-		case client.ch <- stats:
-		default:
+		dropped, alive := client.send(stats, now)
+		droppedSends += dropped
+		if !alive {
+			c.log.Debug("slow client is disconnected")
+			clients = clients[:len(clients)-1]
+			c.release(client)
+			continue
+		}
+		if client.once {
+			client.close()
+			clients = clients[:len(clients)-1]
+			c.release(client)
 		}
 	}
 	c.clients = clients
+
+	if droppedSends > 0 {
+		c.droppedSends += droppedSends
+		c.log.Debug("stats dropped for slow clients: ", droppedSends)
+	}
 }
@@ -11,6 +11,7 @@ import (
 	"go.uber.org/goleak"
 
 	"github.com/anfilat/final-stats/internal/mocks"
+	"github.com/anfilat/final-stats/internal/store"
 	"github.com/anfilat/final-stats/internal/symo"
 )
 
@@ -23,7 +24,8 @@ func TestClientsStartStop(t *testing.T) {
 	toClientsCh := make(symo.CollectorToClientsCh, 1)
 
 	startCtx := context.Background()
-	clientsService := NewClients(log, clock.NewMock())
+	config, _ := symo.NewConfig("")
+	clientsService := NewClients(log, clock.NewMock(), config)
 	clientsService.Start(startCtx, toClientsCh)
 
 	stopCtx := context.Background()
@@ -42,7 +44,8 @@ func TestClientsStartWithCanceledContext(t *testing.T) {
 
 	startCtx, cancel := context.WithCancel(context.Background())
 	cancel()
-	clientsService := NewClients(log, clock.NewMock())
+	config, _ := symo.NewConfig("")
+	clientsService := NewClients(log, clock.NewMock(), config)
 	clientsService.Start(startCtx, toClientsCh)
 
 	stopCtx := context.Background()
@@ -58,14 +61,15 @@ func TestClosingChannelsOnClose(t *testing.T) {
 	toClientsCh := make(symo.CollectorToClientsCh, 1)
 
 	startCtx := context.Background()
-	clientsService := NewClients(log, clock.NewMock())
+	config, _ := symo.NewConfig("")
+	clientsService := NewClients(log, clock.NewMock(), config)
 	clientsService.Start(startCtx, toClientsCh)
 
-	ch1, _, err := clientsService.NewClient(symo.ClientData{N: 1, M: 1})
+	ch1, _, err := clientsService.NewClient(symo.ClientData{N: 1 * time.Second, M: 1 * time.Second})
 	require.NoError(t, err)
-	ch2, _, err := clientsService.NewClient(symo.ClientData{N: 1, M: 1})
+	ch2, _, err := clientsService.NewClient(symo.ClientData{N: 1 * time.Second, M: 1 * time.Second})
 	require.NoError(t, err)
-	ch3, _, err := clientsService.NewClient(symo.ClientData{N: 1, M: 1})
+	ch3, _, err := clientsService.NewClient(symo.ClientData{N: 1 * time.Second, M: 1 * time.Second})
 	require.NoError(t, err)
 
 	stopCtx := context.Background()
@@ -89,19 +93,52 @@ func TestFailNewClientAfterClose(t *testing.T) {
 	toClientsCh := make(symo.CollectorToClientsCh, 1)
 
 	startCtx := context.Background()
-	clientsService := NewClients(log, clock.NewMock())
+	config, _ := symo.NewConfig("")
+	clientsService := NewClients(log, clock.NewMock(), config)
 	clientsService.Start(startCtx, toClientsCh)
 
 	stopCtx := context.Background()
 	clientsService.Stop(stopCtx)
 
 	// нельзя добавлять клиентов после закрытия
-	_, _, err := clientsService.NewClient(symo.ClientData{N: 1, M: 1})
+	_, _, err := clientsService.NewClient(symo.ClientData{N: 1 * time.Second, M: 1 * time.Second})
 	require.ErrorIs(t, err, symo.ErrStopped)
 
 	log.AssertExpectations(t)
 }
 
+func TestOnceClient(t *testing.T) {
+	log := new(mocks.Logger)
+	log.On("Debug", mock.Anything)
+	log.On("Debug", mock.Anything, mock.Anything)
+
+	toClientsCh := make(symo.CollectorToClientsCh, 1)
+
+	mockedClock := clock.NewMock()
+	config, _ := symo.NewConfig("")
+	clientsService := NewClients(log, mockedClock, config)
+	clientsService.Start(context.Background(), toClientsCh)
+	defer clientsService.Stop(context.Background())
+
+	ch, del, err := clientsService.NewClient(symo.ClientData{M: 5 * time.Second, Once: true})
+	require.NoError(t, err)
+	defer del()
+
+	// пакет отправляется на ближайшей секунде, не дожидаясь M секунд
+	mockedClock.Add(time.Second)
+	toClientsCh <- symo.MetricsData{
+		Time:   mockedClock.Now().Truncate(time.Second),
+		Points: storeOf(nil),
+	}
+
+	stats, ok := <-ch
+	require.True(t, ok)
+	require.Equal(t, uint64(1), stats.Seq)
+
+	_, ok = <-ch
+	require.False(t, ok)
+}
+
 //nolint:gocognit, funlen
 func TestSend(t *testing.T) {
 	type grpcClient struct {
@@ -203,7 +240,8 @@ func TestSend(t *testing.T) {
 
 			startCtx := context.Background()
 			mockedClock := clock.NewMock()
-			clientsService := NewClients(log, mockedClock)
+			config, _ := symo.NewConfig("")
+			clientsService := NewClients(log, mockedClock, config)
 			clientsService.Start(startCtx, toClientsCh)
 			defer func() {
 				stopCtx := context.Background()
@@ -219,7 +257,7 @@ func TestSend(t *testing.T) {
 				// старт новых клиентов
 				for i, grpc := range tt.grpcs {
 					if grpc.start == tick {
-						ch, del, err := clientsService.NewClient(symo.ClientData{N: grpc.n, M: grpc.m})
+						ch, del, err := clientsService.NewClient(symo.ClientData{N: time.Duration(grpc.n) * time.Second, M: time.Duration(grpc.m) * time.Second})
 						require.NoErrorf(t, err, "tick %d, client %d", tick, i)
 						clients[i].ch = ch
 						clients[i].del = del
@@ -239,7 +277,7 @@ func TestSend(t *testing.T) {
 				now := mockedClock.Now()
 				toClientsCh <- symo.MetricsData{
 					Time:   now.Truncate(time.Second),
-					Points: nil,
+					Points: storeOf(nil),
 				}
 
 				// проверка клиентских каналов
@@ -268,3 +306,264 @@ func TestSend(t *testing.T) {
 		})
 	}
 }
+
+func TestSubSecondClient(t *testing.T) {
+	log := new(mocks.Logger)
+	log.On("Debug", mock.Anything)
+	log.On("Debug", mock.Anything, mock.Anything)
+
+	toClientsCh := make(symo.CollectorToClientsCh, 1)
+
+	tick := 250 * time.Millisecond
+	mockedClock := clock.NewMock()
+	mockedClock.Set(time.Unix(1_600_000_000, 0))
+	config, _ := symo.NewConfig("")
+	config.App.Tick = tick
+	config.Clients.MinN = tick // NewConfig задает его по тику из конфига
+	clientsService := NewClients(log, mockedClock, config)
+	clientsService.Start(context.Background(), toClientsCh)
+	defer clientsService.Stop(context.Background())
+
+	fine, del, err := clientsService.NewClient(symo.ClientData{N: 500 * time.Millisecond, M: 500 * time.Millisecond})
+	require.NoError(t, err)
+	defer del()
+	whole, del, err := clientsService.NewClient(symo.ClientData{N: time.Second, M: time.Second})
+	require.NoError(t, err)
+	defer del()
+
+	start := mockedClock.Now()
+	ticks := store.NewTickStore(tick, symo.TickSeconds)
+	for i := 1; i <= 8; i++ {
+		now := start.Add(time.Duration(i) * tick)
+		ticks.Append(now.Add(-tick), symo.Point{CPU: &symo.CPUData{User: float64(i)}})
+		toClientsCh <- symo.MetricsData{
+			Time:   now,
+			Points: storeOf(points{now.Add(-time.Second): {CPU: &symo.CPUData{User: 100}}}),
+			Ticks:  ticks,
+			Tick:   tick,
+		}
+	}
+
+	// клиент с N и M короче секунды получает пакет каждые два тика со средним двух последних тиков
+	for i, user := range []float64{1.5, 3.5, 5.5, 7.5} {
+		var stats *symo.Stats
+		require.Eventually(t, func() bool {
+			select {
+			case stats = <-fine:
+				return true
+			default:
+				return false
+			}
+		}, time.Second, time.Millisecond)
+		require.True(t, start.Add(time.Duration(i+1)*500*time.Millisecond).Equal(stats.Time))
+		require.Equal(t, user, stats.CPU.User)
+	}
+
+	// клиент с целыми секундами получает пакеты только в начале секунды из посекундного хранилища
+	for i := 1; i <= 2; i++ {
+		var stats *symo.Stats
+		require.Eventually(t, func() bool {
+			select {
+			case stats = <-whole:
+				return true
+			default:
+				return false
+			}
+		}, time.Second, time.Millisecond)
+		require.True(t, start.Add(time.Duration(i)*time.Second).Equal(stats.Time))
+		require.Equal(t, 100.0, stats.CPU.User)
+	}
+	require.Len(t, whole, 0)
+}
+
+func TestClientDropPolicies(t *testing.T) {
+	conf := symo.ClientsConf{QueueSize: 2, Policy: "drop-newest", DropLimit: 2}
+	now := time.Now().Truncate(time.Second)
+	stats := &symo.Stats{Time: now}
+
+	// номера пакетов в очереди и сколько отброшенных пакетов в них отмечено
+	queued := func(ch <-chan *symo.Stats) [][2]uint64 {
+		var result [][2]uint64
+		for len(ch) > 0 {
+			frame := <-ch
+			result = append(result, [2]uint64{frame.Seq, frame.Dropped})
+		}
+		return result
+	}
+
+	t.Run("drop newest", func(t *testing.T) {
+		client := newClient(symo.ClientData{N: 1 * time.Second, M: 1 * time.Second}, conf, now, time.Second)
+		for i := 0; i < 3; i++ {
+			dropped, alive := client.send(stats, now)
+			require.True(t, alive)
+			require.Equal(t, uint64(i/2), dropped)
+		}
+		require.Equal(t, [][2]uint64{{1, 0}, {2, 0}}, queued(client.ch))
+
+		// о пакете, не поместившемся в очередь, клиент узнает из следующего
+		client.send(stats, now)
+		require.Equal(t, [][2]uint64{{4, 1}}, queued(client.ch))
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		client := newClient(symo.ClientData{N: 1 * time.Second, M: 1 * time.Second, Policy: symo.PolicyDropOldest}, conf, now, time.Second)
+		for i := 0; i < 4; i++ {
+			_, alive := client.send(stats, now)
+			require.True(t, alive)
+		}
+		require.Equal(t, [][2]uint64{{3, 1}, {4, 1}}, queued(client.ch))
+	})
+
+	t.Run("disconnect", func(t *testing.T) {
+		client := newClient(symo.ClientData{N: 1 * time.Second, M: 1 * time.Second, Policy: symo.PolicyDisconnect}, conf, now, time.Second)
+		for i := 0; i < 3; i++ {
+			_, alive := client.send(stats, now)
+			require.True(t, alive)
+		}
+		_, alive := client.send(stats, now)
+		require.False(t, alive)
+
+		last := <-client.ch
+		require.ErrorIs(t, last.Err, symo.ErrOverflow)
+		_, ok := <-client.ch
+		require.False(t, ok)
+	})
+
+	t.Run("skipped ticks", func(t *testing.T) {
+		client := newClient(symo.ClientData{N: 1 * time.Second, M: 1 * time.Second}, conf, now, time.Second)
+		dropped, _ := client.send(stats, now.Add(time.Second))
+		require.Equal(t, uint64(0), dropped)
+		client.setNextReady(now.Add(time.Second))
+
+		// два тика пропущены
+		dropped, _ = client.send(stats, now.Add(4*time.Second))
+		require.Equal(t, uint64(2), dropped)
+		require.Equal(t, [][2]uint64{{1, 0}, {4, 2}}, queued(client.ch))
+	})
+}
+
+func TestClientLimits(t *testing.T) {
+	log := new(mocks.Logger)
+	log.On("Debug", mock.Anything)
+	log.On("Debug", "client from ", mock.Anything, " is rejected: ", mock.Anything)
+
+	toClientsCh := make(symo.CollectorToClientsCh, 1)
+
+	config, _ := symo.NewConfig("")
+	config.Clients.MaxClients = 3
+	config.Clients.MaxPerPeer = 2
+	config.Clients.MinN = 2 * time.Second
+	clientsService := NewClients(log, clock.NewMock(), config)
+	clientsService.Start(context.Background(), toClientsCh)
+	defer clientsService.Stop(context.Background())
+
+	_, _, err := clientsService.NewClient(symo.ClientData{N: 1 * time.Second, M: 1 * time.Second, Peer: "10.0.0.1"})
+	require.ErrorIs(t, err, symo.ErrLimit)
+
+	_, delFirst, err := clientsService.NewClient(symo.ClientData{N: 2 * time.Second, M: 1 * time.Second, Peer: "10.0.0.1"})
+	require.NoError(t, err)
+	_, _, err = clientsService.NewClient(symo.ClientData{M: 1 * time.Second, Once: true, Peer: "10.0.0.1"})
+	require.NoError(t, err)
+	_, _, err = clientsService.NewClient(symo.ClientData{N: 2 * time.Second, M: 1 * time.Second, Peer: "10.0.0.1"})
+	require.ErrorIs(t, err, symo.ErrLimit)
+
+	_, _, err = clientsService.NewClient(symo.ClientData{N: 2 * time.Second, M: 1 * time.Second, Peer: "10.0.0.2"})
+	require.NoError(t, err)
+	_, _, err = clientsService.NewClient(symo.ClientData{N: 2 * time.Second, M: 1 * time.Second, Peer: "10.0.0.3"})
+	require.ErrorIs(t, err, symo.ErrLimit)
+
+	// экспортеры не ограничиваются, а клиенты без адреса ограничиваются
+	_, _, err = clientsService.NewClient(symo.ClientData{N: 1 * time.Second, M: 1 * time.Second, Exporter: true})
+	require.NoError(t, err)
+	_, _, err = clientsService.NewClient(symo.ClientData{N: 2 * time.Second, M: 1 * time.Second})
+	require.ErrorIs(t, err, symo.ErrLimit)
+
+	// повторное отключение не освобождает место дважды
+	delFirst()
+	delFirst()
+	_, _, err = clientsService.NewClient(symo.ClientData{N: 2 * time.Second, M: 1 * time.Second, Peer: "10.0.0.3"})
+	require.NoError(t, err)
+	_, _, err = clientsService.NewClient(symo.ClientData{N: 2 * time.Second, M: 1 * time.Second, Peer: "10.0.0.4"})
+	require.ErrorIs(t, err, symo.ErrLimit)
+
+	stats := clientsService.ClientsStats()
+	require.Equal(t, 3, stats.Clients)
+	require.Equal(t, map[string]uint64{"n": 1, "peer": 1, "clients": 3}, stats.Rejected)
+	// отключенные клиенты и разовые запросы не считаются подписанными
+	require.Equal(t, map[symo.ClientInterval]int{
+		{N: 2 * time.Second, M: time.Second}: 2,
+		{N: time.Second, M: time.Second}:     1,
+	}, stats.Intervals)
+}
+
+func TestClientsDroppedSends(t *testing.T) {
+	log := new(mocks.Logger)
+	log.On("Debug", mock.Anything)
+	log.On("Debug", mock.Anything, mock.Anything)
+
+	toClientsCh := make(symo.CollectorToClientsCh)
+
+	mockedClock := clock.NewMock()
+	mockedClock.Set(time.Unix(1_600_000_000, 0))
+	config, _ := symo.NewConfig("")
+	config.Clients.QueueSize = 1
+	clientsService := NewClients(log, mockedClock, config)
+	clientsService.Start(context.Background(), toClientsCh)
+	defer clientsService.Stop(context.Background())
+
+	_, del, err := clientsService.NewClient(symo.ClientData{N: time.Second, M: time.Second})
+	require.NoError(t, err)
+	defer del()
+
+	// клиент не забирает пакеты, в очередь помещается только первый
+	for i := 1; i <= 3; i++ {
+		toClientsCh <- symo.MetricsData{
+			Time:   mockedClock.Now().Add(time.Duration(i) * time.Second),
+			Points: storeOf(nil),
+		}
+	}
+	// канал без буфера: после следующей передачи предыдущий тик разослан
+	toClientsCh <- symo.MetricsData{Time: mockedClock.Now().Add(3500 * time.Millisecond), Points: storeOf(nil)}
+
+	require.Equal(t, uint64(2), clientsService.ClientsStats().DroppedSends)
+}
+
+func TestClientSubscriptionRate(t *testing.T) {
+	log := new(mocks.Logger)
+	log.On("Debug", mock.Anything)
+	log.On("Debug", "client from ", mock.Anything, " is rejected: ", mock.Anything)
+
+	toClientsCh := make(symo.CollectorToClientsCh, 1)
+
+	mockedClock := clock.NewMock()
+	config, _ := symo.NewConfig("")
+	config.Clients.SubscriptionRate = 2
+	config.Clients.SubscriptionBurst = 3
+	clientsService := NewClients(log, mockedClock, config)
+	clientsService.Start(context.Background(), toClientsCh)
+	defer clientsService.Stop(context.Background())
+
+	subscribe := func() error {
+		_, _, err := clientsService.NewClient(symo.ClientData{N: 1 * time.Second, M: 1 * time.Second, Peer: "10.0.0.1"})
+		return err
+	}
+
+	// пачка подключений до емкости ведра
+	for i := 0; i < 3; i++ {
+		require.NoError(t, subscribe())
+	}
+	require.ErrorIs(t, subscribe(), symo.ErrLimit)
+
+	// за полсекунды набирается один токен
+	mockedClock.Add(500 * time.Millisecond)
+	require.NoError(t, subscribe())
+	require.ErrorIs(t, subscribe(), symo.ErrLimit)
+
+	// ведро не переполняется
+	mockedClock.Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, subscribe())
+	}
+	require.ErrorIs(t, subscribe(), symo.ErrLimit)
+	require.Equal(t, uint64(3), clientsService.ClientsStats().Rejected["rate"])
+}
@@ -0,0 +1,46 @@
+package clients
+
+import (
+	"math"
+	"time"
+)
+
+// limiter - token bucket для новых подключений. Время берется у часов сервиса, поэтому его можно подменить в тестах.
+type limiter struct {
+	rate   float64 // сколько токенов добавляется в секунду, 0 - без ограничения
+	burst  float64 // емкость ведра
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(rate float64, burst int) *limiter {
+	capacity := float64(burst)
+	if capacity == 0 {
+		capacity = math.Max(1, math.Floor(rate))
+	}
+	return &limiter{
+		rate:   rate,
+		burst:  capacity,
+		tokens: capacity,
+	}
+}
+
+// allow забирает токен, если он есть.
+func (l *limiter) allow(now time.Time) bool {
+	if l.rate == 0 {
+		return true
+	}
+
+	if !l.last.IsZero() && now.After(l.last) {
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	if l.last.IsZero() || now.After(l.last) {
+		l.last = now
+	}
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
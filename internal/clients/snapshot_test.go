@@ -1,11 +1,13 @@
 package clients
 
 import (
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/anfilat/final-stats/internal/store"
 	"github.com/anfilat/final-stats/internal/symo"
 )
 
@@ -182,7 +184,7 @@ func TestSnapshot(t *testing.T) {
 			name: "with data",
 			data: &symo.MetricsData{
 				Time: now,
-				Points: symo.Points{
+				Points: storeOf(points{
 					now.Add(-time.Second): {
 						LoadAvg:   &la1,
 						CPU:       &cpu1,
@@ -195,7 +197,7 @@ func TestSnapshot(t *testing.T) {
 						LoadDisks: ld2,
 						UsedFS:    fs2,
 					},
-				},
+				}),
 			},
 			m: 2,
 			expected: &symo.Stats{
@@ -210,7 +212,7 @@ func TestSnapshot(t *testing.T) {
 			name: "for 1 second",
 			data: &symo.MetricsData{
 				Time: now,
-				Points: symo.Points{
+				Points: storeOf(points{
 					now.Add(-time.Second): {
 						LoadAvg:   &la1,
 						CPU:       &cpu1,
@@ -223,7 +225,7 @@ func TestSnapshot(t *testing.T) {
 						LoadDisks: ld2,
 						UsedFS:    fs2,
 					},
-				},
+				}),
 			},
 			m: 1,
 			expected: &symo.Stats{
@@ -238,7 +240,7 @@ func TestSnapshot(t *testing.T) {
 			name: "with old points",
 			data: &symo.MetricsData{
 				Time: now,
-				Points: symo.Points{
+				Points: storeOf(points{
 					now.Add(-time.Second): {
 						LoadAvg:   &la1,
 						CPU:       &cpu1,
@@ -258,7 +260,7 @@ func TestSnapshot(t *testing.T) {
 						LoadDisks: ld2,
 						UsedFS:    fs2,
 					},
-				},
+				}),
 			},
 			m: 2,
 			expected: &symo.Stats{
@@ -273,7 +275,7 @@ func TestSnapshot(t *testing.T) {
 			name: "with only old points",
 			data: &symo.MetricsData{
 				Time: now,
-				Points: symo.Points{
+				Points: storeOf(points{
 					now.Add(-10 * time.Second): {
 						LoadAvg:   &la1,
 						CPU:       &cpu1,
@@ -286,7 +288,7 @@ func TestSnapshot(t *testing.T) {
 						LoadDisks: ld2,
 						UsedFS:    fs2,
 					},
-				},
+				}),
 			},
 			m: 2,
 			expected: &symo.Stats{
@@ -295,13 +297,19 @@ func TestSnapshot(t *testing.T) {
 				CPU:       nil,
 				LoadDisks: nil,
 				UsedFS:    nil,
+				State: symo.MetricsState{
+					LoadAvg:   symo.MetricState{Status: symo.StatusStale},
+					CPU:       symo.MetricState{Status: symo.StatusStale},
+					LoadDisks: symo.MetricState{Status: symo.StatusStale},
+					UsedFS:    symo.MetricState{Status: symo.StatusStale},
+				},
 			},
 		},
 		{
 			name: "with changed set of disks",
 			data: &symo.MetricsData{
 				Time: now,
-				Points: symo.Points{
+				Points: storeOf(points{
 					now.Add(-time.Second): {
 						LoadAvg:   &la1,
 						CPU:       &cpu1,
@@ -314,7 +322,7 @@ func TestSnapshot(t *testing.T) {
 						LoadDisks: ld3,
 						UsedFS:    fs3,
 					},
-				},
+				}),
 			},
 			m: 5,
 			expected: &symo.Stats{
@@ -325,11 +333,67 @@ func TestSnapshot(t *testing.T) {
 				UsedFS:    fsSum13,
 			},
 		},
+		{
+			name: "state from last second",
+			data: &symo.MetricsData{
+				Time: now,
+				Points: storeOf(points{
+					now.Add(-time.Second): {
+						LoadAvg: &la1,
+						State: symo.MetricsState{
+							CPU:       symo.MetricState{Status: symo.StatusError, Message: "cpu error"},
+							LoadDisks: symo.MetricState{Status: symo.StatusStale},
+							UsedFS:    symo.MetricState{Status: symo.StatusDisabled},
+						},
+					},
+					now.Add(-2 * time.Second): {
+						LoadAvg:   &la2,
+						CPU:       &cpu2,
+						LoadDisks: ld2,
+					},
+				}),
+			},
+			m: 2,
+			expected: &symo.Stats{
+				Time:      now,
+				LoadAvg:   &laSum12,
+				CPU:       &cpu2,
+				LoadDisks: ld2,
+				UsedFS:    nil,
+				State: symo.MetricsState{
+					CPU:       symo.MetricState{Status: symo.StatusError, Message: "cpu error"},
+					LoadDisks: symo.MetricState{Status: symo.StatusStale},
+					UsedFS:    symo.MetricState{Status: symo.StatusDisabled},
+				},
+			},
+		},
+		{
+			// файловые системы опрашиваются раз в 2 секунды, секунда без опроса не смещает среднее
+			name: "with different sampling intervals",
+			data: &symo.MetricsData{
+				Time: now,
+				Points: storeOf(points{
+					now.Add(-time.Second): {
+						LoadAvg: &la1,
+					},
+					now.Add(-2 * time.Second): {
+						LoadAvg: &la2,
+						UsedFS:  fs2,
+					},
+				}),
+			},
+			m: 2,
+			expected: &symo.Stats{
+				Time:    now,
+				LoadAvg: &laSum12,
+				UsedFS:  fs2,
+			},
+		},
 		{
 			name: "without data",
 			data: &symo.MetricsData{
 				Time:   now,
-				Points: nil,
+				Points: storeOf(nil),
 			},
 			m: 5,
 			expected: &symo.Stats{
@@ -338,20 +402,26 @@ func TestSnapshot(t *testing.T) {
 				CPU:       nil,
 				LoadDisks: nil,
 				UsedFS:    nil,
+				State: symo.MetricsState{
+					LoadAvg:   symo.MetricState{Status: symo.StatusStale},
+					CPU:       symo.MetricState{Status: symo.StatusStale},
+					LoadDisks: symo.MetricState{Status: symo.StatusStale},
+					UsedFS:    symo.MetricState{Status: symo.StatusStale},
+				},
 			},
 		},
 		{
 			name: "with empty point",
 			data: &symo.MetricsData{
 				Time: now,
-				Points: symo.Points{
+				Points: storeOf(points{
 					now.Add(-time.Second): {
 						LoadAvg:   nil,
 						CPU:       nil,
 						LoadDisks: nil,
 						UsedFS:    nil,
 					},
-				},
+				}),
 			},
 			m: 5,
 			expected: &symo.Stats{
@@ -367,7 +437,8 @@ func TestSnapshot(t *testing.T) {
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			stats := makeSnapshot(tt.data, tt.m)
+			stats := makeSnapshot(tt.data, time.Duration(tt.m)*time.Second)
+			require.Equal(t, tt.expected.State, stats.State)
 
 			require.True(t, tt.expected.Time.Equal(stats.Time))
 
@@ -411,6 +482,25 @@ func TestSnapshot(t *testing.T) {
 	}
 }
 
+type points map[time.Time]*symo.Point
+
+// storeOf возвращает хранилище с перечисленными точками.
+func storeOf(list points) symo.PointsReader {
+	times := make([]time.Time, 0, len(list))
+	for tm := range list {
+		times = append(times, tm)
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Before(times[j])
+	})
+
+	result := store.NewStore(symo.MaxSeconds)
+	for _, tm := range times {
+		result.Append(tm, *list[tm])
+	}
+	return result
+}
+
 func findLoadDisk(name string, ld symo.LoadDisksData) *symo.DiskData {
 	for i := 0; i < len(ld); i++ {
 		if ld[i].Name == name {
@@ -2,6 +2,7 @@ package collector
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -9,30 +10,49 @@ import (
 	"github.com/anfilat/final-stats/internal/symo"
 )
 
-const timeToGetMetric = 950 * time.Millisecond
-
 type collector struct {
 	ctx         context.Context // управление остановкой сервиса
 	ctxCancel   context.CancelFunc
 	stoppedCh   chan interface{}
 	mutex       *sync.Mutex
-	points      symo.Points        // собираемые данные
-	workerChans []chan<- timePoint // каналы горутин, ответственных за сбор конкретных метрик
+	store       symo.PointsStore   // собранные данные за завершенные секунды
+	ticks       symo.PointsStore   // собранные данные за завершенные тики, если тик короче секунды
+	tick        time.Duration      // как часто опрашиваются коллекторы
+	current     *symo.Point        // точка текущего тика, заполняемая коллекторами
+	currentTime time.Time          // текущий тик
+	second      *symo.Rollup       // точки тиков текущей секунды, если тик короче секунды
+	secondTime  time.Time          // текущая секунда
+	states      symo.MetricsState  // начальное состояние метрик для каждой новой точки
+	workers     map[string]*worker // горутины, ответственные за сбор конкретных метрик, по именам метрик
+	metrics     symo.MetricConf    // какие метрики собираются сейчас. Меняется при перезагрузке конфига
 	config      symo.Config
 	collectors  symo.MetricCollectors // функции возвращающие конкретные метрики
 	toClientsCh chan<- symo.MetricsData
+	stats       symo.CollectorStats // работа сервиса для метрик самого приложения
 	log         symo.Logger
 }
 
+// worker - горутина, собирающая одну метрику. Ее можно остановить отдельно от остальных.
+type worker struct {
+	ch     chan timePoint
+	cancel context.CancelFunc
+}
+
 // информация, отправляемая горутинам, ответственным за сбор конкретных метрик.
+// Собранные значения записываются в точку тика, в который они получены.
 type timePoint struct {
-	time  time.Time   // за какую секунду метрика
-	point *symo.Point // структура, в которую складываются метрики
+	time     time.Time           // время тика
+	tick     time.Duration       // длительность тика
+	sampling symo.MetricSampling // как часто опрашивать коллектор в этот тик
 }
 
-// NewCollector возвращает сервис сбора метрик.
-func NewCollector(log symo.Logger, config symo.Config) symo.Collector {
+// NewCollector возвращает сервис сбора метрик, складывающий их в хранилище points.
+// Если тик из конфига короче секунды, точки тиков складываются в ticks, а в points - их средние за секунду.
+func NewCollector(log symo.Logger, config symo.Config, points, ticks symo.PointsStore) symo.Collector {
 	return &collector{
+		store:  points,
+		ticks:  ticks,
+		tick:   config.App.Tick,
 		config: config,
 		log:    log,
 	}
@@ -45,11 +65,15 @@ func (c *collector) Start(ctx context.Context, collectors symo.MetricCollectors,
 	c.ctx, c.ctxCancel = context.WithCancel(context.Background())
 	c.stoppedCh = make(chan interface{})
 	c.mutex = &sync.Mutex{}
-	c.points = make(symo.Points)
-	c.workerChans = nil
+	c.current = nil
+	c.second = nil
+	c.metrics = c.config.Metric
+	c.states = initialStates(c.metrics)
+	c.workers = make(map[string]*worker)
+	c.stats = symo.CollectorStats{Samplers: make(map[string]symo.SamplerStats)}
 
 	mountedCh := make(chan interface{})
-	go c.mountMetrics(ctx, mountedCh)
+	go c.mountMetrics(ctx, c.metrics, mountedCh)
 
 	select {
 	case <-ctx.Done():
@@ -70,8 +94,12 @@ func (c *collector) Stop(ctx context.Context) {
 	case <-c.stoppedCh:
 	}
 
+	c.mutex.Lock()
+	metrics := c.metrics
+	c.mutex.Unlock()
+
 	unmountedCh := make(chan interface{})
-	go c.unmountMetrics(ctx, unmountedCh)
+	go c.unmountMetrics(ctx, metrics, unmountedCh)
 
 	select {
 	case <-ctx.Done():
@@ -81,21 +109,108 @@ func (c *collector) Stop(ctx context.Context) {
 	}
 }
 
-func (c *collector) mountMetrics(startCtx context.Context, mountedCh chan interface{}) {
+// Reload запускает сбор включенных в конфиге метрик и останавливает сбор отключенных.
+// Остальные коллекторы и подключенные клиенты продолжают работать.
+func (c *collector) Reload(ctx context.Context, config symo.Config) {
+	c.mutex.Lock()
+	if c.ctx.Err() != nil {
+		c.mutex.Unlock()
+		return
+	}
+	enabled := onlyIn(config.Metric, c.metrics)
+	disabled := onlyIn(c.metrics, config.Metric)
+	c.metrics = config.Metric
+	c.mutex.Unlock()
+
+	if disabled != (symo.MetricConf{}) {
+		c.setStates(disabled, symo.MetricState{Status: symo.StatusDisabled})
+
+		unmountedCh := make(chan interface{})
+		go c.unmountMetrics(ctx, disabled, unmountedCh)
+		select {
+		case <-ctx.Done():
+			return
+		case <-unmountedCh:
+		}
+	}
+
+	if enabled != (symo.MetricConf{}) {
+		c.setStates(enabled, symo.MetricState{Status: symo.StatusStale})
+
+		mountedCh := make(chan interface{})
+		go c.mountMetrics(ctx, enabled, mountedCh)
+		select {
+		case <-ctx.Done():
+			return
+		case <-mountedCh:
+		}
+	}
+
+	c.log.Debug("collected metrics are reloaded")
+}
+
+// onlyIn возвращает метрики, включенные в a и выключенные в b.
+func onlyIn(a, b symo.MetricConf) symo.MetricConf {
+	return symo.MetricConf{
+		Loadavg:   a.Loadavg && !b.Loadavg,
+		CPU:       a.CPU && !b.CPU,
+		Loaddisks: a.Loaddisks && !b.Loaddisks,
+		UsedFS:    a.UsedFS && !b.UsedFS,
+	}
+}
+
+// setStates задает начальное состояние метрик из metrics в следующих точках.
+func (c *collector) setStates(metrics symo.MetricConf, state symo.MetricState) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if metrics.Loadavg {
+		c.states.LoadAvg = state
+	}
+	if metrics.CPU {
+		c.states.CPU = state
+	}
+	if metrics.Loaddisks {
+		c.states.LoadDisks = state
+	}
+	if metrics.UsedFS {
+		c.states.UsedFS = state
+	}
+}
+
+// пока коллектор не заполнил точку, его метрика считается устаревшей.
+func initialStates(conf symo.MetricConf) symo.MetricsState {
+	state := func(enabled bool) symo.MetricState {
+		if enabled {
+			return symo.MetricState{Status: symo.StatusStale}
+		}
+		return symo.MetricState{Status: symo.StatusDisabled}
+	}
+
+	return symo.MetricsState{
+		LoadAvg:   state(conf.Loadavg),
+		CPU:       state(conf.CPU),
+		LoadDisks: state(conf.Loaddisks),
+		UsedFS:    state(conf.UsedFS),
+	}
+}
+
+func (c *collector) mountMetrics(startCtx context.Context, metrics symo.MetricConf, mountedCh chan interface{}) {
 	wg := &sync.WaitGroup{}
 
-	if c.config.Metric.Loadavg {
-		go loadavgCollect(c.ctx, c.mutex, c.newWorkerChan(), c.collectors.LoadAvg, c.log)
+	if metrics.Loadavg {
+		ctx, ch, log := c.newWorker("loadavg")
+		go collect(ctx, ch, c.observed(loadavgSampler(c.collectors.LoadAvg)), c.write, log)
 	}
-	if c.config.Metric.CPU {
+	if metrics.CPU {
 		wg.Add(1)
 		go c.mountCPU(startCtx, wg)
 	}
-	if c.config.Metric.Loaddisks {
+	if metrics.Loaddisks {
 		wg.Add(1)
 		go c.mountLoadDisks(startCtx, wg)
 	}
-	if c.config.Metric.UsedFS {
+	if metrics.UsedFS {
 		wg.Add(1)
 		go c.mountUsedFS(startCtx, wg)
 	}
@@ -109,40 +224,65 @@ func (c *collector) mountCPU(startCtx context.Context, wg *sync.WaitGroup) {
 
 	_, err := c.collectors.CPU(startCtx, symo.StartMetric)
 	if err != nil {
-		c.log.Debug(fmt.Errorf("cannot start collect the cpu metric: %w", err))
+		err = fmt.Errorf("cannot start collect the cpu metric: %w", err)
+		c.log.Debug(err)
+		c.setMountError(&c.states.CPU, err)
 		return
 	}
-	go cpuCollect(c.ctx, c.mutex, c.newWorkerChan(), c.collectors.CPU, c.log)
+	ctx, ch, log := c.newWorker("cpu")
+	go collect(ctx, ch, c.observed(cpuSampler(c.collectors.CPU)), c.write, log)
 }
 
 func (c *collector) mountLoadDisks(startCtx context.Context, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	_, err := c.collectors.LoadDisks(startCtx, symo.StartMetric)
+	// сбор продолжается: ошибки опроса коллектора отмечаются в точках
 	if err != nil {
 		c.log.Debug(fmt.Errorf("cannot start collect the load disks metric: %w", err))
 	}
-	go loadDisksCollect(c.ctx, c.mutex, c.newWorkerChan(), c.collectors.LoadDisks, c.log)
+	ctx, ch, log := c.newWorker("loaddisks")
+	go collect(ctx, ch, c.observed(loadDisksSampler(c.collectors.LoadDisks)), c.write, log)
 }
 
 func (c *collector) mountUsedFS(startCtx context.Context, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	_, err := c.collectors.UsedFS(startCtx, symo.StartMetric)
+	// сбор продолжается: ошибки опроса коллектора отмечаются в точках
 	if err != nil {
 		c.log.Debug(fmt.Errorf("cannot start collect the used fs metric: %w", err))
 	}
-	go usedFSCollect(c.ctx, c.mutex, c.newWorkerChan(), c.collectors.UsedFS, c.log)
+	ctx, ch, log := c.newWorker("usedfs")
+	go collect(ctx, ch, c.observed(usedFSSampler(c.collectors.UsedFS)), c.write, log)
+}
+
+// коллектор, который не удалось запустить, во всех точках помечается ошибкой.
+func (c *collector) setMountError(state *symo.MetricState, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	*state = symo.MetricState{Status: symo.StatusError, Message: err.Error()}
 }
 
-func (c *collector) unmountMetrics(stopCtx context.Context, unmountedCh chan interface{}) {
+func (c *collector) unmountMetrics(stopCtx context.Context, metrics symo.MetricConf, unmountedCh chan interface{}) {
 	wg := &sync.WaitGroup{}
 
-	if c.config.Metric.Loaddisks {
+	for _, metric := range symo.MetricNames {
+		settings, err := metrics.Metric(metric)
+		if err != nil {
+			c.log.Error(err)
+			continue
+		}
+		if settings.Enabled {
+			c.stopWorker(metric)
+		}
+	}
+	if metrics.Loaddisks {
 		wg.Add(1)
 		go c.unmountLoadDisks(stopCtx, wg)
 	}
-	if c.config.Metric.UsedFS {
+	if metrics.UsedFS {
 		wg.Add(1)
 		go c.unmountUsedFS(stopCtx, wg)
 	}
@@ -169,20 +309,32 @@ func (c *collector) unmountUsedFS(stopCtx context.Context, wg *sync.WaitGroup) {
 	}
 }
 
-func (c *collector) newWorkerChan() chan timePoint {
+// newWorker регистрирует горутину сбора метрики и возвращает ее контекст, канал и логгер с именем метрики.
+func (c *collector) newWorker(metric string) (context.Context, chan timePoint, symo.Logger) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
+	ctx, cancel := context.WithCancel(c.ctx)
 	ch := make(chan timePoint, 1)
-	c.workerChans = append(c.workerChans, ch)
+	c.workers[metric] = &worker{ch: ch, cancel: cancel}
+
+	return ctx, ch, c.log.With(symo.LogCollector, metric)
+}
 
-	return ch
+func (c *collector) stopWorker(metric string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if w, ok := c.workers[metric]; ok {
+		w.cancel()
+		delete(c.workers, metric)
+	}
 }
 
 func (c *collector) work() {
 	defer close(c.stoppedCh)
 
-	ticker := time.NewTicker(time.Second)
+	ticker := time.NewTicker(c.tick)
 	defer ticker.Stop()
 	for {
 		select {
@@ -195,78 +347,188 @@ func (c *collector) work() {
 		case <-c.ctx.Done():
 			return
 		case now := <-ticker.C:
-			c.processTick(now.Truncate(time.Second))
+			start := time.Now()
+			c.processTick(now.Truncate(c.tick))
+			c.observeTick(start.Sub(now), time.Since(start))
 		}
 	}
 }
 
-func (c *collector) processTick(now time.Time) {
-	c.log.Debug("tick ", now)
+// observeTick учитывает обработанный тик: насколько позже срабатывания таймера он начат и сколько длился.
+func (c *collector) observeTick(lag, duration time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
 
-	// добавляется новая точка для статистики за эту секунду
-	point := c.addPoint(now)
+	c.stats.Ticks++
+	c.stats.TickLag = lag
+	c.stats.TickDuration = duration
+}
 
-	// точка отправляется всем горутинам, ответственным за получение части статистики для заполнения
-	for _, ch := range c.workerChans {
-		tp := timePoint{
-			time:  now,
-			point: point,
-		}
-		select {
-		case ch <- tp:
-		default:
+// observed добавляет учет опросов коллектора в статистику сервиса.
+func (c *collector) observed(s sampler) sampler {
+	s.observe = func(latency time.Duration, err error) {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+
+		stats := c.stats.Samplers[s.name]
+		stats.Samples++
+		stats.Latency = latency
+		stats.LatencyTotal += latency
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			stats.Timeouts++
+		case err != nil:
+			stats.Errors++
 		}
+		c.stats.Samplers[s.name] = stats
+	}
+	return s
+}
+
+func (c *collector) CollectorStats() symo.CollectorStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	result := c.stats
+	result.Samplers = make(map[string]symo.SamplerStats, len(c.stats.Samplers))
+	for metric, stats := range c.stats.Samplers {
+		result.Samplers[metric] = stats
 	}
+	return result
+}
 
-	// устаревшие точки удаляются
-	c.cleanPoints(now)
+func (c *collector) processTick(now time.Time) {
+	c.log.Debug("tick ", now)
+
+	// добавляется новая точка для статистики за этот тик, предыдущая считается заполненной
+	c.addPoint(now)
+
+	// тик отправляется горутинам метрик, которые опрашиваются в этот тик
+	c.sendToWorkers(now)
 
 	data := symo.MetricsData{
-		Time:   now,
-		Points: c.cloneOldPoints(now),
+		Time:         now,
+		Points:       c.store,
+		Tick:         c.tick,
+		DroppedTicks: c.droppedTicks(),
+	}
+	if c.ticks != nil {
+		data.Ticks = c.ticks
 	}
 
+	// сервис клиентов еще обрабатывает предыдущий тик. Он узнает о пропуске из следующего тика
 	select {
 	case c.toClientsCh <- data:
 	default:
+		c.mutex.Lock()
+		c.stats.DroppedTicks++
+		c.mutex.Unlock()
+		c.log.Debug("clients service is busy, tick is dropped")
 	}
 }
 
-func (c *collector) addPoint(now time.Time) *symo.Point {
+func (c *collector) droppedTicks() uint64 {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	point := &symo.Point{}
-	c.points[now] = point
-
-	return point
+	return c.stats.DroppedTicks
 }
 
-func (c *collector) cleanPoints(now time.Time) {
+func (c *collector) sendToWorkers(now time.Time) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	limit := now.Add(-time.Duration(c.config.App.MaxSeconds) * time.Second)
+	for metric, w := range c.workers {
+		settings, err := c.metrics.Metric(metric)
+		if err != nil {
+			c.log.Error(err)
+			continue
+		}
+		sampling := settings.Sampling.WithDefaults(c.tick)
+		if !sampling.Due(now, c.tick) {
+			continue
+		}
 
-	for key := range c.points {
-		if key.Before(limit) {
-			delete(c.points, key)
+		select {
+		case w.ch <- timePoint{time: now, tick: c.tick, sampling: sampling}:
+		default:
 		}
 	}
 }
 
-func (c *collector) cloneOldPoints(now time.Time) symo.Points {
+// addPoint создает точку для текущего тика и переносит в хранилище точку предыдущего.
+func (c *collector) addPoint(now time.Time) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	result := make(symo.Points, len(c.points))
-	for key, point := range c.points {
-		if key.Equal(now) {
-			continue
+	states := c.states
+	if c.current != nil {
+		c.storePoint(now)
+
+		for _, metric := range symo.MetricNames {
+			if err := c.keepLastSample(&states, metric); err != nil {
+				c.log.Error(err)
+			}
 		}
-		newPoint := *point
-		result[key] = &newPoint
 	}
 
-	return result
+	c.current = &symo.Point{State: states}
+	c.currentTime = now
+}
+
+// keepLastSample переносит в states результат последнего опроса метрики, которая опрашивается реже раза в тик.
+// Он сохраняется до следующего опроса.
+func (c *collector) keepLastSample(states *symo.MetricsState, metric string) error {
+	settings, err := c.metrics.Metric(metric)
+	if err != nil {
+		return err
+	}
+	state, err := states.Metric(metric)
+	if err != nil {
+		return err
+	}
+	last, err := c.current.State.Metric(metric)
+	if err != nil {
+		return err
+	}
+
+	sampled := last.Status == symo.StatusOK || last.Status == symo.StatusError
+	if state.Status == symo.StatusStale && sampled && settings.Sampling.WithDefaults(c.tick).Interval > c.tick {
+		*state = *last
+	}
+	return nil
+}
+
+// storePoint переносит в хранилища точку завершенного тика. Если тик короче секунды, точки тиков
+// усредняются в точку секунды, которая сохраняется, когда наступает следующая секунда.
+func (c *collector) storePoint(now time.Time) {
+	// хранилище получает копию, чтобы запоздавший коллектор не изменил ее
+	if c.ticks == nil {
+		c.store.Append(c.currentTime, *c.current)
+		return
+	}
+
+	c.ticks.Append(c.currentTime, *c.current)
+	if c.second == nil {
+		c.second = symo.NewRollup()
+		c.secondTime = c.currentTime.Truncate(time.Second)
+	}
+	c.second.Add(*c.current)
+	if !now.Truncate(time.Second).Equal(c.secondTime) {
+		c.store.Append(c.secondTime, c.second.Mean())
+		c.second = nil
+	}
+}
+
+// write применяет изменение к точке тика tm. Точка завершенного тика уже передана в хранилища,
+// поэтому опоздавший результат отбрасывается.
+func (c *collector) write(tm time.Time, apply func(point *symo.Point)) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.current == nil || !c.currentTime.Equal(tm) {
+		c.log.Debug("sample for the closed tick ", tm, " is dropped")
+		return
+	}
+	apply(c.current)
 }
@@ -3,6 +3,7 @@ package collector
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/anfilat/final-stats/internal/loadavg"
 	"github.com/anfilat/final-stats/internal/loaddisks"
 	"github.com/anfilat/final-stats/internal/mocks"
+	"github.com/anfilat/final-stats/internal/store"
 	"github.com/anfilat/final-stats/internal/symo"
 	"github.com/anfilat/final-stats/internal/usedfs"
 )
@@ -25,6 +27,7 @@ func TestCollectorStartStop(t *testing.T) {
 	require.NoError(t, err)
 
 	log := new(mocks.Logger)
+	log.On("With", mock.Anything, mock.Anything).Return(log)
 	log.On("Debug", "collector is stopped")
 
 	collectors := symo.MetricCollectors{
@@ -37,7 +40,7 @@ func TestCollectorStartStop(t *testing.T) {
 	toClientsCh := make(symo.CollectorToClientsCh, 1)
 
 	startCtx := context.Background()
-	collectorService := NewCollector(log, config)
+	collectorService := NewCollector(log, config, store.NewStore(config.App.MaxSeconds), nil)
 	collectorService.Start(startCtx, collectors, toClientsCh)
 
 	stopCtx := context.Background()
@@ -54,6 +57,7 @@ func TestCollectorStartWithCanceledContext(t *testing.T) {
 	require.NoError(t, err)
 
 	log := new(mocks.Logger)
+	log.On("With", mock.Anything, mock.Anything).Return(log)
 	log.On("Debug", mock.Anything)
 
 	collectors := symo.MetricCollectors{
@@ -67,7 +71,7 @@ func TestCollectorStartWithCanceledContext(t *testing.T) {
 
 	startCtx, cancel := context.WithCancel(context.Background())
 	cancel()
-	collectorService := NewCollector(log, config)
+	collectorService := NewCollector(log, config, store.NewStore(config.App.MaxSeconds), nil)
 	collectorService.Start(startCtx, collectors, toClientsCh)
 
 	stopCtx := context.Background()
@@ -84,6 +88,7 @@ func TestCollectorTick(t *testing.T) {
 	require.NoError(t, err)
 
 	log := new(mocks.Logger)
+	log.On("With", mock.Anything, mock.Anything).Return(log)
 	log.On("Debug", mock.Anything)
 	log.On("Debug", "tick ", mock.Anything)
 
@@ -134,7 +139,7 @@ func TestCollectorTick(t *testing.T) {
 	toClientsCh := make(symo.CollectorToClientsCh, 1)
 
 	startCtx := context.Background()
-	collectorService := NewCollector(log, config)
+	collectorService := NewCollector(log, config, store.NewStore(config.App.MaxSeconds), nil)
 	collectorService.Start(startCtx, collectors, toClientsCh)
 
 	time.Sleep(50 * time.Millisecond)
@@ -142,16 +147,23 @@ func TestCollectorTick(t *testing.T) {
 	time.Sleep(time.Second)
 	data := <-toClientsCh
 	// текущая секунда еще не заполнена, предыдущих нет - статистика должна быть пустой
-	require.Len(t, data.Points, 0)
+	require.Len(t, data.Points.Points(data.Time, config.App.MaxSeconds), 0)
 
 	time.Sleep(time.Second)
 	data = <-toClientsCh
-	require.Len(t, data.Points, 1)
-	for _, point := range data.Points {
+	points := data.Points.Points(data.Time, config.App.MaxSeconds)
+	require.Len(t, points, 1)
+	for _, point := range points {
 		require.Equal(t, laData, point.LoadAvg)
 		require.Equal(t, cpuData, point.CPU)
 		require.Equal(t, ldData, point.LoadDisks)
 		require.Equal(t, fsData, point.UsedFS)
+		require.Equal(t, symo.MetricsState{
+			LoadAvg:   symo.MetricState{Status: symo.StatusOK},
+			CPU:       symo.MetricState{Status: symo.StatusOK},
+			LoadDisks: symo.MetricState{Status: symo.StatusOK},
+			UsedFS:    symo.MetricState{Status: symo.StatusOK},
+		}, point.State)
 	}
 
 	stopCtx := context.Background()
@@ -167,6 +179,7 @@ func TestCollectorTickWithErrors(t *testing.T) {
 	require.NoError(t, err)
 
 	log := new(mocks.Logger)
+	log.On("With", mock.Anything, mock.Anything).Return(log)
 	log.On("Debug", mock.Anything)
 	log.On("Debug", "tick ", mock.Anything)
 
@@ -202,7 +215,7 @@ func TestCollectorTickWithErrors(t *testing.T) {
 	toClientsCh := make(symo.CollectorToClientsCh, 1)
 
 	startCtx := context.Background()
-	collectorService := NewCollector(log, config)
+	collectorService := NewCollector(log, config, store.NewStore(config.App.MaxSeconds), nil)
 	collectorService.Start(startCtx, collectors, toClientsCh)
 
 	time.Sleep(50 * time.Millisecond)
@@ -210,17 +223,26 @@ func TestCollectorTickWithErrors(t *testing.T) {
 	time.Sleep(time.Second)
 	data := <-toClientsCh
 	// текущая секунда еще не заполнена, предыдущих нет - статистика должна быть пустой
-	require.Len(t, data.Points, 0)
+	require.Len(t, data.Points.Points(data.Time, config.App.MaxSeconds), 0)
 
 	// все коллекторы вернули ошибки, данные должны быть пустые
 	time.Sleep(time.Second)
 	data = <-toClientsCh
-	require.Len(t, data.Points, 1)
-	for _, point := range data.Points {
+	points := data.Points.Points(data.Time, config.App.MaxSeconds)
+	require.Len(t, points, 1)
+	for _, point := range points {
 		require.Nil(t, point.LoadAvg)
 		require.Nil(t, point.CPU)
 		require.Nil(t, point.LoadDisks)
 		require.Nil(t, point.UsedFS)
+		require.Equal(t, symo.MetricState{Status: symo.StatusError, Message: "cannot get load average: LoadAvg Error"},
+			point.State.LoadAvg)
+		require.Equal(t, symo.MetricState{Status: symo.StatusError, Message: "cannot get cpu: CPU Error"},
+			point.State.CPU)
+		require.Equal(t, symo.MetricState{Status: symo.StatusError, Message: "cannot get load disks: LoadDisks Error"},
+			point.State.LoadDisks)
+		require.Equal(t, symo.MetricState{Status: symo.StatusError, Message: "cannot get used fs: UsedFS Error"},
+			point.State.UsedFS)
 	}
 
 	stopCtx := context.Background()
@@ -228,3 +250,352 @@ func TestCollectorTickWithErrors(t *testing.T) {
 
 	log.AssertExpectations(t)
 }
+
+func TestCollectorDisabledAndFailedMetrics(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	config, err := symo.NewConfig("")
+	require.NoError(t, err)
+	config.Metric.Loadavg = false
+	config.Metric.UsedFS = false
+
+	log := new(mocks.Logger)
+	log.On("With", mock.Anything, mock.Anything).Return(log)
+	log.On("Debug", mock.Anything)
+	log.On("Debug", "tick ", mock.Anything)
+
+	cpuErr := errors.New("CPU Error")
+	CPU := new(mocks.CPU)
+	CPU.On("Execute", mock.Anything, symo.StartMetric).Return(nil, cpuErr)
+
+	// коллектор дисков, который не удалось запустить, все равно опрашивается
+	LoadDisks := new(mocks.LoadDisks)
+	LoadDisks.On("Execute", mock.Anything, symo.StartMetric).Return(nil, errors.New("LoadDisks Error"))
+	LoadDisks.On("Execute", mock.Anything, mock.Anything).Return(symo.LoadDisksData{}, nil)
+
+	collectors := symo.MetricCollectors{
+		CPU:       CPU.Execute,
+		LoadDisks: LoadDisks.Execute,
+	}
+
+	toClientsCh := make(symo.CollectorToClientsCh, 1)
+
+	startCtx := context.Background()
+	collectorService := NewCollector(log, config, store.NewStore(config.App.MaxSeconds), nil)
+	collectorService.Start(startCtx, collectors, toClientsCh)
+
+	time.Sleep(50 * time.Millisecond)
+
+	time.Sleep(time.Second)
+	<-toClientsCh
+
+	time.Sleep(time.Second)
+	data := <-toClientsCh
+	points := data.Points.Points(data.Time, config.App.MaxSeconds)
+	require.Len(t, points, 1)
+	for _, point := range points {
+		require.Equal(t, symo.MetricsState{
+			LoadAvg: symo.MetricState{Status: symo.StatusDisabled},
+			CPU: symo.MetricState{
+				Status:  symo.StatusError,
+				Message: "cannot start collect the cpu metric: CPU Error",
+			},
+			LoadDisks: symo.MetricState{Status: symo.StatusOK},
+			UsedFS:    symo.MetricState{Status: symo.StatusDisabled},
+		}, point.State)
+	}
+
+	stopCtx := context.Background()
+	collectorService.Stop(stopCtx)
+
+	log.AssertExpectations(t)
+}
+
+func TestCollectorReload(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	config, err := symo.NewConfig("")
+	require.NoError(t, err)
+	config.Metric.Loadavg = false
+	config.Metric.CPU = false
+	config.Metric.Loaddisks = false
+
+	log := new(mocks.Logger)
+	log.On("With", mock.Anything, mock.Anything).Return(log)
+	log.On("Debug", mock.Anything)
+	log.On("Debug", "tick ", mock.Anything)
+
+	laData := &symo.LoadAvgData{Load1: 1}
+	LoadAvg := new(mocks.LoadAvg)
+	LoadAvg.On("Execute", mock.Anything).Return(laData, nil)
+
+	fsData := symo.UsedFSData{{Path: "/", UsedSpace: 13}}
+	UsedFS := new(mocks.UsedFS)
+	UsedFS.On("Execute", mock.Anything, mock.Anything).Return(fsData, nil)
+
+	collectors := symo.MetricCollectors{
+		LoadAvg: LoadAvg.Execute,
+		UsedFS:  UsedFS.Execute,
+	}
+
+	toClientsCh := make(symo.CollectorToClientsCh, 1)
+
+	startCtx := context.Background()
+	collectorService := NewCollector(log, config, store.NewStore(config.App.MaxSeconds), nil)
+	collectorService.Start(startCtx, collectors, toClientsCh)
+
+	<-toClientsCh
+
+	// включается сбор load average, сбор использования файловых систем останавливается
+	config.Metric.Loadavg = true
+	config.Metric.UsedFS = false
+	collectorService.Reload(context.Background(), config)
+	UsedFS.AssertCalled(t, "Execute", mock.Anything, symo.StopMetric)
+
+	<-toClientsCh
+	data := <-toClientsCh
+	points := data.Points.Points(data.Time, 1)
+	require.Len(t, points, 1)
+	require.Equal(t, laData, points[0].LoadAvg)
+	require.Nil(t, points[0].UsedFS)
+	require.Equal(t, symo.MetricsState{
+		LoadAvg:   symo.MetricState{Status: symo.StatusOK},
+		CPU:       symo.MetricState{Status: symo.StatusDisabled},
+		LoadDisks: symo.MetricState{Status: symo.StatusDisabled},
+		UsedFS:    symo.MetricState{Status: symo.StatusDisabled},
+	}, points[0].State)
+
+	stopCtx := context.Background()
+	collectorService.Stop(stopCtx)
+
+	// остановленный при перезагрузке коллектор не останавливается повторно
+	stops := 0
+	for _, call := range UsedFS.Calls {
+		if call.Arguments.Get(1) == symo.StopMetric {
+			stops++
+		}
+	}
+	require.Equal(t, 1, stops)
+
+	log.AssertExpectations(t)
+}
+
+func TestCollectorSamplingInterval(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	config, err := symo.NewConfig("")
+	require.NoError(t, err)
+	config.Metric.Loadavg = false
+	config.Metric.CPU = false
+	config.Metric.Loaddisks = false
+	config.Metric.Sampling.UsedFS.Interval = 2 * time.Second
+
+	log := new(mocks.Logger)
+	log.On("With", mock.Anything, mock.Anything).Return(log)
+	log.On("Debug", mock.Anything)
+	log.On("Debug", "tick ", mock.Anything)
+
+	fsData := symo.UsedFSData{{Path: "/", UsedSpace: 13}}
+	UsedFS := new(mocks.UsedFS)
+	UsedFS.On("Execute", mock.Anything, mock.Anything).Return(fsData, nil)
+
+	collectors := symo.MetricCollectors{
+		UsedFS: UsedFS.Execute,
+	}
+
+	toClientsCh := make(symo.CollectorToClientsCh, 1)
+
+	startCtx := context.Background()
+	collectorService := NewCollector(log, config, store.NewStore(config.App.MaxSeconds), nil)
+	collectorService.Start(startCtx, collectors, toClientsCh)
+
+	for i := 0; i < 4; i++ {
+		<-toClientsCh
+	}
+	data := <-toClientsCh
+
+	stopCtx := context.Background()
+	collectorService.Stop(stopCtx)
+
+	// файловые системы опрашиваются только в четные секунды, в остальных точках их нет,
+	// но состояние последнего опроса сохраняется
+	points := data.Points.Points(data.Time, 3)
+	require.Len(t, points, 3)
+	for _, point := range points {
+		if point.Time.Unix()%2 == 0 {
+			require.Equal(t, fsData, point.UsedFS)
+		} else {
+			require.Nil(t, point.UsedFS)
+		}
+		require.Equal(t, symo.MetricState{Status: symo.StatusOK}, point.State.UsedFS)
+	}
+	require.Equal(t, fsData, data.Points.Mean(data.Time, 3).UsedFS)
+
+	log.AssertExpectations(t)
+}
+
+func TestCollectorDropsLateSamples(t *testing.T) {
+	log := new(mocks.Logger)
+	log.On("Debug", "sample for the closed tick ", mock.Anything, " is dropped").Once()
+
+	start := time.Unix(1_600_000_000, 0)
+	c := &collector{
+		mutex:       &sync.Mutex{},
+		current:     &symo.Point{},
+		currentTime: start.Add(time.Second),
+		log:         log,
+	}
+	set := func(load float64) func(point *symo.Point) {
+		return func(point *symo.Point) {
+			point.LoadAvg = &symo.LoadAvgData{Load1: load}
+		}
+	}
+
+	// результат опроса прошлого тика не попадает в точку текущего
+	c.write(start, set(1))
+	require.Nil(t, c.current.LoadAvg)
+
+	c.write(start.Add(time.Second), set(2))
+	require.Equal(t, 2.0, c.current.LoadAvg.Load1)
+	log.AssertExpectations(t)
+}
+
+func TestCollectorSubSecondTick(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	config, err := symo.NewConfig("")
+	require.NoError(t, err)
+	config.App.Tick = 250 * time.Millisecond
+	config.Metric.Loadavg = false
+	config.Metric.Loaddisks = false
+	config.Metric.UsedFS = false
+	config.Metric.Sampling.CPU.Interval = 250 * time.Millisecond
+
+	log := new(mocks.Logger)
+	log.On("With", mock.Anything, mock.Anything).Return(log)
+	log.On("Debug", mock.Anything)
+	log.On("Debug", "tick ", mock.Anything)
+
+	cpuData := &symo.CPUData{User: 0.1, System: 0.2, Idle: 0.3}
+	CPU := new(mocks.CPU)
+	CPU.On("Execute", mock.Anything, mock.Anything).Return(cpuData, nil)
+
+	collectors := symo.MetricCollectors{
+		CPU: CPU.Execute,
+	}
+
+	toClientsCh := make(symo.CollectorToClientsCh, 1)
+
+	startCtx := context.Background()
+	collectorService := NewCollector(log, config, store.NewStore(config.App.MaxSeconds),
+		store.NewTickStore(config.App.Tick, symo.TickSeconds))
+	collectorService.Start(startCtx, collectors, toClientsCh)
+
+	// первая секунда может быть неполной, проверяется начало третьей секунды
+	var data symo.MetricsData
+	for seconds := 0; seconds < 3; {
+		data = <-toClientsCh
+		if data.Time.Equal(data.Time.Truncate(time.Second)) {
+			seconds++
+		}
+	}
+
+	stopCtx := context.Background()
+	collectorService.Stop(stopCtx)
+
+	require.Equal(t, config.App.Tick, data.Tick)
+
+	// в хранилище тиков точка каждого тика
+	ticks := data.Ticks.Points(data.Time, 4)
+	require.Len(t, ticks, 4)
+	for i, point := range ticks {
+		require.True(t, data.Time.Add(time.Duration(i-4)*config.App.Tick).Equal(point.Time))
+		require.Equal(t, cpuData, point.CPU)
+	}
+
+	// в посекундное хранилище попадает среднее тиков прошедшей секунды
+	points := data.Points.Points(data.Time, 1)
+	require.Len(t, points, 1)
+	require.True(t, data.Time.Add(-time.Second).Equal(points[0].Time))
+	require.Equal(t, cpuData, points[0].CPU)
+	require.Equal(t, symo.MetricState{Status: symo.StatusOK}, points[0].State.CPU)
+
+	log.AssertExpectations(t)
+}
+
+func TestCollectorStats(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	config, err := symo.NewConfig("")
+	require.NoError(t, err)
+	config.App.Tick = 250 * time.Millisecond
+	config.Metric.Loaddisks = false
+	config.Metric.Sampling.Loadavg.Interval = 250 * time.Millisecond
+	config.Metric.Sampling.CPU.Interval = 250 * time.Millisecond
+	config.Metric.Sampling.UsedFS = symo.MetricSampling{Interval: 250 * time.Millisecond, Timeout: 10 * time.Millisecond}
+
+	log := new(mocks.Logger)
+	log.On("With", mock.Anything, mock.Anything).Return(log)
+	log.On("Debug", mock.Anything)
+	log.On("Debug", "tick ", mock.Anything)
+
+	LoadAvg := new(mocks.LoadAvg)
+	LoadAvg.On("Execute", mock.Anything).Return(&symo.LoadAvgData{Load1: 1}, nil)
+
+	CPU := new(mocks.CPU)
+	CPU.On("Execute", mock.Anything, symo.StartMetric).Return(nil, nil)
+	CPU.On("Execute", mock.Anything, symo.GetMetric).Return(nil, errors.New("CPU Error"))
+
+	// файловые системы не успевают ответить за таймаут
+	usedFS := func(ctx context.Context, command symo.MetricCommand) (symo.UsedFSData, error) {
+		if command != symo.GetMetric {
+			return nil, nil
+		}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	collectors := symo.MetricCollectors{
+		LoadAvg: LoadAvg.Execute,
+		CPU:     CPU.Execute,
+		UsedFS:  usedFS,
+	}
+
+	toClientsCh := make(symo.CollectorToClientsCh, 1)
+
+	startCtx := context.Background()
+	collectorService := NewCollector(log, config, store.NewStore(config.App.MaxSeconds),
+		store.NewTickStore(config.App.Tick, symo.TickSeconds))
+	collectorService.Start(startCtx, collectors, toClientsCh)
+
+	for i := 0; i < 3; i++ {
+		<-toClientsCh
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	stats := collectorService.CollectorStats()
+
+	stopCtx := context.Background()
+	collectorService.Stop(stopCtx)
+
+	require.GreaterOrEqual(t, stats.Ticks, uint64(3))
+	require.Equal(t, uint64(0), stats.DroppedTicks)
+	require.Less(t, int64(stats.TickDuration), int64(config.App.Tick))
+
+	require.NotContains(t, stats.Samplers, "loaddisks")
+	la := stats.Samplers["loadavg"]
+	require.GreaterOrEqual(t, la.Samples, uint64(3))
+	require.Equal(t, uint64(0), la.Errors+la.Timeouts)
+	require.GreaterOrEqual(t, int64(la.LatencyTotal), int64(la.Latency))
+
+	cpu := stats.Samplers["cpu"]
+	require.Equal(t, cpu.Samples, cpu.Errors)
+	require.Equal(t, uint64(0), cpu.Timeouts)
+
+	fs := stats.Samplers["usedfs"]
+	require.Equal(t, fs.Samples, fs.Timeouts)
+	require.Equal(t, uint64(0), fs.Errors)
+	require.GreaterOrEqual(t, int64(fs.Latency), int64(10*time.Millisecond))
+
+	log.AssertExpectations(t)
+}
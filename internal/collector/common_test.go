@@ -9,19 +9,40 @@ import (
 )
 
 // общий код для тестирования всех горутин, отвечающих за сбор конкретных метрик.
-func testCollector() (context.Context, sync.Locker, <-chan timePoint, *symo.Point) {
+func testCollector() (context.Context, <-chan timePoint, writer, *symo.Point) {
+	return testSampling(symo.MetricSampling{}.WithDefaults(time.Second), 10*time.Millisecond)
+}
+
+// testSampling отправляет горутине тик, начавшийся сейчас, с заданной частотой опроса и останавливает ее через wait.
+func testSampling(sampling symo.MetricSampling, wait time.Duration) (context.Context, <-chan timePoint, writer, *symo.Point) {
+	return testSamplingAt(sampling, time.Now(), wait)
+}
+
+// testSamplingAt отправляет горутине тик, начавшийся в tm.
+func testSamplingAt(
+	sampling symo.MetricSampling,
+	tm time.Time,
+	wait time.Duration,
+) (context.Context, <-chan timePoint, writer, *symo.Point) {
 	ctx, cancel := context.WithCancel(context.Background())
 	mutex := &sync.Mutex{}
 	ch := make(chan timePoint, 1)
 
 	point := &symo.Point{}
+	write := func(_ time.Time, apply func(point *symo.Point)) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		apply(point)
+	}
 	go func() {
 		ch <- timePoint{
-			time:  time.Now().Truncate(time.Second),
-			point: point,
+			time:     tm,
+			tick:     time.Second,
+			sampling: sampling,
 		}
-		time.Sleep(10 * time.Millisecond)
+		time.Sleep(wait)
 		cancel()
 	}()
-	return ctx, mutex, ch, point
+	return ctx, ch, write, point
 }
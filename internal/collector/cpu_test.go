@@ -13,7 +13,7 @@ import (
 )
 
 func TestCPU(t *testing.T) {
-	ctx, mutex, ch, point := testCollector()
+	ctx, ch, write, point := testCollector()
 
 	log := new(mocks.Logger)
 
@@ -27,14 +27,15 @@ func TestCPU(t *testing.T) {
 		return &cpuData, nil
 	}
 
-	cpuCollect(ctx, mutex, ch, collector, log)
+	collect(ctx, ch, cpuSampler(collector), write, log)
 
 	log.AssertExpectations(t)
 	require.Equal(t, &cpuData, point.CPU)
+	require.Equal(t, symo.StatusOK, point.State.CPU.Status)
 }
 
 func TestCPUError(t *testing.T) {
-	ctx, mutex, ch, point := testCollector()
+	ctx, ch, write, point := testCollector()
 
 	log := new(mocks.Logger)
 	log.On("Debug", mock.Anything)
@@ -43,8 +44,10 @@ func TestCPUError(t *testing.T) {
 		return nil, fmt.Errorf("cannot read the stat file")
 	}
 
-	cpuCollect(ctx, mutex, ch, collector, log)
+	collect(ctx, ch, cpuSampler(collector), write, log)
 
 	log.AssertExpectations(t)
 	require.Nil(t, point.CPU)
+	require.Equal(t, symo.StatusError, point.State.CPU.Status)
+	require.NotEmpty(t, point.State.CPU.Message)
 }
@@ -13,7 +13,7 @@ import (
 )
 
 func TestLoadAvg(t *testing.T) {
-	ctx, mutex, ch, point := testCollector()
+	ctx, ch, write, point := testCollector()
 
 	log := new(mocks.Logger)
 
@@ -27,14 +27,15 @@ func TestLoadAvg(t *testing.T) {
 		return &loadAvg, nil
 	}
 
-	loadavgCollect(ctx, mutex, ch, collector, log)
+	collect(ctx, ch, loadavgSampler(collector), write, log)
 
 	log.AssertExpectations(t)
 	require.Equal(t, &loadAvg, point.LoadAvg)
+	require.Equal(t, symo.StatusOK, point.State.LoadAvg.Status)
 }
 
 func TestLoadAvgError(t *testing.T) {
-	ctx, mutex, ch, point := testCollector()
+	ctx, ch, write, point := testCollector()
 
 	log := new(mocks.Logger)
 	log.On("Debug", mock.Anything)
@@ -43,8 +44,10 @@ func TestLoadAvgError(t *testing.T) {
 		return nil, fmt.Errorf("cannot read the loadavg file")
 	}
 
-	loadavgCollect(ctx, mutex, ch, collector, log)
+	collect(ctx, ch, loadavgSampler(collector), write, log)
 
 	log.AssertExpectations(t)
 	require.Nil(t, point.LoadAvg)
+	require.Equal(t, symo.StatusError, point.State.LoadAvg.Status)
+	require.NotEmpty(t, point.State.LoadAvg.Message)
 }
@@ -13,7 +13,7 @@ import (
 )
 
 func TestLoadDisks(t *testing.T) {
-	ctx, mutex, ch, point := testCollector()
+	ctx, ch, write, point := testCollector()
 
 	log := new(mocks.Logger)
 
@@ -30,14 +30,15 @@ func TestLoadDisks(t *testing.T) {
 		return ldData, nil
 	}
 
-	loadDisksCollect(ctx, mutex, ch, collector, log)
+	collect(ctx, ch, loadDisksSampler(collector), write, log)
 
 	log.AssertExpectations(t)
 	require.Equal(t, ldData, point.LoadDisks)
+	require.Equal(t, symo.StatusOK, point.State.LoadDisks.Status)
 }
 
 func TestLoadDisksError(t *testing.T) {
-	ctx, mutex, ch, point := testCollector()
+	ctx, ch, write, point := testCollector()
 
 	log := new(mocks.Logger)
 	log.On("Debug", mock.Anything)
@@ -46,8 +47,10 @@ func TestLoadDisksError(t *testing.T) {
 		return nil, fmt.Errorf("cannot parse iostat line")
 	}
 
-	loadDisksCollect(ctx, mutex, ch, collector, log)
+	collect(ctx, ch, loadDisksSampler(collector), write, log)
 
 	log.AssertExpectations(t)
 	require.Nil(t, point.LoadDisks)
+	require.Equal(t, symo.StatusError, point.State.LoadDisks.Status)
+	require.NotEmpty(t, point.State.LoadDisks.Message)
 }
@@ -0,0 +1,125 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+type replay struct {
+	ctx       context.Context // управление остановкой сервиса
+	ctxCancel context.CancelFunc
+	stoppedCh chan interface{}
+	mutex     *sync.Mutex
+	ticks     uint64            // сколько точек воспроизведено
+	store     symo.PointsStore  // куда складываются воспроизводимые точки
+	source    symo.PointsSource // записанные точки
+	clock     *clock.Mock       // время сервиса клиентов, идет по записи
+	speed     float64           // во сколько раз воспроизведение быстрее реального времени
+	log       symo.Logger
+}
+
+// NewReplay возвращает сервис, который вместо сбора метрик воспроизводит записанные точки из source.
+// Тики идут со временем записи, паузы между ними сокращаются в speed раз. Часы clock выставляются
+// на время каждого тика, поэтому сервис клиентов, работающий по ним, ведет себя так же, как при записи.
+func NewReplay(log symo.Logger, points symo.PointsStore, source symo.PointsSource, clock *clock.Mock,
+	speed float64) symo.Collector {
+	return &replay{
+		mutex:  &sync.Mutex{},
+		store:  points,
+		source: source,
+		clock:  clock,
+		speed:  speed,
+		log:    log,
+	}
+}
+
+// Start запускает воспроизведение. Коллекторы метрик в этом режиме не используются.
+func (r *replay) Start(_ context.Context, _ symo.MetricCollectors, toClientsCh chan<- symo.MetricsData) {
+	r.ctx, r.ctxCancel = context.WithCancel(context.Background())
+	r.stoppedCh = make(chan interface{})
+
+	go r.work(toClientsCh)
+}
+
+func (r *replay) Stop(ctx context.Context) {
+	r.ctxCancel()
+
+	select {
+	case <-ctx.Done():
+	case <-r.stoppedCh:
+		r.log.Debug("replay is stopped")
+	}
+}
+
+// CollectorStats возвращает число воспроизведенных тиков. Коллекторы не опрашиваются, а тики не пропускаются.
+func (r *replay) CollectorStats() symo.CollectorStats {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return symo.CollectorStats{Ticks: r.ticks, Samplers: make(map[string]symo.SamplerStats)}
+}
+
+// Reload не меняет воспроизведение: в записи уже есть все собранные метрики.
+func (r *replay) Reload(_ context.Context, _ symo.Config) {
+	r.log.Debug("metrics are not reloaded in replay mode")
+}
+
+func (r *replay) work(toClientsCh chan<- symo.MetricsData) {
+	defer close(r.stoppedCh)
+
+	var prev time.Time
+	count := 0
+	for {
+		tm, point, err := r.source.Next()
+		if errors.Is(err, io.EOF) {
+			r.log.Info("replay is finished, ", count, " points replayed")
+			return
+		}
+		if err != nil {
+			r.log.Error(err)
+			return
+		}
+
+		if !prev.IsZero() && !r.wait(tm.Sub(prev)) {
+			return
+		}
+		prev = tm
+
+		// тик следующей секунды означает, что секунда точки завершена
+		now := tm.Add(time.Second)
+		r.store.Append(tm, point)
+		r.clock.Set(now)
+		count++
+
+		// тики не пропускаются, чтобы воспроизведение было одинаковым при любой скорости
+		select {
+		case <-r.ctx.Done():
+			return
+		case toClientsCh <- symo.MetricsData{Time: now, Points: r.store}:
+		}
+
+		r.mutex.Lock()
+		r.ticks++
+		r.mutex.Unlock()
+	}
+}
+
+// wait выдерживает паузу между точками записи с учетом скорости. Возвращает false, если сервис остановлен.
+func (r *replay) wait(pause time.Duration) bool {
+	timer := time.NewTimer(time.Duration(float64(pause) / r.speed))
+	defer timer.Stop()
+
+	select {
+	case <-r.ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,118 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/anfilat/final-stats/internal/mocks"
+	"github.com/anfilat/final-stats/internal/store"
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+type listSource struct {
+	times  []time.Time
+	points []symo.Point
+	err    error
+}
+
+func (l *listSource) Next() (time.Time, symo.Point, error) {
+	if len(l.times) == 0 {
+		if l.err != nil {
+			return time.Time{}, symo.Point{}, l.err
+		}
+		return time.Time{}, symo.Point{}, io.EOF
+	}
+	tm, point := l.times[0], l.points[0]
+	l.times, l.points = l.times[1:], l.points[1:]
+	return tm, point, nil
+}
+
+func TestReplay(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	start := time.Unix(1_600_000_000, 0)
+	source := &listSource{}
+	for i := 0; i < 5; i++ {
+		source.times = append(source.times, start.Add(time.Duration(i)*time.Second))
+		source.points = append(source.points, symo.Point{CPU: &symo.CPUData{User: float64(i)}})
+	}
+
+	log := new(mocks.Logger)
+	finished := make(chan struct{})
+	log.On("Info", "replay is finished, ", 5, " points replayed").Run(func(mock.Arguments) {
+		close(finished)
+	})
+	log.On("Debug", "replay is stopped")
+
+	points := store.NewStore(symo.MaxSeconds)
+	mockedClock := clock.NewMock()
+	// без буфера: тики не пропускаются, даже если их не успевают забирать
+	toClientsCh := make(chan symo.MetricsData)
+
+	replayService := NewReplay(log, points, source, mockedClock, 1000)
+	replayService.Start(context.Background(), symo.MetricCollectors{}, toClientsCh)
+
+	for i := 0; i < 5; i++ {
+		data := <-toClientsCh
+		now := start.Add(time.Duration(i+1) * time.Second)
+		require.True(t, now.Equal(data.Time))
+		require.True(t, now.Equal(mockedClock.Now()))
+		require.Equal(t, float64(i), data.Points.Mean(data.Time, 1).CPU.User)
+	}
+
+	<-finished
+	replayService.Stop(context.Background())
+
+	log.AssertExpectations(t)
+}
+
+func TestReplayError(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	readErr := errors.New("broken record")
+	log := new(mocks.Logger)
+	failed := make(chan struct{})
+	log.On("Error", readErr).Run(func(mock.Arguments) {
+		close(failed)
+	})
+	log.On("Debug", mock.Anything)
+
+	toClientsCh := make(chan symo.MetricsData, 1)
+	replayService := NewReplay(log, store.NewStore(symo.MaxSeconds), &listSource{err: readErr}, clock.NewMock(), 1)
+	replayService.Start(context.Background(), symo.MetricCollectors{}, toClientsCh)
+
+	<-failed
+	replayService.Stop(context.Background())
+
+	require.Len(t, toClientsCh, 0)
+	log.AssertExpectations(t)
+}
+
+func TestReplayStopWhileWaiting(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	start := time.Unix(1_600_000_000, 0)
+	source := &listSource{
+		times:  []time.Time{start, start.Add(time.Hour)},
+		points: []symo.Point{{}, {}},
+	}
+	log := new(mocks.Logger)
+	log.On("Debug", "replay is stopped")
+
+	toClientsCh := make(chan symo.MetricsData, 1)
+	replayService := NewReplay(log, store.NewStore(symo.MaxSeconds), source, clock.NewMock(), 1)
+	replayService.Start(context.Background(), symo.MetricCollectors{}, toClientsCh)
+
+	<-toClientsCh
+	replayService.Stop(context.Background())
+
+	log.AssertExpectations(t)
+}
@@ -0,0 +1,137 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+// sampler описывает, как получить одну метрику.
+type sampler struct {
+	name    string                                           // имя метрики из symo.MetricNames
+	state   func(state *symo.MetricsState) *symo.MetricState // состояние этой метрики в точке
+	get     func(ctx context.Context) (symo.Point, error)    // точка только с этой метрикой
+	observe observer                                         // учет опросов, может быть nil
+}
+
+// observer учитывает длительность и результат одного опроса коллектора.
+type observer func(latency time.Duration, err error)
+
+// writer применяет изменение к точке тика tm под мьютексом коллектора.
+type writer func(tm time.Time, apply func(point *symo.Point))
+
+// collect - горутина сбора одной метрики. На каждый тик коллектор опрашивается столько раз,
+// сколько интервалов опроса укладывается в тик.
+func collect(ctx context.Context, ch <-chan timePoint, s sampler, write writer, log symo.Logger) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case tp := <-ch:
+			s.sampleTick(ctx, tp, write, log)
+		}
+	}
+}
+
+// sampleTick после каждого опроса записывает в точку среднее полученных за тик значений, поэтому
+// неудачный или не уложившийся в отведенное время опрос не смещает среднее.
+// Если ни один опрос не удался, в точке остается ошибка или признак устаревания метрики.
+// Опросы расписаны от начала тика. Опрос, интервал которого уже прошел, пропускается, а время опроса
+// не выходит за конец тика, чтобы результат не опоздал к точке.
+func (s sampler) sampleTick(ctx context.Context, tp timePoint, write writer, log symo.Logger) {
+	sampling := tp.sampling
+	rollup := symo.NewRollup()
+	succeeded := false
+	tickEnd := tp.time.Add(tp.tick)
+
+	for i := 0; i < sampling.Samples(tp.tick); i++ {
+		slot := tp.time.Add(time.Duration(i) * sampling.Interval)
+		if !sleepUntil(ctx, slot) {
+			return
+		}
+		slotEnd := slot.Add(sampling.Interval)
+		if slotEnd.After(tickEnd) {
+			slotEnd = tickEnd
+		}
+		if !time.Now().Before(slotEnd) {
+			log.Debug("sample time has passed, sample is skipped")
+			continue
+		}
+		timeout := sampling.Timeout
+		if left := time.Until(tickEnd); left < timeout {
+			timeout = left
+		}
+
+		data, err := s.sample(ctx, timeout)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Debug(err)
+			if succeeded {
+				continue
+			}
+
+			state := symo.MetricState{Status: symo.StatusError, Message: err.Error()}
+			if errors.Is(err, context.DeadlineExceeded) {
+				state = symo.MetricState{Status: symo.StatusStale}
+			}
+			write(tp.time, func(point *symo.Point) {
+				*s.state(&point.State) = state
+			})
+			continue
+		}
+
+		rollup.Add(data)
+		succeeded = true
+		mean := rollup.Mean()
+		write(tp.time, func(point *symo.Point) {
+			setMetric(point, mean)
+			*s.state(&point.State) = symo.MetricState{Status: symo.StatusOK}
+		})
+	}
+}
+
+func (s sampler) sample(ctx context.Context, timeout time.Duration) (symo.Point, error) {
+	workCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	data, err := s.get(workCtx)
+	// опрос, прерванный остановкой сбора, не учитывается
+	if s.observe != nil && ctx.Err() == nil {
+		s.observe(time.Since(start), err)
+	}
+	return data, err
+}
+
+// sleepUntil ждет момента tm. Возвращает false, если сбор остановлен раньше.
+func sleepUntil(ctx context.Context, tm time.Time) bool {
+	timer := time.NewTimer(time.Until(tm))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// setMetric переносит в точку метрики, которые есть в data.
+func setMetric(point *symo.Point, data symo.Point) {
+	if data.LoadAvg != nil {
+		point.LoadAvg = data.LoadAvg
+	}
+	if data.CPU != nil {
+		point.CPU = data.CPU
+	}
+	if data.LoadDisks != nil {
+		point.LoadDisks = data.LoadDisks
+	}
+	if data.UsedFS != nil {
+		point.UsedFS = data.UsedFS
+	}
+}
@@ -0,0 +1,89 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anfilat/final-stats/internal/mocks"
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+func TestSampleSubSecond(t *testing.T) {
+	sampling := symo.MetricSampling{Interval: 250 * time.Millisecond, Timeout: 200 * time.Millisecond}
+	ctx, ch, write, point := testSampling(sampling, 900*time.Millisecond)
+
+	log := new(mocks.Logger)
+	log.On("Debug", mock.Anything)
+
+	// второй опрос завершается ошибкой и не участвует в среднем
+	mutex := &sync.Mutex{}
+	calls := 0
+	collector := func(_ context.Context) (*symo.LoadAvgData, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		calls++
+		if calls == 2 {
+			return nil, errors.New("cannot read the loadavg file")
+		}
+		return &symo.LoadAvgData{Load1: float64(calls)}, nil
+	}
+
+	collect(ctx, ch, loadavgSampler(collector), write, log)
+
+	log.AssertExpectations(t)
+	require.Equal(t, 4, calls)
+	require.InDelta(t, (1.0+3+4)/3, point.LoadAvg.Load1, 0.0001)
+	require.Equal(t, symo.StatusOK, point.State.LoadAvg.Status)
+}
+
+func TestSampleSkipsPassedSlots(t *testing.T) {
+	sampling := symo.MetricSampling{Interval: 250 * time.Millisecond, Timeout: 200 * time.Millisecond}
+	// тик начался 600ms назад: два первых интервала прошли, третий идет
+	ctx, ch, write, point := testSamplingAt(sampling, time.Now().Add(-600*time.Millisecond), 500*time.Millisecond)
+
+	log := new(mocks.Logger)
+	log.On("Debug", "sample time has passed, sample is skipped").Twice()
+
+	mutex := &sync.Mutex{}
+	calls := 0
+	collector := func(_ context.Context) (*symo.LoadAvgData, error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		calls++
+		return &symo.LoadAvgData{Load1: float64(calls)}, nil
+	}
+
+	collect(ctx, ch, loadavgSampler(collector), write, log)
+
+	log.AssertExpectations(t)
+	require.Equal(t, 2, calls)
+	require.InDelta(t, 1.5, point.LoadAvg.Load1, 0.0001)
+}
+
+func TestSampleTimeout(t *testing.T) {
+	sampling := symo.MetricSampling{Interval: time.Second, Timeout: 10 * time.Millisecond}
+	ctx, ch, write, point := testSampling(sampling, 50*time.Millisecond)
+	point.State.UsedFS = symo.MetricState{Status: symo.StatusOK}
+
+	log := new(mocks.Logger)
+	log.On("Debug", mock.Anything)
+
+	collector := func(ctx context.Context, _ symo.MetricCommand) (symo.UsedFSData, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	collect(ctx, ch, usedFSSampler(collector), write, log)
+
+	log.AssertExpectations(t)
+	require.Nil(t, point.UsedFS)
+	require.Equal(t, symo.MetricState{Status: symo.StatusStale}, point.State.UsedFS)
+}
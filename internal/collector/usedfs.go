@@ -2,37 +2,23 @@ package collector
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"sync"
 
 	"github.com/anfilat/final-stats/internal/symo"
 )
 
-func usedFSCollect(ctx context.Context, mutex sync.Locker, ch <-chan timePoint, collector symo.UsedFS, log symo.Logger) {
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case tp := <-ch:
-			func() {
-				workCtx, cancel := context.WithTimeout(ctx, timeToGetMetric)
-				defer cancel()
-
-				data, err := collector(workCtx, symo.GetMetric)
-				if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
-					return
-				}
-				if err != nil {
-					log.Debug(fmt.Errorf("cannot get used fs: %w", err))
-					return
-				}
-
-				mutex.Lock()
-				defer mutex.Unlock()
-
-				tp.point.UsedFS = data
-			}()
-		}
+func usedFSSampler(collector symo.UsedFS) sampler {
+	return sampler{
+		name: "usedfs",
+		state: func(state *symo.MetricsState) *symo.MetricState {
+			return &state.UsedFS
+		},
+		get: func(ctx context.Context) (symo.Point, error) {
+			data, err := collector(ctx, symo.GetMetric)
+			if err != nil {
+				return symo.Point{}, fmt.Errorf("cannot get used fs: %w", err)
+			}
+			return symo.Point{UsedFS: data}, nil
+		},
 	}
 }
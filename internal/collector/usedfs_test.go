@@ -13,7 +13,7 @@ import (
 )
 
 func TestUsedFS(t *testing.T) {
-	ctx, mutex, ch, point := testCollector()
+	ctx, ch, write, point := testCollector()
 
 	log := new(mocks.Logger)
 
@@ -29,14 +29,15 @@ func TestUsedFS(t *testing.T) {
 		return ufData, nil
 	}
 
-	usedFSCollect(ctx, mutex, ch, collector, log)
+	collect(ctx, ch, usedFSSampler(collector), write, log)
 
 	log.AssertExpectations(t)
 	require.Equal(t, ufData, point.UsedFS)
+	require.Equal(t, symo.StatusOK, point.State.UsedFS.Status)
 }
 
 func TestUsedFSError(t *testing.T) {
-	ctx, mutex, ch, point := testCollector()
+	ctx, ch, write, point := testCollector()
 
 	log := new(mocks.Logger)
 	log.On("Debug", mock.Anything)
@@ -45,8 +46,10 @@ func TestUsedFSError(t *testing.T) {
 		return nil, fmt.Errorf("cannot parse df line")
 	}
 
-	usedFSCollect(ctx, mutex, ch, collector, log)
+	collect(ctx, ch, usedFSSampler(collector), write, log)
 
 	log.AssertExpectations(t)
 	require.Nil(t, point.UsedFS)
+	require.Equal(t, symo.StatusError, point.State.UsedFS.Status)
+	require.NotEmpty(t, point.State.UsedFS.Message)
 }
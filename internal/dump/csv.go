@@ -0,0 +1,333 @@
+package dump
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+// В CSV по колонке на каждое поле метрики. Колонки дисков и файловых систем называются по имени,
+// например disk.tps[sda] или fs.used_space[/]. Пустая ячейка означает, что значения в эту секунду не было.
+// Состояние коллектора записывается как ok, stale, disabled, unknown или error: текст ошибки.
+
+var fixedColumns = []string{
+	"time",
+	"loadavg.load1", "loadavg.load5", "loadavg.load15",
+	"cpu.user", "cpu.system", "cpu.idle",
+}
+
+var stateColumns = []string{"loadavg.state", "cpu.state", "disks.state", "fs.state"}
+
+var diskFields = []string{"tps", "kb_read", "kb_write"}
+
+var fsFields = []string{"used_space", "used_inode"}
+
+var namedColumn = regexp.MustCompile(`^(disk|fs)\.(\w+)\[(.*)]$`)
+
+func writeCSV(w io.Writer, points []symo.TimedPoint) error {
+	disks, paths := collectNames(points)
+
+	header := append([]string{}, fixedColumns...)
+	for _, name := range disks {
+		for _, field := range diskFields {
+			header = append(header, "disk."+field+"["+name+"]")
+		}
+	}
+	for _, path := range paths {
+		for _, field := range fsFields {
+			header = append(header, "fs."+field+"["+path+"]")
+		}
+	}
+	header = append(header, stateColumns...)
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, point := range points {
+		if err := writer.Write(pointToRow(point, disks, paths)); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func collectNames(points []symo.TimedPoint) ([]string, []string) {
+	disks := make(map[string]bool)
+	paths := make(map[string]bool)
+	for _, point := range points {
+		for _, disk := range point.LoadDisks {
+			disks[disk.Name] = true
+		}
+		for _, fs := range point.UsedFS {
+			paths[fs.Path] = true
+		}
+	}
+	return sortedKeys(disks), sortedKeys(paths)
+}
+
+func sortedKeys(list map[string]bool) []string {
+	result := make([]string, 0, len(list))
+	for key := range list {
+		result = append(result, key)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func pointToRow(point symo.TimedPoint, disks, paths []string) []string {
+	row := []string{point.Time.UTC().Format(time.RFC3339)}
+
+	if data := point.LoadAvg; data != nil {
+		row = append(row, formatFloat(data.Load1), formatFloat(data.Load5), formatFloat(data.Load15))
+	} else {
+		row = append(row, "", "", "")
+	}
+	if data := point.CPU; data != nil {
+		row = append(row, formatFloat(data.User), formatFloat(data.System), formatFloat(data.Idle))
+	} else {
+		row = append(row, "", "", "")
+	}
+
+	loadDisks := make(map[string]symo.DiskData, len(point.LoadDisks))
+	for _, disk := range point.LoadDisks {
+		loadDisks[disk.Name] = disk
+	}
+	for _, name := range disks {
+		if disk, ok := loadDisks[name]; ok {
+			row = append(row, formatFloat(disk.Tps), formatFloat(disk.KBRead), formatFloat(disk.KBWrite))
+		} else {
+			row = append(row, "", "", "")
+		}
+	}
+
+	usedFS := make(map[string]symo.FSData, len(point.UsedFS))
+	for _, fs := range point.UsedFS {
+		usedFS[fs.Path] = fs
+	}
+	for _, path := range paths {
+		if fs, ok := usedFS[path]; ok {
+			row = append(row, formatFloat(fs.UsedSpace), formatFloat(fs.UsedInode))
+		} else {
+			row = append(row, "", "")
+		}
+	}
+
+	return append(row,
+		formatState(point.State.LoadAvg),
+		formatState(point.State.CPU),
+		formatState(point.State.LoadDisks),
+		formatState(point.State.UsedFS))
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func formatState(state symo.MetricState) string {
+	if state.Message == "" {
+		return state.Status.String()
+	}
+	return state.Status.String() + ": " + state.Message
+}
+
+// csvLayout - расположение колонок в прочитанном CSV.
+type csvLayout struct {
+	index map[string]int   // номера общих колонок и колонок состояний
+	disks map[string][]int // номера колонок полей дисков, в порядке diskFields
+	fs    map[string][]int // номера колонок полей файловых систем, в порядке fsFields
+	names []string         // имена дисков в порядке появления
+	paths []string         // пути файловых систем в порядке появления
+}
+
+func readCSV(r io.Reader) ([]symo.TimedPoint, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("cannot read csv header: %w", err)
+	}
+	layout, err := parseHeader(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []symo.TimedPoint
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot read csv: %w", err)
+		}
+		point, err := layout.point(row)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		result = append(result, point)
+	}
+}
+
+func parseHeader(header []string) (*csvLayout, error) {
+	layout := &csvLayout{
+		index: make(map[string]int),
+		disks: make(map[string][]int),
+		fs:    make(map[string][]int),
+	}
+
+	for i, column := range header {
+		match := namedColumn.FindStringSubmatch(column)
+		if match == nil {
+			layout.index[column] = i
+			continue
+		}
+
+		kind, field, name := match[1], match[2], match[3]
+		fields, list, names := diskFields, layout.disks, &layout.names
+		if kind == "fs" {
+			fields, list, names = fsFields, layout.fs, &layout.paths
+		}
+		pos := indexOf(fields, field)
+		if pos < 0 {
+			return nil, fmt.Errorf("unknown csv column %q", column)
+		}
+		if _, ok := list[name]; !ok {
+			list[name] = make([]int, len(fields))
+			for j := range list[name] {
+				list[name][j] = -1
+			}
+			*names = append(*names, name)
+		}
+		list[name][pos] = i
+	}
+
+	if _, ok := layout.index["time"]; !ok {
+		return nil, errors.New("csv column time is required")
+	}
+	return layout, nil
+}
+
+func indexOf(list []string, value string) int {
+	for i, item := range list {
+		if item == value {
+			return i
+		}
+	}
+	return -1
+}
+
+func (l *csvLayout) point(row []string) (symo.TimedPoint, error) {
+	var result symo.TimedPoint
+
+	tm, err := time.Parse(time.RFC3339, row[l.index["time"]])
+	if err != nil {
+		return result, err
+	}
+	result.Time = tm
+
+	values, err := l.floats(row, "loadavg.load1", "loadavg.load5", "loadavg.load15")
+	if err != nil {
+		return result, err
+	}
+	if values != nil {
+		result.LoadAvg = &symo.LoadAvgData{Load1: values[0], Load5: values[1], Load15: values[2]}
+	}
+
+	values, err = l.floats(row, "cpu.user", "cpu.system", "cpu.idle")
+	if err != nil {
+		return result, err
+	}
+	if values != nil {
+		result.CPU = &symo.CPUData{User: values[0], System: values[1], Idle: values[2]}
+	}
+
+	for _, name := range l.names {
+		values, err := cellFloats(row, l.disks[name])
+		if err != nil {
+			return result, err
+		}
+		if values != nil {
+			result.LoadDisks = append(result.LoadDisks, symo.DiskData{
+				Name: name, Tps: values[0], KBRead: values[1], KBWrite: values[2],
+			})
+		}
+	}
+	for _, path := range l.paths {
+		values, err := cellFloats(row, l.fs[path])
+		if err != nil {
+			return result, err
+		}
+		if values != nil {
+			result.UsedFS = append(result.UsedFS, symo.FSData{
+				Path: path, UsedSpace: values[0], UsedInode: values[1],
+			})
+		}
+	}
+
+	states := []*symo.MetricState{
+		&result.State.LoadAvg, &result.State.CPU, &result.State.LoadDisks, &result.State.UsedFS,
+	}
+	for i, column := range stateColumns {
+		if pos, ok := l.index[column]; ok && row[pos] != "" {
+			*states[i], err = parseState(row[pos])
+			if err != nil {
+				return result, err
+			}
+		}
+	}
+	return result, nil
+}
+
+func (l *csvLayout) floats(row []string, columns ...string) ([]float64, error) {
+	positions := make([]int, len(columns))
+	for i, column := range columns {
+		pos, ok := l.index[column]
+		if !ok {
+			pos = -1
+		}
+		positions[i] = pos
+	}
+	return cellFloats(row, positions)
+}
+
+// cellFloats возвращает значения ячеек или nil, если значения метрики нет. Отсутствующие колонки считаются нулями.
+func cellFloats(row []string, positions []int) ([]float64, error) {
+	result := make([]float64, len(positions))
+	present := false
+	for i, pos := range positions {
+		if pos < 0 || row[pos] == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(row[pos], 64)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = value
+		present = true
+	}
+	if !present {
+		return nil, nil
+	}
+	return result, nil
+}
+
+func parseState(cell string) (symo.MetricState, error) {
+	name, message := cell, ""
+	if pos := strings.Index(cell, ": "); pos >= 0 {
+		name, message = cell[:pos], cell[pos+2:]
+	}
+	status, err := symo.ParseMetricStatus(name)
+	if err != nil {
+		return symo.MetricState{}, err
+	}
+	return symo.MetricState{Status: status, Message: message}, nil
+}
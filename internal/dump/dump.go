@@ -0,0 +1,95 @@
+package dump
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+// Formats - поддерживаемые форматы дампа.
+var Formats = []string{"json", "csv"}
+
+// Write пишет посекундные точки в формате json или csv.
+func Write(w io.Writer, format string, points []symo.TimedPoint) error {
+	switch format {
+	case "json":
+		return writeJSON(w, points)
+	case "csv":
+		return writeCSV(w, points)
+	default:
+		return fmt.Errorf("unknown dump format %q", format)
+	}
+}
+
+// NewReader возвращает источник точек из дампа. Формат определяется по содержимому: JSON начинается с [.
+func NewReader(r io.Reader) (symo.PointsSource, error) {
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.Peek(1)
+		if errors.Is(err, io.EOF) {
+			return &listSource{}, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.ContainsAny(b, " \t\r\n") {
+			break
+		}
+		_, _ = br.Discard(1)
+	}
+
+	var (
+		points []symo.TimedPoint
+		err    error
+	)
+	if b, _ := br.Peek(1); b[0] == '[' {
+		points, err = readJSON(br)
+	} else {
+		points, err = readCSV(br)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &listSource{points: points}, nil
+}
+
+type listSource struct {
+	points []symo.TimedPoint
+}
+
+func (l *listSource) Next() (time.Time, symo.Point, error) {
+	if len(l.points) == 0 {
+		return time.Time{}, symo.Point{}, io.EOF
+	}
+	point := l.points[0]
+	l.points = l.points[1:]
+	return point.Time, point.Point, nil
+}
+
+// Seed добавляет в хранилище точки из source, завершенные до now, и возвращает их количество.
+// Точки не по порядку времени пропускаются.
+func Seed(points symo.PointsStore, source symo.PointsSource, now time.Time) (int, error) {
+	count := 0
+	var last time.Time
+	for {
+		tm, point, err := source.Next()
+		if errors.Is(err, io.EOF) {
+			return count, nil
+		}
+		if err != nil {
+			return count, err
+		}
+		if !tm.Before(now) || (count > 0 && !tm.After(last)) {
+			continue
+		}
+
+		points.Append(tm, point)
+		last = tm
+		count++
+	}
+}
@@ -0,0 +1,135 @@
+package dump
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/anfilat/final-stats/internal/store"
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+func somePoints() []symo.TimedPoint {
+	start := time.Unix(1_600_000_000, 0).UTC()
+	return []symo.TimedPoint{
+		{
+			Time: start,
+			Point: symo.Point{
+				LoadAvg:   &symo.LoadAvgData{Load1: 1.5, Load5: 1, Load15: 0.5},
+				CPU:       &symo.CPUData{User: 10, System: 5, Idle: 85},
+				LoadDisks: symo.LoadDisksData{{Name: "sda", Tps: 3, KBRead: 4, KBWrite: 5}},
+				UsedFS:    symo.UsedFSData{{Path: "/", UsedSpace: 40, UsedInode: 10}},
+				State: symo.MetricsState{
+					LoadAvg:   symo.MetricState{Status: symo.StatusOK},
+					CPU:       symo.MetricState{Status: symo.StatusOK},
+					LoadDisks: symo.MetricState{Status: symo.StatusOK},
+					UsedFS:    symo.MetricState{Status: symo.StatusOK},
+				},
+			},
+		},
+		{
+			Time: start.Add(time.Second),
+			Point: symo.Point{
+				LoadDisks: symo.LoadDisksData{{Name: "sdb", Tps: 1}},
+				UsedFS:    symo.UsedFSData{{Path: "/data, old", UsedSpace: 1, UsedInode: 2}},
+				State: symo.MetricsState{
+					LoadAvg: symo.MetricState{Status: symo.StatusStale},
+					CPU:     symo.MetricState{Status: symo.StatusError, Message: "cpu: no data"},
+					UsedFS:  symo.MetricState{Status: symo.StatusDisabled},
+				},
+			},
+		},
+	}
+}
+
+func readAll(t *testing.T, data []byte) []symo.TimedPoint {
+	source, err := NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	var result []symo.TimedPoint
+	for {
+		tm, point, err := source.Next()
+		if err != nil {
+			return result
+		}
+		result = append(result, symo.TimedPoint{Time: tm, Point: point})
+	}
+}
+
+func TestDumpRoundTrip(t *testing.T) {
+	for _, format := range Formats {
+		format := format
+		t.Run(format, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			require.NoError(t, Write(buf, format, somePoints()))
+			require.Equal(t, somePoints(), readAll(t, buf.Bytes()))
+		})
+	}
+}
+
+func TestDumpCSVColumns(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, Write(buf, "csv", somePoints()))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 3)
+	require.Equal(t, "time,loadavg.load1,loadavg.load5,loadavg.load15,cpu.user,cpu.system,cpu.idle,"+
+		"disk.tps[sda],disk.kb_read[sda],disk.kb_write[sda],disk.tps[sdb],disk.kb_read[sdb],disk.kb_write[sdb],"+
+		"fs.used_space[/],fs.used_inode[/],\"fs.used_space[/data, old]\",\"fs.used_inode[/data, old]\","+
+		"loadavg.state,cpu.state,disks.state,fs.state", lines[0])
+	require.Equal(t, "2020-09-13T12:26:40Z,1.5,1,0.5,10,5,85,3,4,5,,,,40,10,,,ok,ok,ok,ok", lines[1])
+}
+
+func TestDumpReadErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "bad json", data: `[{"time": 1}]`},
+		{name: "unknown status", data: `[{"time": "2020-09-13T12:26:40Z", "state": {"cpu": {"status": "bad"}}}]`},
+		{name: "no time column", data: "cpu.user\n1\n"},
+		{name: "unknown column", data: "time,disk.iops[sda]\n"},
+		{name: "bad value", data: "time,cpu.user\n2020-09-13T12:26:40Z,x\n"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewReader(strings.NewReader(tt.data))
+			require.Error(t, err)
+		})
+	}
+
+	require.Error(t, Write(&bytes.Buffer{}, "xml", nil))
+}
+
+func TestSeed(t *testing.T) {
+	points := somePoints()
+	start := points[0].Time
+	// повтор и точка текущей, еще не завершенной секунды пропускаются
+	points = append(points, points[0], symo.TimedPoint{Time: start.Add(2 * time.Second)})
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, Write(buf, "json", points))
+	source, err := NewReader(buf)
+	require.NoError(t, err)
+
+	st := store.NewStore(symo.MaxSeconds)
+	count, err := Seed(st, source, start.Add(2*time.Second))
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	seeded := st.Points(start.Add(2*time.Second), 10)
+	for i := range seeded {
+		seeded[i].Time = seeded[i].Time.UTC()
+	}
+	require.Equal(t, somePoints(), seeded)
+
+	source, err = NewReader(strings.NewReader("  \n"))
+	require.NoError(t, err)
+	count, err = Seed(st, source, start)
+	require.NoError(t, err)
+	require.Equal(t, 0, count)
+}
@@ -0,0 +1,162 @@
+package dump
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+type pointJSON struct {
+	Time      time.Time      `json:"time"`
+	LoadAvg   *loadAvgJSON   `json:"loadAvg,omitempty"`
+	CPU       *cpuJSON       `json:"cpu,omitempty"`
+	LoadDisks []loadDiskJSON `json:"loadDisks,omitempty"`
+	UsedFS    []usedFSJSON   `json:"usedFs,omitempty"`
+	State     stateJSON      `json:"state"`
+}
+
+type loadAvgJSON struct {
+	Load1  float64 `json:"load1"`
+	Load5  float64 `json:"load5"`
+	Load15 float64 `json:"load15"`
+}
+
+type cpuJSON struct {
+	User   float64 `json:"user"`
+	System float64 `json:"system"`
+	Idle   float64 `json:"idle"`
+}
+
+type loadDiskJSON struct {
+	Name    string  `json:"name"`
+	Tps     float64 `json:"tps"`
+	KBRead  float64 `json:"kbRead"`
+	KBWrite float64 `json:"kbWrite"`
+}
+
+type usedFSJSON struct {
+	Path      string  `json:"path"`
+	UsedSpace float64 `json:"usedSpace"`
+	UsedInode float64 `json:"usedInode"`
+}
+
+type stateJSON struct {
+	LoadAvg   metricStateJSON `json:"loadAvg"`
+	CPU       metricStateJSON `json:"cpu"`
+	LoadDisks metricStateJSON `json:"loadDisks"`
+	UsedFS    metricStateJSON `json:"usedFs"`
+}
+
+type metricStateJSON struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+func writeJSON(w io.Writer, points []symo.TimedPoint) error {
+	list := make([]pointJSON, 0, len(points))
+	for _, point := range points {
+		list = append(list, pointToJSON(point))
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(list)
+}
+
+func readJSON(r io.Reader) ([]symo.TimedPoint, error) {
+	var list []pointJSON
+	if err := json.NewDecoder(r).Decode(&list); err != nil {
+		return nil, fmt.Errorf("cannot decode json dump: %w", err)
+	}
+
+	result := make([]symo.TimedPoint, 0, len(list))
+	for _, item := range list {
+		point, err := pointFromJSON(item)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, point)
+	}
+	return result, nil
+}
+
+func pointToJSON(point symo.TimedPoint) pointJSON {
+	result := pointJSON{
+		Time: point.Time.UTC(),
+		State: stateJSON{
+			LoadAvg:   metricStateToJSON(point.State.LoadAvg),
+			CPU:       metricStateToJSON(point.State.CPU),
+			LoadDisks: metricStateToJSON(point.State.LoadDisks),
+			UsedFS:    metricStateToJSON(point.State.UsedFS),
+		},
+	}
+	if data := point.LoadAvg; data != nil {
+		result.LoadAvg = &loadAvgJSON{Load1: data.Load1, Load5: data.Load5, Load15: data.Load15}
+	}
+	if data := point.CPU; data != nil {
+		result.CPU = &cpuJSON{User: data.User, System: data.System, Idle: data.Idle}
+	}
+	for _, disk := range point.LoadDisks {
+		result.LoadDisks = append(result.LoadDisks, loadDiskJSON(disk))
+	}
+	for _, fs := range point.UsedFS {
+		result.UsedFS = append(result.UsedFS, usedFSJSON(fs))
+	}
+	return result
+}
+
+func pointFromJSON(item pointJSON) (symo.TimedPoint, error) {
+	result := symo.TimedPoint{Time: item.Time}
+	if data := item.LoadAvg; data != nil {
+		result.LoadAvg = &symo.LoadAvgData{Load1: data.Load1, Load5: data.Load5, Load15: data.Load15}
+	}
+	if data := item.CPU; data != nil {
+		result.CPU = &symo.CPUData{User: data.User, System: data.System, Idle: data.Idle}
+	}
+	for _, disk := range item.LoadDisks {
+		result.LoadDisks = append(result.LoadDisks, symo.DiskData(disk))
+	}
+	for _, fs := range item.UsedFS {
+		result.UsedFS = append(result.UsedFS, symo.FSData(fs))
+	}
+
+	var err error
+	states := []struct {
+		from metricStateJSON
+		to   *symo.MetricState
+	}{
+		{item.State.LoadAvg, &result.State.LoadAvg},
+		{item.State.CPU, &result.State.CPU},
+		{item.State.LoadDisks, &result.State.LoadDisks},
+		{item.State.UsedFS, &result.State.UsedFS},
+	}
+	for _, state := range states {
+		*state.to, err = metricStateFromJSON(state.from)
+		if err != nil {
+			return result, fmt.Errorf("point %v: %w", item.Time, err)
+		}
+	}
+	return result, nil
+}
+
+func metricStateToJSON(state symo.MetricState) metricStateJSON {
+	return metricStateJSON{
+		Status:  state.Status.String(),
+		Message: state.Message,
+	}
+}
+
+// состояние может отсутствовать в дампе, тогда метрика считается полученной.
+func metricStateFromJSON(state metricStateJSON) (symo.MetricState, error) {
+	if state.Status == "" {
+		return symo.MetricState{}, nil
+	}
+	status, err := symo.ParseMetricStatus(state.Status)
+	if err != nil {
+		return symo.MetricState{}, err
+	}
+	return symo.MetricState{Status: status, Message: state.Message}, nil
+}
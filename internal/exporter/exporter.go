@@ -0,0 +1,171 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+type exporter struct {
+	conf      symo.ExporterConf
+	log       symo.Logger
+	encode    encoder
+	sender    sender
+	queue     *queue
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+	wg        *sync.WaitGroup
+}
+
+// NewExporter возвращает экспортер, отправляющий статистику каждые N секунд во внешнюю систему.
+func NewExporter(log symo.Logger, conf symo.ExporterConf) symo.Exporter {
+	return &exporter{
+		conf:   conf,
+		log:    log,
+		encode: newEncoder(conf),
+		sender: newSender(conf),
+		queue:  newQueue(conf.QueueSize),
+		wg:     &sync.WaitGroup{},
+	}
+}
+
+// Start подключает экспортер к сервису клиентов как обычного клиента с параметрами N и M из конфига.
+func (e *exporter) Start(clients symo.NewClienter) error {
+	ch, del, err := clients.NewClient(symo.ClientData{
+		N:        time.Duration(e.conf.N) * time.Second,
+		M:        time.Duration(e.conf.M) * time.Second,
+		Policy:   symo.PolicyDropOldest,
+		Exporter: true,
+	})
+	if err != nil {
+		return fmt.Errorf("exporter %q: %w", e.conf.Name, err)
+	}
+
+	e.ctx, e.ctxCancel = context.WithCancel(context.Background())
+	e.wg.Add(2)
+	go e.read(ch, del)
+	go e.write()
+
+	e.log.Debug("exporter ", e.conf.Name, " is started")
+	return nil
+}
+
+func (e *exporter) Stop(ctx context.Context) {
+	if e.ctxCancel == nil {
+		return
+	}
+	e.ctxCancel()
+
+	stopped := make(chan interface{})
+	go func() {
+		e.wg.Wait()
+		close(stopped)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-stopped:
+	}
+	e.sender.close()
+
+	e.log.Debug("exporter ", e.conf.Name, " is stopped")
+}
+
+// read переводит пакеты статистики в формат приемника и складывает в очередь.
+func (e *exporter) read(ch <-chan *symo.Stats, del func()) {
+	defer e.wg.Done()
+	defer del()
+
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+		case data, ok := <-ch:
+			if !ok {
+				return
+			}
+			if data.Err != nil {
+				continue
+			}
+			payload := e.encode(data)
+			if len(payload) == 0 {
+				continue
+			}
+			if !e.queue.push(payload) {
+				e.log.Debug("exporter ", e.conf.Name, " queue is full, the oldest stats are dropped")
+			}
+		}
+	}
+}
+
+// write отправляет все накопленные пакеты разом. Пакеты, которые не удалось отправить, возвращаются в начало
+// очереди и отправляются вместе со следующими, так что пока приемник недоступен, пакеты копятся в очереди.
+func (e *exporter) write() {
+	defer e.wg.Done()
+
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+		case <-e.queue.ready():
+		}
+
+		batch := e.queue.takeAll()
+		if len(batch) == 0 {
+			continue
+		}
+		rest, err := e.send(batch)
+		if err == nil {
+			continue
+		}
+		if e.ctx.Err() != nil {
+			return
+		}
+		e.log.Error(fmt.Errorf("exporter %q: unable to send %d stats: %w", e.conf.Name, len(rest), err))
+		if !e.queue.pushFront(rest) {
+			e.log.Debug("exporter ", e.conf.Name, " queue is full, the oldest stats are dropped")
+		}
+	}
+}
+
+// send отправляет пакеты с повторами. Возвращает пакеты, которые так и не удалось отправить.
+func (e *exporter) send(batch [][]byte) ([][]byte, error) {
+	var err error
+	for attempt := 0; attempt <= e.conf.Retries; attempt++ {
+		if attempt > 0 {
+			e.log.Debug("exporter ", e.conf.Name, " retry after error: ", err)
+
+			timer := time.NewTimer(e.conf.RetryDelay)
+			select {
+			case <-e.ctx.Done():
+				timer.Stop()
+				return batch, e.ctx.Err()
+			case <-timer.C:
+			}
+		}
+
+		var sent int
+		sent, err = e.sender.send(e.ctx, batch)
+		batch = unsent(batch, sent)
+		if err == nil {
+			return nil, nil
+		}
+	}
+	return batch, err
+}
+
+// unsent возвращает пакеты без первых sent байт, уже доставленных приемнику.
+func unsent(batch [][]byte, sent int) [][]byte {
+	for len(batch) > 0 && sent >= len(batch[0]) {
+		sent -= len(batch[0])
+		batch = batch[1:]
+	}
+	if len(batch) > 0 && sent > 0 {
+		batch[0] = batch[0][sent:]
+	}
+	return batch
+}
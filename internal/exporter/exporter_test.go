@@ -0,0 +1,276 @@
+package exporter
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/anfilat/final-stats/internal/mocks"
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+func TestExporterTCP(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	lsn, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer lsn.Close()
+
+	lines := make(chan string, 100)
+	go func() {
+		conn, err := lsn.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	conf := testConf("graphite", "tcp", lsn.Addr().String())
+	ch, stop := startExporter(t, conf)
+	defer stop()
+
+	ch <- testStats()
+	require.Equal(t, "symo.load_average.load1 0.5 1600000000", <-lines)
+}
+
+func TestExporterUDP(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conf := testConf("influx", "udp", conn.LocalAddr().String())
+	ch, stop := startExporter(t, conf)
+	defer stop()
+
+	ch <- testStats()
+
+	buf := make([]byte, maxUDPPacket)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	require.Contains(t, string(buf[:n]), "symo_cpu user=10,system=5.25,idle=84.75 1600000000000000000\n")
+}
+
+func TestExporterHTTPRetries(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	mutex := &sync.Mutex{}
+	var requests int
+	bodies := make(chan string, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requests++
+		fail := requests == 1
+		mutex.Unlock()
+
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies <- string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	conf := testConf("influx", "http", srv.URL+"/write?db=symo")
+	conf.Retries = 2
+	ch, stop := startExporter(t, conf)
+	defer stop()
+
+	ch <- testStats()
+	body := <-bodies
+	require.Contains(t, body, "symo_load_average load1=0.5,load5=1,load15=1.5 1600000000000000000\n")
+
+	mutex.Lock()
+	require.Equal(t, 2, requests)
+	mutex.Unlock()
+}
+
+func TestExporterBuffersWhileUnavailable(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	// адрес, на котором никто не слушает
+	lsn, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := lsn.Addr().String()
+	require.NoError(t, lsn.Close())
+
+	conf := testConf("graphite", "tcp", addr)
+	conf.QueueSize = 2
+	conf.Retries = 0
+
+	e := NewExporter(testLogger(), conf).(*exporter)
+	e.ctx, e.ctxCancel = context.WithCancel(context.Background())
+	defer e.ctxCancel()
+
+	// при переполнении очереди отбрасывается самый старый пакет
+	for i := 0; i < 3; i++ {
+		stats := testStats()
+		stats.Time = stats.Time.Add(time.Duration(i) * time.Second)
+		e.queue.push(e.encode(stats))
+	}
+
+	batch := e.queue.takeAll()
+	require.Len(t, batch, 2)
+	require.Contains(t, string(batch[0]), " 1600000001\n")
+	rest, err := e.send(batch)
+	require.Error(t, err)
+	require.Equal(t, batch, rest)
+
+	// неотправленные пакеты возвращаются в начало очереди, новые вытесняют самые старые из них
+	require.True(t, e.queue.pushFront(rest))
+	stats := testStats()
+	stats.Time = stats.Time.Add(3 * time.Second)
+	require.False(t, e.queue.push(e.encode(stats)))
+	batch = e.queue.takeAll()
+	require.Len(t, batch, 2)
+	require.Contains(t, string(batch[0]), " 1600000002\n")
+	require.Contains(t, string(batch[1]), " 1600000003\n")
+}
+
+func TestExporterResendsFailedStats(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	mutex := &sync.Mutex{}
+	var requests int
+	bodies := make(chan string, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requests++
+		fail := requests == 1
+		mutex.Unlock()
+
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies <- string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	conf := testConf("influx", "http", srv.URL)
+	ch, stop := startExporter(t, conf)
+	defer stop()
+
+	// без повторов первая статистика не отправлена и уходит вместе со следующей
+	ch <- testStats()
+	second := testStats()
+	second.Time = second.Time.Add(time.Second)
+	require.Eventually(t, func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return requests == 1
+	}, time.Second, time.Millisecond)
+	ch <- second
+
+	body := <-bodies
+	require.Contains(t, body, "load15=1.5 1600000000000000000\n")
+	require.Contains(t, body, "load15=1.5 1600000001000000000\n")
+}
+
+func TestExporterSendsRest(t *testing.T) {
+	conf := testConf("graphite", "tcp", "")
+	conf.Retries = 1
+	e := NewExporter(testLogger(), conf).(*exporter)
+	e.ctx, e.ctxCancel = context.WithCancel(context.Background())
+	defer e.ctxCancel()
+
+	// первая строка второго пакета доставлена до обрыва соединения
+	sender := &partialSender{sent: len("a 1\nb 2\n") + len("c 3\n"), err: errors.New("broken pipe")}
+	e.sender = sender
+	rest, err := e.send([][]byte{[]byte("a 1\nb 2\n"), []byte("c 3\nd 4\n")})
+	require.NoError(t, err)
+	require.Nil(t, rest)
+	require.Equal(t, [][][]byte{
+		{[]byte("a 1\nb 2\n"), []byte("c 3\nd 4\n")},
+		{[]byte("d 4\n")},
+	}, sender.batches)
+}
+
+func TestUnsent(t *testing.T) {
+	batch := func() [][]byte {
+		return [][]byte{[]byte("a 1\nb 2\n"), []byte("c 3\n")}
+	}
+	require.Equal(t, batch(), unsent(batch(), 0))
+	require.Equal(t, [][]byte{[]byte("b 2\n"), []byte("c 3\n")}, unsent(batch(), 4))
+	require.Equal(t, [][]byte{[]byte("c 3\n")}, unsent(batch(), 8))
+	require.Empty(t, unsent(batch(), 12))
+}
+
+// partialSender при первой отправке доставляет sent байт и возвращает ошибку, затем отправляет все.
+type partialSender struct {
+	sent    int
+	err     error
+	batches [][][]byte
+}
+
+func (s *partialSender) send(_ context.Context, batch [][]byte) (int, error) {
+	s.batches = append(s.batches, append([][]byte(nil), batch...))
+	if len(s.batches) == 1 {
+		return s.sent, s.err
+	}
+	size := 0
+	for _, item := range batch {
+		size += len(item)
+	}
+	return size, nil
+}
+
+func (s *partialSender) close() {}
+
+func testConf(format, protocol, address string) symo.ExporterConf {
+	return symo.ExporterConf{
+		Name:       "test",
+		Format:     format,
+		Protocol:   protocol,
+		Address:    address,
+		N:          1,
+		M:          1,
+		Prefix:     "symo",
+		QueueSize:  10,
+		RetryDelay: 10 * time.Millisecond,
+		Timeout:    time.Second,
+	}
+}
+
+func testLogger() *mocks.Logger {
+	log := new(mocks.Logger)
+	log.On("Debug", mock.Anything, mock.Anything, mock.Anything)
+	log.On("Debug", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	log.On("Error", mock.Anything)
+	return log
+}
+
+func startExporter(t *testing.T, conf symo.ExporterConf) (chan<- *symo.Stats, func()) {
+	ch := make(chan *symo.Stats, 1)
+	clientsService := new(mocks.NewClienter)
+	clientData := symo.ClientData{N: time.Duration(conf.N) * time.Second, M: time.Duration(conf.M) * time.Second, Policy: symo.PolicyDropOldest, Exporter: true}
+	clientsService.On("NewClient", clientData).Return((<-chan *symo.Stats)(ch), func() {}, nil)
+
+	e := NewExporter(testLogger(), conf)
+	require.NoError(t, e.Start(clientsService))
+
+	return ch, func() {
+		e.Stop(context.Background())
+	}
+}
@@ -0,0 +1,173 @@
+package exporter
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+// encoder переводит пакет статистики в формат внешней системы. Пустой результат не отправляется.
+type encoder func(stats *symo.Stats) []byte
+
+func newEncoder(conf symo.ExporterConf) encoder {
+	switch conf.Format {
+	case "graphite":
+		return graphiteEncoder(conf.Prefix, conf.Tags)
+	case "statsd":
+		return statsDEncoder(conf.Prefix, conf.Tags, false, conf.SampleRate, newRandom())
+	case "dogstatsd":
+		return statsDEncoder(conf.Prefix, conf.Tags, true, conf.SampleRate, newRandom())
+	case "otlp":
+		return otlpEncoder(conf.Prefix, conf.M, newResource(conf.Tags))
+	default:
+		return influxEncoder(conf.Prefix, conf.Tags)
+	}
+}
+
+type field struct {
+	name  string
+	value float64
+}
+
+var (
+	influxMeasurementReplacer = strings.NewReplacer(",", `\,`, " ", `\ `)
+	influxTagReplacer         = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+)
+
+// influxEncoder пишет статистику в InfluxDB line protocol, по строке на метрику.
+func influxEncoder(prefix string, tags map[string]string) encoder {
+	return func(stats *symo.Stats) []byte {
+		buf := &bytes.Buffer{}
+		ts := strconv.FormatInt(stats.Time.UnixNano(), 10)
+
+		line := func(measurement, tagName, tagValue string, fields ...field) {
+			buf.WriteString(influxMeasurementReplacer.Replace(prefix + "_" + measurement))
+			lineTags := tags
+			if tagName != "" {
+				lineTags = withTag(tags, tagName, tagValue)
+			}
+			for _, name := range sortedKeys(lineTags) {
+				buf.WriteByte(',')
+				buf.WriteString(influxTagReplacer.Replace(name))
+				buf.WriteByte('=')
+				buf.WriteString(influxTagReplacer.Replace(lineTags[name]))
+			}
+			for i, f := range fields {
+				if i == 0 {
+					buf.WriteByte(' ')
+				} else {
+					buf.WriteByte(',')
+				}
+				buf.WriteString(f.name)
+				buf.WriteByte('=')
+				buf.WriteString(formatFloat(f.value))
+			}
+			buf.WriteByte(' ')
+			buf.WriteString(ts)
+			buf.WriteByte('\n')
+		}
+
+		if la := stats.LoadAvg; la != nil {
+			line("load_average", "", "",
+				field{"load1", la.Load1}, field{"load5", la.Load5}, field{"load15", la.Load15})
+		}
+		if cpu := stats.CPU; cpu != nil {
+			line("cpu", "", "",
+				field{"user", cpu.User}, field{"system", cpu.System}, field{"idle", cpu.Idle})
+		}
+		for _, disk := range stats.LoadDisks {
+			line("disk", "disk", disk.Name,
+				field{"tps", disk.Tps}, field{"kb_read", disk.KBRead}, field{"kb_write", disk.KBWrite})
+		}
+		for _, fs := range stats.UsedFS {
+			line("fs", "path", fs.Path,
+				field{"used_space", fs.UsedSpace}, field{"used_inode", fs.UsedInode})
+		}
+		return buf.Bytes()
+	}
+}
+
+// graphiteEncoder пишет статистику в plaintext протоколе Graphite. Теги передаются в формате Graphite 1.1.
+func graphiteEncoder(prefix string, tags map[string]string) encoder {
+	var suffix strings.Builder
+	for _, name := range sortedKeys(tags) {
+		suffix.WriteString(";" + graphiteName(name) + "=" + graphiteName(tags[name]))
+	}
+	tagsSuffix := suffix.String()
+
+	return func(stats *symo.Stats) []byte {
+		buf := &bytes.Buffer{}
+		ts := strconv.FormatInt(stats.Time.Unix(), 10)
+
+		line := func(path string, value float64) {
+			buf.WriteString(prefix + "." + path + tagsSuffix + " " + formatFloat(value) + " " + ts + "\n")
+		}
+
+		if la := stats.LoadAvg; la != nil {
+			line("load_average.load1", la.Load1)
+			line("load_average.load5", la.Load5)
+			line("load_average.load15", la.Load15)
+		}
+		if cpu := stats.CPU; cpu != nil {
+			line("cpu.user", cpu.User)
+			line("cpu.system", cpu.System)
+			line("cpu.idle", cpu.Idle)
+		}
+		for _, disk := range stats.LoadDisks {
+			name := "disk." + graphiteName(disk.Name)
+			line(name+".tps", disk.Tps)
+			line(name+".kb_read", disk.KBRead)
+			line(name+".kb_write", disk.KBWrite)
+		}
+		for _, fs := range stats.UsedFS {
+			name := "fs." + graphitePath(fs.Path)
+			line(name+".used_space", fs.UsedSpace)
+			line(name+".used_inode", fs.UsedInode)
+		}
+		return buf.Bytes()
+	}
+}
+
+// graphiteName заменяет символы, недопустимые в узле имени метрики Graphite.
+func graphiteName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// graphitePath превращает путь файловой системы в узел имени: "/" - root, "/var/log" - var_log.
+func graphitePath(path string) string {
+	path = strings.Trim(path, `/\`)
+	if path == "" {
+		return "root"
+	}
+	return graphiteName(path)
+}
+
+func withTag(tags map[string]string, name, value string) map[string]string {
+	result := make(map[string]string, len(tags)+1)
+	for k, v := range tags {
+		result[k] = v
+	}
+	result[name] = value
+	return result
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
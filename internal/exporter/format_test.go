@@ -0,0 +1,76 @@
+package exporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+func TestInflux(t *testing.T) {
+	encode := influxEncoder("symo", map[string]string{"host": "web 1", "dc": "eu"})
+
+	result := encode(testStats())
+	require.Equal(t, ""+
+		"symo_load_average,dc=eu,host=web\\ 1 load1=0.5,load5=1,load15=1.5 1600000000000000000\n"+
+		"symo_cpu,dc=eu,host=web\\ 1 user=10,system=5.25,idle=84.75 1600000000000000000\n"+
+		"symo_disk,dc=eu,disk=sda,host=web\\ 1 tps=7,kb_read=8,kb_write=9 1600000000000000000\n"+
+		"symo_fs,dc=eu,host=web\\ 1,path=/ used_space=12.5,used_inode=3 1600000000000000000\n"+
+		"symo_fs,dc=eu,host=web\\ 1,path=/mnt/my\\,disk used_space=50,used_inode=0 1600000000000000000\n",
+		string(result))
+}
+
+func TestGraphite(t *testing.T) {
+	encode := graphiteEncoder("symo", map[string]string{"host": "web.1"})
+
+	result := encode(testStats())
+	require.Equal(t, ""+
+		"symo.load_average.load1;host=web_1 0.5 1600000000\n"+
+		"symo.load_average.load5;host=web_1 1 1600000000\n"+
+		"symo.load_average.load15;host=web_1 1.5 1600000000\n"+
+		"symo.cpu.user;host=web_1 10 1600000000\n"+
+		"symo.cpu.system;host=web_1 5.25 1600000000\n"+
+		"symo.cpu.idle;host=web_1 84.75 1600000000\n"+
+		"symo.disk.sda.tps;host=web_1 7 1600000000\n"+
+		"symo.disk.sda.kb_read;host=web_1 8 1600000000\n"+
+		"symo.disk.sda.kb_write;host=web_1 9 1600000000\n"+
+		"symo.fs.root.used_space;host=web_1 12.5 1600000000\n"+
+		"symo.fs.root.used_inode;host=web_1 3 1600000000\n"+
+		"symo.fs.mnt_my_disk.used_space;host=web_1 50 1600000000\n"+
+		"symo.fs.mnt_my_disk.used_inode;host=web_1 0 1600000000\n",
+		string(result))
+}
+
+func TestEmptyStats(t *testing.T) {
+	stats := &symo.Stats{Time: time.Unix(1600000000, 0)}
+
+	require.Empty(t, influxEncoder("symo", nil)(stats))
+	require.Empty(t, graphiteEncoder("symo", nil)(stats))
+}
+
+func TestSplitLines(t *testing.T) {
+	data := []byte("aaaa\nbbbb\ncc\ndddddddddd\ne")
+	require.Equal(t, [][]byte{
+		[]byte("aaaa\nbbbb\n"),
+		[]byte("cc\n"),
+		[]byte("dddddddddd\n"),
+		[]byte("e"),
+	}, splitLines(data, 10))
+}
+
+func testStats() *symo.Stats {
+	return &symo.Stats{
+		Time:    time.Unix(1600000000, 0),
+		LoadAvg: &symo.LoadAvgData{Load1: 0.5, Load5: 1, Load15: 1.5},
+		CPU:     &symo.CPUData{User: 10, System: 5.25, Idle: 84.75},
+		LoadDisks: symo.LoadDisksData{
+			{Name: "sda", Tps: 7, KBRead: 8, KBWrite: 9},
+		},
+		UsedFS: symo.UsedFSData{
+			{Path: "/", UsedSpace: 12.5, UsedInode: 3},
+			{Path: "/mnt/my,disk", UsedSpace: 50, UsedInode: 0},
+		},
+	}
+}
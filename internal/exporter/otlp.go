@@ -0,0 +1,176 @@
+package exporter
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"time"
+
+	collectorpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+// otlpEncoder переводит статистику в OTLP запрос с гейджами. Запрос хранится в очереди сериализованным,
+// чтобы очередь не зависела от формата.
+func otlpEncoder(prefix string, m int, resource *resourcepb.Resource) encoder {
+	return func(stats *symo.Stats) []byte {
+		end := uint64(stats.Time.UnixNano())
+		start := uint64(stats.Time.Add(-time.Duration(m) * time.Second).UnixNano())
+
+		var metrics []*metricspb.Metric
+		gauge := func(name, description, unit string, points ...*metricspb.DoubleDataPoint) {
+			for _, point := range points {
+				point.StartTimeUnixNano = start
+				point.TimeUnixNano = end
+			}
+			metrics = append(metrics, &metricspb.Metric{
+				Name:        prefix + "." + name,
+				Description: description,
+				Unit:        unit,
+				Data: &metricspb.Metric_DoubleGauge{
+					DoubleGauge: &metricspb.DoubleGauge{DataPoints: points},
+				},
+			})
+		}
+
+		if la := stats.LoadAvg; la != nil {
+			gauge("load_average", "System load average.", "1",
+				point(la.Load1, "period", "1m"), point(la.Load5, "period", "5m"), point(la.Load15, "period", "15m"))
+		}
+		if cpu := stats.CPU; cpu != nil {
+			gauge("cpu.utilization", "CPU usage in percent.", "%",
+				point(cpu.User, "state", "user"), point(cpu.System, "state", "system"), point(cpu.Idle, "state", "idle"))
+		}
+		if len(stats.LoadDisks) > 0 {
+			var tps, read, write []*metricspb.DoubleDataPoint
+			for _, disk := range stats.LoadDisks {
+				tps = append(tps, point(disk.Tps, "device", disk.Name))
+				read = append(read, point(disk.KBRead, "device", disk.Name))
+				write = append(write, point(disk.KBWrite, "device", disk.Name))
+			}
+			gauge("disk.tps", "Disk transfers per second.", "{transfers}/s", tps...)
+			gauge("disk.read", "Kilobytes read from the disk per second.", "kBy/s", read...)
+			gauge("disk.write", "Kilobytes written to the disk per second.", "kBy/s", write...)
+		}
+		if len(stats.UsedFS) > 0 {
+			var space, inode []*metricspb.DoubleDataPoint
+			for _, fs := range stats.UsedFS {
+				space = append(space, point(fs.UsedSpace, "path", fs.Path))
+				inode = append(inode, point(fs.UsedInode, "path", fs.Path))
+			}
+			gauge("fs.used_space", "Used file system space in percent.", "%", space...)
+			gauge("fs.used_inodes", "Used file system inodes in percent.", "%", inode...)
+		}
+
+		if len(metrics) == 0 {
+			return nil
+		}
+		req := &collectorpb.ExportMetricsServiceRequest{
+			ResourceMetrics: []*metricspb.ResourceMetrics{{
+				Resource: resource,
+				InstrumentationLibraryMetrics: []*metricspb.InstrumentationLibraryMetrics{{
+					InstrumentationLibrary: &commonpb.InstrumentationLibrary{Name: "symo"},
+					Metrics:                metrics,
+				}},
+			}},
+		}
+		data, err := proto.Marshal(req)
+		if err != nil {
+			return nil
+		}
+		return data
+	}
+}
+
+func point(value float64, label, labelValue string) *metricspb.DoubleDataPoint {
+	return &metricspb.DoubleDataPoint{
+		Labels: []*commonpb.StringKeyValue{{Key: label, Value: labelValue}},
+		Value:  value,
+	}
+}
+
+// newResource описывает хост, с которого собраны метрики. Теги из конфига добавляются к атрибутам хоста.
+func newResource(tags map[string]string) *resourcepb.Resource {
+	attributes := map[string]string{
+		"service.name": "symo",
+		"os.type":      runtime.GOOS,
+		"host.arch":    runtime.GOARCH,
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		attributes["host.name"] = hostname
+	}
+	for name, value := range tags {
+		attributes[name] = value
+	}
+
+	resource := &resourcepb.Resource{}
+	for _, name := range sortedKeys(attributes) {
+		resource.Attributes = append(resource.Attributes, &commonpb.KeyValue{
+			Key:   name,
+			Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: attributes[name]}},
+		})
+	}
+	return resource
+}
+
+// otlpSender отправляет накопленные запросы одним вызовом Export коллектора OpenTelemetry.
+type otlpSender struct {
+	address  string
+	headers  map[string]string
+	insecure bool
+	timeout  time.Duration
+	conn     *grpc.ClientConn
+	client   collectorpb.MetricsServiceClient
+}
+
+func (s *otlpSender) send(ctx context.Context, batch [][]byte) (int, error) {
+	size := 0
+	req := &collectorpb.ExportMetricsServiceRequest{}
+	for _, item := range batch {
+		size += len(item)
+		part := &collectorpb.ExportMetricsServiceRequest{}
+		if err := proto.Unmarshal(item, part); err != nil {
+			return 0, err
+		}
+		req.ResourceMetrics = append(req.ResourceMetrics, part.ResourceMetrics...)
+	}
+
+	if s.conn == nil {
+		creds := grpc.WithTransportCredentials(credentials.NewClientTLSFromCert(nil, ""))
+		if s.insecure {
+			creds = grpc.WithInsecure()
+		}
+		conn, err := grpc.DialContext(ctx, s.address, creds)
+		if err != nil {
+			return 0, err
+		}
+		s.conn = conn
+		s.client = collectorpb.NewMetricsServiceClient(conn)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	for name, value := range s.headers {
+		ctx = metadata.AppendToOutgoingContext(ctx, name, value)
+	}
+
+	if _, err := s.client.Export(ctx, req); err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+func (s *otlpSender) close() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+}
@@ -0,0 +1,94 @@
+package exporter
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	collectorpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"go.uber.org/goleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// otlpReceiver - заглушка коллектора OpenTelemetry, запоминающая полученные запросы.
+type otlpReceiver struct {
+	collectorpb.UnimplementedMetricsServiceServer
+
+	requests chan *collectorpb.ExportMetricsServiceRequest
+	headers  chan metadata.MD
+}
+
+func (r *otlpReceiver) Export(ctx context.Context, req *collectorpb.ExportMetricsServiceRequest) (
+	*collectorpb.ExportMetricsServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	r.headers <- md
+	r.requests <- req
+	return &collectorpb.ExportMetricsServiceResponse{}, nil
+}
+
+func TestExporterOTLP(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	lsn, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	receiver := &otlpReceiver{
+		requests: make(chan *collectorpb.ExportMetricsServiceRequest, 1),
+		headers:  make(chan metadata.MD, 1),
+	}
+	srv := grpc.NewServer()
+	collectorpb.RegisterMetricsServiceServer(srv, receiver)
+	go func() {
+		_ = srv.Serve(lsn)
+	}()
+	defer srv.Stop()
+
+	conf := testConf("otlp", "grpc", lsn.Addr().String())
+	conf.M = 5
+	conf.Insecure = true
+	conf.Headers = map[string]string{"api-key": "secret"}
+	conf.Tags = map[string]string{"deployment.environment": "test"}
+	ch, stop := startExporter(t, conf)
+	defer stop()
+
+	ch <- testStats()
+
+	var md metadata.MD
+	select {
+	case md = <-receiver.headers:
+	case <-time.After(time.Second):
+		require.FailNow(t, "no export request")
+	}
+	require.Equal(t, []string{"secret"}, md.Get("api-key"))
+
+	req := <-receiver.requests
+	require.Len(t, req.ResourceMetrics, 1)
+
+	attributes := make(map[string]string)
+	for _, attr := range req.ResourceMetrics[0].Resource.Attributes {
+		attributes[attr.Key] = attr.Value.GetStringValue()
+	}
+	require.Equal(t, "symo", attributes["service.name"])
+	require.Equal(t, "test", attributes["deployment.environment"])
+	require.NotEmpty(t, attributes["host.name"])
+
+	metrics := req.ResourceMetrics[0].InstrumentationLibraryMetrics[0].Metrics
+	require.Len(t, metrics, 7)
+	require.Equal(t, "symo.cpu.utilization", metrics[1].Name)
+	require.Equal(t, "%", metrics[1].Unit)
+
+	points := metrics[1].GetDoubleGauge().DataPoints
+	require.Len(t, points, 3)
+	require.Equal(t, "state", points[0].Labels[0].Key)
+	require.Equal(t, "user", points[0].Labels[0].Value)
+	require.Equal(t, 10.0, points[0].Value)
+	require.Equal(t, uint64(1600000000000000000), points[0].TimeUnixNano)
+	require.Equal(t, uint64(1599999995000000000), points[0].StartTimeUnixNano)
+
+	fs := metrics[5].GetDoubleGauge().DataPoints
+	require.Equal(t, "symo.fs.used_space", metrics[5].Name)
+	require.Equal(t, "/mnt/my,disk", fs[1].Labels[0].Value)
+}
@@ -0,0 +1,68 @@
+package exporter
+
+import (
+	"sync"
+)
+
+// queue - ограниченная очередь неотправленных пакетов. При переполнении отбрасывается самый старый пакет.
+type queue struct {
+	mutex  *sync.Mutex
+	items  [][]byte
+	size   int
+	notify chan struct{} // сигнал, что в очереди появились пакеты
+}
+
+func newQueue(size int) *queue {
+	return &queue{
+		mutex:  &sync.Mutex{},
+		size:   size,
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// push добавляет пакет в очередь. Возвращает false, если ради него был отброшен самый старый пакет.
+func (q *queue) push(item []byte) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	ok := true
+	if len(q.items) >= q.size {
+		q.items = q.items[1:]
+		ok = false
+	}
+	q.items = append(q.items, item)
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return ok
+}
+
+// pushFront возвращает неотправленные пакеты в начало очереди, не будя отправку: они уйдут вместе
+// со следующим пакетом. Возвращает false, если ради них были отброшены самые старые пакеты.
+func (q *queue) pushFront(items [][]byte) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.items = append(items[:len(items):len(items)], q.items...)
+	if extra := len(q.items) - q.size; extra > 0 {
+		q.items = q.items[extra:]
+		return false
+	}
+	return true
+}
+
+// takeAll забирает из очереди все пакеты.
+func (q *queue) takeAll() [][]byte {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	items := q.items
+	q.items = nil
+	return items
+}
+
+func (q *queue) ready() <-chan struct{} {
+	return q.notify
+}
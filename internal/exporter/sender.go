@@ -0,0 +1,158 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+// размер UDP пакета, который гарантированно не фрагментируется в обычной сети.
+const maxUDPPacket = 1400
+
+// sender отправляет пакеты во внешнюю систему. send возвращает, сколько первых байт пакетов доставлено.
+// Доставленная часть кончается на границе строки или пакета, после ошибки заново отправляется только остаток.
+type sender interface {
+	send(ctx context.Context, batch [][]byte) (int, error)
+	close()
+}
+
+func newSender(conf symo.ExporterConf) sender {
+	switch conf.Protocol {
+	case "udp":
+		return &udpSender{address: conf.Address, timeout: conf.Timeout}
+	case "http":
+		return &httpSender{url: conf.Address, client: &http.Client{Timeout: conf.Timeout}}
+	case "grpc":
+		return &otlpSender{address: conf.Address, headers: conf.Headers, insecure: conf.Insecure, timeout: conf.Timeout}
+	default:
+		return &tcpSender{address: conf.Address, timeout: conf.Timeout}
+	}
+}
+
+// tcpSender держит соединение открытым и переподключается после ошибки.
+type tcpSender struct {
+	address string
+	timeout time.Duration
+	conn    net.Conn
+}
+
+func (s *tcpSender) send(ctx context.Context, batch [][]byte) (int, error) {
+	if s.conn == nil {
+		dialer := &net.Dialer{Timeout: s.timeout}
+		conn, err := dialer.DialContext(ctx, "tcp", s.address)
+		if err != nil {
+			return 0, err
+		}
+		s.conn = conn
+	}
+
+	data := bytes.Join(batch, nil)
+	_ = s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+	if n, err := s.conn.Write(data); err != nil {
+		s.close()
+		// строка, оборванная на закрытом соединении, отправляется заново целиком
+		return bytes.LastIndexByte(data[:n], '\n') + 1, err
+	}
+	return len(data), nil
+}
+
+func (s *tcpSender) close() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// udpSender отправляет пакеты дейтаграммами, разбивая их по строкам.
+type udpSender struct {
+	address string
+	timeout time.Duration
+	conn    net.Conn
+}
+
+func (s *udpSender) send(ctx context.Context, batch [][]byte) (int, error) {
+	if s.conn == nil {
+		dialer := &net.Dialer{Timeout: s.timeout}
+		conn, err := dialer.DialContext(ctx, "udp", s.address)
+		if err != nil {
+			return 0, err
+		}
+		s.conn = conn
+	}
+
+	sent := 0
+	for _, item := range batch {
+		for _, packet := range splitLines(item, maxUDPPacket) {
+			_ = s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+			if _, err := s.conn.Write(packet); err != nil {
+				s.close()
+				return sent, err
+			}
+			sent += len(packet)
+		}
+	}
+	return sent, nil
+}
+
+func (s *udpSender) close() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// httpSender отправляет все накопленные пакеты одним POST запросом.
+type httpSender struct {
+	url    string
+	client *http.Client
+}
+
+func (s *httpSender) send(ctx context.Context, batch [][]byte) (int, error) {
+	data := bytes.Join(batch, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return len(data), nil
+}
+
+func (s *httpSender) close() {
+	s.client.CloseIdleConnections()
+}
+
+// splitLines разбивает данные на куски не больше size байт по границам строк.
+// Строка длиннее size отправляется отдельным куском целиком.
+func splitLines(data []byte, size int) [][]byte {
+	var result [][]byte
+	for len(data) > size {
+		cut := bytes.LastIndexByte(data[:size], '\n')
+		if cut < 0 {
+			cut = bytes.IndexByte(data, '\n')
+			if cut < 0 {
+				break
+			}
+		}
+		result = append(result, data[:cut+1])
+		data = data[cut+1:]
+	}
+	if len(data) > 0 {
+		result = append(result, data)
+	}
+	return result
+}
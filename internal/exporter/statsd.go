@@ -0,0 +1,85 @@
+package exporter
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+var dogStatsDTagReplacer = strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_")
+
+// statsDEncoder пишет статистику StatsD гейджами. В формате DogStatsD диски и файловые системы
+// передаются тегами, а в обычном StatsD - узлами имени метрики, как в Graphite.
+// При sampleRate < 1 каждая метрика отправляется с этой вероятностью.
+func statsDEncoder(prefix string, tags map[string]string, dogStatsD bool, sampleRate float64,
+	random func() float64) encoder {
+	var suffix string
+	if sampleRate < 1 {
+		suffix = "|@" + formatFloat(sampleRate)
+	}
+
+	commonTags := make([]string, 0, len(tags))
+	for _, name := range sortedKeys(tags) {
+		commonTags = append(commonTags, dogStatsDTag(name, tags[name]))
+	}
+
+	return func(stats *symo.Stats) []byte {
+		buf := &bytes.Buffer{}
+
+		gauge := func(name string, value float64, tag string) {
+			if sampleRate < 1 && random() >= sampleRate {
+				return
+			}
+			buf.WriteString(prefix + "." + name + ":" + formatFloat(value) + "|g" + suffix)
+
+			lineTags := commonTags
+			if tag != "" {
+				lineTags = append(append([]string(nil), commonTags...), tag)
+			}
+			if dogStatsD && len(lineTags) > 0 {
+				buf.WriteString("|#" + strings.Join(lineTags, ","))
+			}
+			buf.WriteByte('\n')
+		}
+
+		if la := stats.LoadAvg; la != nil {
+			gauge("load_average.load1", la.Load1, "")
+			gauge("load_average.load5", la.Load5, "")
+			gauge("load_average.load15", la.Load15, "")
+		}
+		if cpu := stats.CPU; cpu != nil {
+			gauge("cpu.user", cpu.User, "")
+			gauge("cpu.system", cpu.System, "")
+			gauge("cpu.idle", cpu.Idle, "")
+		}
+		for _, disk := range stats.LoadDisks {
+			name, tag := "disk."+graphiteName(disk.Name), ""
+			if dogStatsD {
+				name, tag = "disk", dogStatsDTag("disk", disk.Name)
+			}
+			gauge(name+".tps", disk.Tps, tag)
+			gauge(name+".kb_read", disk.KBRead, tag)
+			gauge(name+".kb_write", disk.KBWrite, tag)
+		}
+		for _, fs := range stats.UsedFS {
+			name, tag := "fs."+graphitePath(fs.Path), ""
+			if dogStatsD {
+				name, tag = "fs", dogStatsDTag("path", fs.Path)
+			}
+			gauge(name+".used_space", fs.UsedSpace, tag)
+			gauge(name+".used_inode", fs.UsedInode, tag)
+		}
+		return buf.Bytes()
+	}
+}
+
+func dogStatsDTag(name, value string) string {
+	return dogStatsDTagReplacer.Replace(name) + ":" + dogStatsDTagReplacer.Replace(value)
+}
+
+// newRandom возвращает источник случайных чисел для семплирования метрик.
+func newRandom() func() float64 {
+	return rand.Float64 //nolint:gosec
+}
@@ -0,0 +1,70 @@
+package exporter
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func TestStatsD(t *testing.T) {
+	encode := statsDEncoder("symo", map[string]string{"host": "web1"}, false, 1, nil)
+
+	result := string(encode(testStats()))
+	require.True(t, strings.HasPrefix(result, "symo.load_average.load1:0.5|g\n"))
+	require.Contains(t, result, "symo.disk.sda.kb_write:9|g\n")
+	require.Contains(t, result, "symo.fs.mnt_my_disk.used_space:50|g\n")
+	require.Len(t, strings.Split(strings.TrimSpace(result), "\n"), 13)
+}
+
+func TestDogStatsD(t *testing.T) {
+	encode := statsDEncoder("symo", map[string]string{"host": "web1", "env": "prod"}, true, 1, nil)
+
+	result := string(encode(testStats()))
+	require.True(t, strings.HasPrefix(result, "symo.load_average.load1:0.5|g|#env:prod,host:web1\n"))
+	require.Contains(t, result, "symo.disk.tps:7|g|#env:prod,host:web1,disk:sda\n")
+	require.Contains(t, result, "symo.fs.used_space:50|g|#env:prod,host:web1,path:/mnt/my_disk\n")
+}
+
+func TestStatsDSampling(t *testing.T) {
+	// каждая вторая метрика проходит семплирование
+	var calls int
+	random := func() float64 {
+		calls++
+		if calls%2 == 0 {
+			return 0.9
+		}
+		return 0.1
+	}
+	encode := statsDEncoder("symo", nil, false, 0.5, random)
+
+	result := strings.Split(strings.TrimSpace(string(encode(testStats()))), "\n")
+	require.Equal(t, 13, calls)
+	require.Len(t, result, 7)
+	require.Equal(t, "symo.load_average.load1:0.5|g|@0.5", result[0])
+	require.Equal(t, "symo.load_average.load15:1.5|g|@0.5", result[1])
+}
+
+func TestExporterStatsD(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	conf := testConf("dogstatsd", "udp", conn.LocalAddr().String())
+	conf.SampleRate = 1
+	ch, stop := startExporter(t, conf)
+	defer stop()
+
+	ch <- testStats()
+
+	buf := make([]byte, maxUDPPacket)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	require.Contains(t, string(buf[:n]), "symo.cpu.idle:84.75|g\n")
+}
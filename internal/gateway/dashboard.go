@@ -0,0 +1,224 @@
+package gateway
+
+import (
+	"net/http"
+)
+
+func getDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(dashboardHTML))
+}
+
+// страница с графиками. Получает статистику через /ws и рисует ее на canvas без внешних зависимостей.
+const dashboardHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>symo</title>
+<style>
+  body { font-family: sans-serif; margin: 16px; background: #fafafa; color: #222; }
+  h1 { font-size: 20px; margin: 0 0 12px; }
+  form { margin-bottom: 12px; }
+  input { width: 60px; }
+  #status { margin-left: 12px; color: #666; }
+  .grid { display: grid; grid-template-columns: repeat(auto-fill, minmax(460px, 1fr)); gap: 12px; }
+  .card { background: #fff; border: 1px solid #ddd; border-radius: 4px; padding: 8px; }
+  .card h2 { font-size: 15px; margin: 0 0 6px; }
+  .state { font-size: 12px; padding: 1px 6px; border-radius: 3px; margin-left: 6px; }
+  .ok { background: #d4f4d4; } .stale { background: #f4ecc4; }
+  .error { background: #f4cccc; } .disabled, .unknown { background: #ddd; }
+  .legend { font-size: 12px; }
+  .legend span { margin-right: 10px; }
+  canvas { width: 100%; height: 180px; }
+</style>
+</head>
+<body>
+<h1>System monitor</h1>
+<form id="params">
+  every N <input id="n" type="number" min="1" value="1">
+  for M <input id="m" type="number" min="1" value="5">
+  <button type="submit">connect</button>
+  <span id="status">disconnected</span>
+</form>
+<div class="grid">
+  <div class="card"><h2>Load average<span id="loadAvgState" class="state"></span></h2>
+    <canvas id="loadAvg"></canvas><div id="loadAvgLegend" class="legend"></div></div>
+  <div class="card"><h2>CPU, %<span id="cpuState" class="state"></span></h2>
+    <canvas id="cpu"></canvas><div id="cpuLegend" class="legend"></div></div>
+  <div class="card"><h2>Disks, tps<span id="loadDisksState" class="state"></span></h2>
+    <canvas id="loadDisks"></canvas><div id="loadDisksLegend" class="legend"></div></div>
+  <div class="card"><h2>File systems, used space %<span id="usedFsState" class="state"></span></h2>
+    <canvas id="usedFs"></canvas><div id="usedFsLegend" class="legend"></div></div>
+</div>
+<script>
+"use strict";
+
+var maxPoints = 120;
+var colors = ["#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd", "#8c564b", "#e377c2", "#7f7f7f"];
+var charts = {};
+var socket = null;
+
+function chart(id) {
+  if (!charts[id]) {
+    charts[id] = {series: {}, order: []};
+  }
+  return charts[id];
+}
+
+function push(id, name, value) {
+  var c = chart(id);
+  if (!c.series[name]) {
+    c.series[name] = [];
+    c.order.push(name);
+  }
+  var values = c.series[name];
+  values.push(value);
+  if (values.length > maxPoints) {
+    values.shift();
+  }
+}
+
+function draw(id) {
+  var c = chart(id);
+  var canvas = document.getElementById(id);
+  var width = canvas.width = canvas.clientWidth;
+  var height = canvas.height = canvas.clientHeight;
+  var ctx = canvas.getContext("2d");
+  ctx.clearRect(0, 0, width, height);
+
+  var max = 0;
+  c.order.forEach(function (name) {
+    c.series[name].forEach(function (v) { if (v !== null && v > max) { max = v; } });
+  });
+  if (max === 0) {
+    max = 1;
+  }
+
+  ctx.fillStyle = "#999";
+  ctx.font = "11px sans-serif";
+  ctx.fillText(max.toFixed(2), 2, 10);
+
+  var legend = [];
+  c.order.forEach(function (name, i) {
+    var color = colors[i % colors.length];
+    var values = c.series[name];
+    var step = width / (maxPoints - 1);
+    var offset = maxPoints - values.length;
+    ctx.strokeStyle = color;
+    ctx.lineWidth = 1.5;
+    ctx.beginPath();
+    var drawing = false;
+    values.forEach(function (v, j) {
+      if (v === null) {
+        drawing = false;
+        return;
+      }
+      var x = (offset + j) * step;
+      var y = height - 2 - (height - 14) * v / max;
+      if (drawing) { ctx.lineTo(x, y); } else { ctx.moveTo(x, y); drawing = true; }
+    });
+    ctx.stroke();
+    var last = values[values.length - 1];
+    legend.push('<span style="color:' + color + '">' + escape(name) + ": " +
+      (last === null ? "-" : last.toFixed(2)) + "</span>");
+  });
+  document.getElementById(id + "Legend").innerHTML = legend.join("");
+}
+
+function escape(text) {
+  return String(text).replace(/[&<>"]/g, function (ch) {
+    return {"&": "&amp;", "<": "&lt;", ">": "&gt;", '"': "&quot;"}[ch];
+  });
+}
+
+function showState(id, state) {
+  var el = document.getElementById(id + "State");
+  el.className = "state " + state.status;
+  el.textContent = state.status;
+  el.title = state.message || "";
+}
+
+// метрики, которых нет в пакете, продолжают ряд пропуском
+function pushAll(id, list, key, field) {
+  var seen = {};
+  (list || []).forEach(function (item) {
+    push(id, item[key], item[field]);
+    seen[item[key]] = true;
+  });
+  chart(id).order.forEach(function (name) {
+    if (!seen[name]) { push(id, name, null); }
+  });
+}
+
+function onStats(stats) {
+  var la = stats.loadAvg || {};
+  push("loadAvg", "1m", la.load1 === undefined ? null : la.load1);
+  push("loadAvg", "5m", la.load5 === undefined ? null : la.load5);
+  push("loadAvg", "15m", la.load15 === undefined ? null : la.load15);
+
+  var cpu = stats.cpu || {};
+  push("cpu", "user", cpu.user === undefined ? null : cpu.user);
+  push("cpu", "system", cpu.system === undefined ? null : cpu.system);
+  push("cpu", "idle", cpu.idle === undefined ? null : cpu.idle);
+
+  pushAll("loadDisks", stats.loadDisks, "name", "tps");
+  pushAll("usedFs", stats.usedFs, "path", "usedSpace");
+
+  showState("loadAvg", stats.state.loadAvg);
+  showState("cpu", stats.state.cpu);
+  showState("loadDisks", stats.state.loadDisks);
+  showState("usedFs", stats.state.usedFs);
+
+  ["loadAvg", "cpu", "loadDisks", "usedFs"].forEach(draw);
+
+  var status = "updated " + new Date(stats.time).toLocaleTimeString();
+  if (stats.dropped > 0) {
+    status += ", dropped " + stats.dropped;
+  }
+  setStatus(status);
+}
+
+function setStatus(text) {
+  document.getElementById("status").textContent = text;
+}
+
+function connect() {
+  if (socket) {
+    socket.onclose = null;
+    socket.close();
+  }
+  charts = {};
+
+  var n = document.getElementById("n").value;
+  var m = document.getElementById("m").value;
+  var proto = location.protocol === "https:" ? "wss:" : "ws:";
+  socket = new WebSocket(proto + "//" + location.host + "/ws?n=" + encodeURIComponent(n) + "&m=" + encodeURIComponent(m));
+  setStatus("waiting for " + m + " seconds of data...");
+
+  socket.onmessage = function (e) {
+    var msg = JSON.parse(e.data);
+    if (msg.event === "stats") {
+      onStats(msg.data);
+    } else if (msg.event === "error") {
+      setStatus("error: " + msg.data.error);
+    }
+  };
+  socket.onclose = function () {
+    setStatus("disconnected");
+  };
+}
+
+document.getElementById("params").addEventListener("submit", function (e) {
+  e.preventDefault();
+  connect();
+});
+connect();
+</script>
+</body>
+</html>
+`
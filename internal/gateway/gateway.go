@@ -0,0 +1,65 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+type httpServer struct {
+	mutex  *sync.Mutex
+	srv    *http.Server
+	app    *symo.AppSettings // время хранения метрик, меняется при перезагрузке конфига
+	config symo.Config
+	log    symo.Logger
+}
+
+// NewServer возвращает HTTP сервер, отдающий статистику в JSON.
+func NewServer(log symo.Logger, config symo.Config) symo.HTTPServer {
+	return &httpServer{
+		mutex:  &sync.Mutex{},
+		app:    symo.NewAppSettings(config.App),
+		config: config,
+		log:    log,
+	}
+}
+
+func (h *httpServer) Start(addr string, clients symo.NewClienter) error {
+	h.mutex.Lock()
+	h.srv = &http.Server{
+		Addr:    addr,
+		Handler: newHandler(h.log, h.config, h.app, clients),
+	}
+	srv := h.srv
+	h.mutex.Unlock()
+
+	h.log.Debug("starting http server on ", addr)
+	err := srv.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Reload применяет новое время хранения метрик. Адрес сервера меняется только перезапуском.
+func (h *httpServer) Reload(_ context.Context, config symo.Config) {
+	h.app.Set(config.App)
+}
+
+func (h *httpServer) Stop(ctx context.Context) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.srv == nil {
+		return
+	}
+	// потоки статистики завершаются, когда сервис клиентов закрывает их каналы
+	if err := h.srv.Shutdown(ctx); err != nil {
+		_ = h.srv.Close()
+	}
+
+	h.log.Debug("http server is stopped")
+}
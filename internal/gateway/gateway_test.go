@@ -0,0 +1,245 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/anfilat/final-stats/internal/mocks"
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+func TestHTTPStartStop(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	config, _ := symo.NewConfig("")
+
+	log := new(mocks.Logger)
+	log.On("Debug", "http server is stopped")
+	log.On("Debug", "starting http server on ", mock.Anything)
+
+	server := NewServer(log, config)
+	go func() {
+		err := server.Start(":"+config.HTTP.Port, new(mocks.NewClienter))
+		require.NoError(t, err)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+
+	server.Stop(context.Background())
+
+	log.AssertExpectations(t)
+}
+
+func TestHTTPStats(t *testing.T) {
+	handler, clientsService := newTestHandler()
+
+	ch := make(chan *symo.Stats, 3)
+	del := func() {}
+	clientData := symo.ClientData{
+		N:         5 * time.Second,
+		M:         15 * time.Second,
+		Aggs:      symo.NewAggregations(symo.AggMax),
+		Policy:    symo.PolicyDisconnect,
+		DropLimit: 3,
+		Peer:      "192.0.2.1",
+	}
+	clientsService.On("NewClient", clientData).Return((<-chan *symo.Stats)(ch), del, nil)
+
+	first := someStats()
+	first.Seq = 1
+	first.Aggregated = []symo.AggregatedData{{
+		Aggregation: symo.AggMax,
+		CPU:         &symo.CPUData{User: 90},
+	}}
+	second := someStats()
+	second.Seq = 3
+	second.Dropped = 1
+	ch <- first
+	ch <- second
+	ch <- &symo.Stats{Seq: 4, Err: symo.ErrOverflow}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet,
+		"/stats?n=5&m=15&agg=max&policy=disconnect&droplimit=3", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+
+	events := strings.Split(strings.TrimSpace(rec.Body.String()), "\n\n")
+	require.Len(t, events, 3)
+
+	stats := parseEvent(t, events[0], "1", "stats")
+	require.Equal(t, uint64(0), stats.Dropped)
+	require.Equal(t, "sda", stats.LoadDisks[0].Name)
+	require.Equal(t, "error", stats.State.CPU.Status)
+	require.Equal(t, "cpu error", stats.State.CPU.Message)
+	require.Equal(t, "max", stats.Aggregated[0].Aggregation)
+	require.Equal(t, 90.0, stats.Aggregated[0].CPU.User)
+
+	stats = parseEvent(t, events[1], "3", "stats")
+	require.Equal(t, uint64(1), stats.Dropped)
+
+	require.Equal(t, "id: 4\nevent: error\ndata: {\"error\":\"client does not keep up with the stats stream\"}", events[2])
+
+	clientsService.AssertExpectations(t)
+}
+
+func TestHTTPSnapshot(t *testing.T) {
+	handler, clientsService := newTestHandler()
+
+	ch := make(chan *symo.Stats, 1)
+	del := func() {}
+	clientData := symo.ClientData{M: 15 * time.Second, Once: true, Peer: "192.0.2.1"}
+	clientsService.On("NewClient", clientData).Return((<-chan *symo.Stats)(ch), del, nil)
+
+	ch <- someStats()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/snapshot?m=15", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var stats statsJSON
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+	require.Equal(t, 1.0, stats.LoadAvg.Load1)
+	require.Equal(t, "/", stats.UsedFS[0].Path)
+	require.Equal(t, "disabled", stats.State.UsedFS.Status)
+
+	clientsService.AssertExpectations(t)
+}
+
+func TestHTTPFails(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		code    int
+		message string
+	}{
+		{
+			name:    "no n",
+			url:     "/stats?m=1",
+			code:    http.StatusBadRequest,
+			message: "N must be a number",
+		},
+		{
+			name:    "n = 0",
+			url:     "/stats?n=0&m=1",
+			code:    http.StatusBadRequest,
+			message: "N must be greater than 0 seconds",
+		},
+		{
+			name:    "m > MaxSeconds",
+			url:     "/stats?n=1&m=100000",
+			code:    http.StatusBadRequest,
+			message: "M must be less than 600 seconds",
+		},
+		{
+			name:    "unknown policy",
+			url:     "/stats?n=1&m=1&policy=never",
+			code:    http.StatusBadRequest,
+			message: `unknown drop policy "never"`,
+		},
+		{
+			name:    "negative drop limit",
+			url:     "/stats?n=1&m=1&droplimit=-1",
+			code:    http.StatusBadRequest,
+			message: "drop limit must not be negative",
+		},
+		{
+			name:    "unknown aggregation",
+			url:     "/snapshot?m=1&agg=max,avg",
+			code:    http.StatusBadRequest,
+			message: `unknown aggregation "avg"`,
+		},
+		{
+			name:    "stopped service",
+			url:     "/snapshot?m=1",
+			code:    http.StatusServiceUnavailable,
+			message: "service is closing",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			handler, clientsService := newTestHandler()
+			clientsService.On("NewClient", mock.Anything).Return(nil, nil, symo.ErrStopped)
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.url, nil))
+
+			require.Equal(t, tt.code, rec.Code)
+			require.Equal(t, tt.message, strings.TrimSpace(rec.Body.String()))
+		})
+	}
+}
+
+func newTestHandler() (http.Handler, *mocks.NewClienter) {
+	log := new(mocks.Logger)
+	log.On("Debug", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	log.On("Debug", mock.Anything, mock.Anything)
+
+	config, _ := symo.NewConfig("")
+	clientsService := new(mocks.NewClienter)
+
+	return newHandler(log, config, symo.NewAppSettings(config.App), clientsService), clientsService
+}
+
+func parseEvent(t *testing.T, event, id, name string) *statsJSON {
+	lines := strings.Split(event, "\n")
+	require.Len(t, lines, 3)
+	require.Equal(t, "id: "+id, lines[0])
+	require.Equal(t, "event: "+name, lines[1])
+	require.True(t, strings.HasPrefix(lines[2], "data: "))
+
+	var stats statsJSON
+	require.NoError(t, json.Unmarshal([]byte(strings.TrimPrefix(lines[2], "data: ")), &stats))
+	return &stats
+}
+
+func someStats() *symo.Stats {
+	return &symo.Stats{
+		Time: time.Now().Truncate(time.Second),
+		LoadAvg: &symo.LoadAvgData{
+			Load1:  1,
+			Load5:  1,
+			Load15: 1,
+		},
+		CPU: &symo.CPUData{
+			User:   1,
+			System: 1,
+			Idle:   1,
+		},
+		LoadDisks: symo.LoadDisksData{
+			{
+				Name:    "sda",
+				Tps:     7,
+				KBRead:  8,
+				KBWrite: 9,
+			},
+		},
+		UsedFS: symo.UsedFSData{
+			{
+				Path:      "/",
+				UsedSpace: 12.3,
+				UsedInode: 7.77,
+			},
+		},
+		State: symo.MetricsState{
+			LoadAvg:   symo.MetricState{Status: symo.StatusOK},
+			CPU:       symo.MetricState{Status: symo.StatusError, Message: "cpu error"},
+			LoadDisks: symo.MetricState{Status: symo.StatusStale},
+			UsedFS:    symo.MetricState{Status: symo.StatusDisabled},
+		},
+	}
+}
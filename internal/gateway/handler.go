@@ -0,0 +1,231 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+type handler struct {
+	clients symo.NewClienter
+	app     *symo.AppSettings
+	log     symo.Logger
+}
+
+func newHandler(log symo.Logger, config symo.Config, app *symo.AppSettings, clients symo.NewClienter) http.Handler {
+	h := &handler{
+		clients: clients,
+		app:     app,
+		log:     log,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stats", h.getStats)
+	mux.HandleFunc("/snapshot", h.getSnapshot)
+	mux.HandleFunc("/ws", h.getWebSocket)
+	if config.HTTP.Dashboard {
+		mux.HandleFunc("/", getDashboard)
+	}
+	return mux
+}
+
+// getStats отдает статистику каждые N секунд в виде Server-Sent Events.
+func (h *handler) getStats(w http.ResponseWriter, r *http.Request) {
+	clientData, err := parseStatsQuery(r.URL.Query())
+	if err == nil {
+		app := h.app.Get()
+		err = clientData.Validate(app.MaxWindow(), app.Tick)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	h.log.Debug("new http client. Every ", clientData.N, " for ", clientData.M)
+
+	clientData.Peer = peerHost(r)
+	ch, del, err := h.clients.NewClient(clientData)
+	if err != nil {
+		newClientError(w, err)
+		return
+	}
+	defer del()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	send := func(event string, id uint64, data interface{}) error {
+		if err := writeEvent(w, event, id, data); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+	h.stream(r.Context().Done(), ch, send)
+}
+
+// eventSender отправляет клиенту событие с номером пакета.
+type eventSender func(event string, id uint64, data interface{}) error
+
+// stream пересылает клиенту пакеты статистики, пока клиент не отключится или канал не будет закрыт.
+func (h *handler) stream(done <-chan struct{}, ch <-chan *symo.Stats, send eventSender) {
+	for {
+		select {
+		case <-done:
+			h.log.Debug("http client disconnected")
+			return
+		case data, ok := <-ch:
+			if !ok {
+				return
+			}
+			if errors.Is(data.Err, symo.ErrOverflow) {
+				_ = send("error", data.Seq, errorJSON{Error: "client does not keep up with the stats stream"})
+				return
+			}
+
+			if err := send("stats", data.Seq, dataToJSON(data)); err != nil {
+				h.log.Debug(fmt.Errorf("unable to send message: %w", err))
+				return
+			}
+		}
+	}
+}
+
+// getSnapshot отдает статистику, усредненную за последние M секунд.
+func (h *handler) getSnapshot(w http.ResponseWriter, r *http.Request) {
+	clientData, err := parseSnapshotQuery(r.URL.Query())
+	if err == nil {
+		app := h.app.Get()
+		err = clientData.Validate(app.MaxWindow(), app.Tick)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.log.Debug("http snapshot for ", clientData.M)
+
+	clientData.Peer = peerHost(r)
+	ch, del, err := h.clients.NewClient(clientData)
+	if err != nil {
+		newClientError(w, err)
+		return
+	}
+	defer del()
+
+	select {
+	case <-r.Context().Done():
+		return
+	case data, ok := <-ch:
+		if !ok || data.Err != nil {
+			http.Error(w, "service is closing", http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(dataToJSON(data)); err != nil {
+			h.log.Debug(fmt.Errorf("unable to send message: %w", err))
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event string, id uint64, data interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, body)
+	return err
+}
+
+func parseStatsQuery(query url.Values) (symo.ClientData, error) {
+	clientData, err := parseSnapshotQuery(query)
+	if err != nil {
+		return clientData, err
+	}
+	clientData.Once = false
+
+	if clientData.N, err = parseSeconds(query, "n", "N"); err != nil {
+		return clientData, err
+	}
+	if value := query.Get("policy"); value != "" {
+		if clientData.Policy, err = symo.ParseDropPolicy(value); err != nil {
+			return clientData, err
+		}
+	}
+	if query.Get("droplimit") != "" {
+		if clientData.DropLimit, err = parseInt(query, "droplimit", "drop limit"); err != nil {
+			return clientData, err
+		}
+	}
+	return clientData, nil
+}
+
+func parseSnapshotQuery(query url.Values) (symo.ClientData, error) {
+	clientData := symo.ClientData{Once: true}
+
+	var err error
+	if clientData.M, err = parseSeconds(query, "m", "M"); err != nil {
+		return clientData, err
+	}
+	if value := query.Get("agg"); value != "" {
+		var list []symo.Aggregation
+		for _, name := range strings.Split(value, ",") {
+			agg, err := symo.ParseAggregation(strings.TrimSpace(name))
+			if err != nil {
+				return clientData, err
+			}
+			list = append(list, agg)
+		}
+		clientData.Aggs = symo.NewAggregations(list...)
+	}
+	return clientData, nil
+}
+
+func parseInt(query url.Values, key, name string) (int, error) {
+	value, err := strconv.Atoi(query.Get(key))
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number", name)
+	}
+	return value, nil
+}
+
+func parseSeconds(query url.Values, key, name string) (time.Duration, error) {
+	value, err := parseInt(query, key, name)
+	return time.Duration(value) * time.Second, err
+}
+
+// peerHost возвращает адрес клиента без порта для ограничения подключений.
+func peerHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// newClientError отвечает на отказ сервиса клиентов в подключении.
+func newClientError(w http.ResponseWriter, err error) {
+	if errors.Is(err, symo.ErrLimit) {
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	http.Error(w, "service is closing", http.StatusServiceUnavailable)
+}
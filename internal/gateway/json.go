@@ -0,0 +1,158 @@
+package gateway
+
+import (
+	"time"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+type statsJSON struct {
+	Seq        uint64           `json:"seq"`
+	Dropped    uint64           `json:"dropped"`
+	Time       time.Time        `json:"time"`
+	LoadAvg    *loadAvgJSON     `json:"loadAvg,omitempty"`
+	CPU        *cpuJSON         `json:"cpu,omitempty"`
+	LoadDisks  []loadDiskJSON   `json:"loadDisks,omitempty"`
+	UsedFS     []usedFSJSON     `json:"usedFs,omitempty"`
+	State      stateJSON        `json:"state"`
+	Aggregated []aggregatedJSON `json:"aggregated,omitempty"`
+}
+
+type aggregatedJSON struct {
+	Aggregation string         `json:"aggregation"`
+	LoadAvg     *loadAvgJSON   `json:"loadAvg,omitempty"`
+	CPU         *cpuJSON       `json:"cpu,omitempty"`
+	LoadDisks   []loadDiskJSON `json:"loadDisks,omitempty"`
+	UsedFS      []usedFSJSON   `json:"usedFs,omitempty"`
+}
+
+type loadAvgJSON struct {
+	Load1  float64 `json:"load1"`
+	Load5  float64 `json:"load5"`
+	Load15 float64 `json:"load15"`
+}
+
+type cpuJSON struct {
+	User   float64 `json:"user"`
+	System float64 `json:"system"`
+	Idle   float64 `json:"idle"`
+}
+
+type loadDiskJSON struct {
+	Name    string  `json:"name"`
+	Tps     float64 `json:"tps"`
+	KBRead  float64 `json:"kbRead"`
+	KBWrite float64 `json:"kbWrite"`
+}
+
+type usedFSJSON struct {
+	Path      string  `json:"path"`
+	UsedSpace float64 `json:"usedSpace"`
+	UsedInode float64 `json:"usedInode"`
+}
+
+type stateJSON struct {
+	LoadAvg   metricStateJSON `json:"loadAvg"`
+	CPU       metricStateJSON `json:"cpu"`
+	LoadDisks metricStateJSON `json:"loadDisks"`
+	UsedFS    metricStateJSON `json:"usedFs"`
+}
+
+type metricStateJSON struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+type errorJSON struct {
+	Error string `json:"error"`
+}
+
+func dataToJSON(data *symo.Stats) *statsJSON {
+	result := &statsJSON{
+		Seq:       data.Seq,
+		Dropped:   data.Dropped,
+		Time:      data.Time,
+		LoadAvg:   loadAvgToJSON(data.LoadAvg),
+		CPU:       cpuToJSON(data.CPU),
+		LoadDisks: loadDisksToJSON(data.LoadDisks),
+		UsedFS:    usedFSToJSON(data.UsedFS),
+		State: stateJSON{
+			LoadAvg:   metricStateToJSON(data.State.LoadAvg),
+			CPU:       metricStateToJSON(data.State.CPU),
+			LoadDisks: metricStateToJSON(data.State.LoadDisks),
+			UsedFS:    metricStateToJSON(data.State.UsedFS),
+		},
+	}
+
+	for _, aggData := range data.Aggregated {
+		result.Aggregated = append(result.Aggregated, aggregatedJSON{
+			Aggregation: aggData.Aggregation.String(),
+			LoadAvg:     loadAvgToJSON(aggData.LoadAvg),
+			CPU:         cpuToJSON(aggData.CPU),
+			LoadDisks:   loadDisksToJSON(aggData.LoadDisks),
+			UsedFS:      usedFSToJSON(aggData.UsedFS),
+		})
+	}
+
+	return result
+}
+
+func loadAvgToJSON(data *symo.LoadAvgData) *loadAvgJSON {
+	if data == nil {
+		return nil
+	}
+	return &loadAvgJSON{
+		Load1:  data.Load1,
+		Load5:  data.Load5,
+		Load15: data.Load15,
+	}
+}
+
+func cpuToJSON(data *symo.CPUData) *cpuJSON {
+	if data == nil {
+		return nil
+	}
+	return &cpuJSON{
+		User:   data.User,
+		System: data.System,
+		Idle:   data.Idle,
+	}
+}
+
+func loadDisksToJSON(data symo.LoadDisksData) []loadDiskJSON {
+	if data == nil {
+		return nil
+	}
+	result := make([]loadDiskJSON, 0, len(data))
+	for _, diskData := range data {
+		result = append(result, loadDiskJSON{
+			Name:    diskData.Name,
+			Tps:     diskData.Tps,
+			KBRead:  diskData.KBRead,
+			KBWrite: diskData.KBWrite,
+		})
+	}
+	return result
+}
+
+func usedFSToJSON(data symo.UsedFSData) []usedFSJSON {
+	if data == nil {
+		return nil
+	}
+	result := make([]usedFSJSON, 0, len(data))
+	for _, fsData := range data {
+		result = append(result, usedFSJSON{
+			Path:      fsData.Path,
+			UsedSpace: fsData.UsedSpace,
+			UsedInode: fsData.UsedInode,
+		})
+	}
+	return result
+}
+
+func metricStateToJSON(state symo.MetricState) metricStateJSON {
+	return metricStateJSON{
+		Status:  state.Status.String(),
+		Message: state.Message,
+	}
+}
@@ -0,0 +1,81 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+// wsMessage - сообщение, отправляемое клиенту через WebSocket.
+type wsMessage struct {
+	Event string      `json:"event"`
+	ID    uint64      `json:"id,omitempty"`
+	Data  interface{} `json:"data"`
+}
+
+// getWebSocket отдает статистику каждые N секунд через WebSocket. Параметры те же, что и у /stats.
+func (h *handler) getWebSocket(w http.ResponseWriter, r *http.Request) {
+	clientData, err := parseStatsQuery(r.URL.Query())
+	if err == nil {
+		app := h.app.Get()
+		err = clientData.Validate(app.MaxWindow(), app.Tick)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	clientData.Peer = peerHost(r)
+	websocket.Server{
+		Handshake: checkOrigin,
+		Handler: func(ws *websocket.Conn) {
+			h.serveWebSocket(ws, clientData)
+		},
+	}.ServeHTTP(w, r)
+}
+
+// checkOrigin пускает только страницы с того же хоста, чтобы чужой сайт не читал статистику из браузера
+// пользователя. Клиенты не из браузера заголовок Origin не присылают.
+func checkOrigin(config *websocket.Config, r *http.Request) error {
+	origin, err := websocket.Origin(config, r)
+	if err != nil {
+		return err
+	}
+	if origin != nil && origin.Host != r.Host {
+		return fmt.Errorf("origin %s is not allowed", origin)
+	}
+	config.Origin = origin
+	return nil
+}
+
+func (h *handler) serveWebSocket(ws *websocket.Conn, clientData symo.ClientData) {
+	h.log.Debug("new websocket client. Every ", clientData.N, " for ", clientData.M)
+
+	ch, del, err := h.clients.NewClient(clientData)
+	if err != nil {
+		message := "service is closing"
+		if errors.Is(err, symo.ErrLimit) {
+			message = err.Error()
+		}
+		_ = websocket.JSON.Send(ws, wsMessage{Event: "error", Data: errorJSON{Error: message}})
+		return
+	}
+	defer del()
+
+	// клиент ничего не присылает, чтение нужно, чтобы заметить его отключение
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var msg []byte
+		for websocket.Message.Receive(ws, &msg) == nil {
+		}
+	}()
+
+	h.stream(done, ch, func(event string, id uint64, data interface{}) error {
+		return websocket.JSON.Send(ws, wsMessage{Event: event, ID: id, Data: data})
+	})
+}
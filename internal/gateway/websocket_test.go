@@ -0,0 +1,107 @@
+package gateway
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+func TestWebSocket(t *testing.T) {
+	handler, clientsService := newTestHandler()
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	ch := make(chan *symo.Stats, 2)
+	del := func() {}
+	clientsService.On("NewClient", symo.ClientData{N: 2 * time.Second, M: 10 * time.Second, Peer: "127.0.0.1"}).Return((<-chan *symo.Stats)(ch), del, nil)
+
+	stats := someStats()
+	stats.Seq = 2
+	stats.Dropped = 1
+	ch <- stats
+	ch <- &symo.Stats{Seq: 3, Err: symo.ErrOverflow}
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws?n=2&m=10"
+	ws, err := websocket.Dial(wsURL, "", srv.URL)
+	require.NoError(t, err)
+	defer ws.Close()
+
+	var msg struct {
+		Event string    `json:"event"`
+		ID    uint64    `json:"id"`
+		Data  statsJSON `json:"data"`
+	}
+	require.NoError(t, websocket.JSON.Receive(ws, &msg))
+	require.Equal(t, "stats", msg.Event)
+	require.Equal(t, uint64(2), msg.ID)
+	require.Equal(t, uint64(1), msg.Data.Dropped)
+	require.Equal(t, "sda", msg.Data.LoadDisks[0].Name)
+
+	var errMsg struct {
+		Event string    `json:"event"`
+		Data  errorJSON `json:"data"`
+	}
+	require.NoError(t, websocket.JSON.Receive(ws, &errMsg))
+	require.Equal(t, "error", errMsg.Event)
+	require.Equal(t, "client does not keep up with the stats stream", errMsg.Data.Error)
+
+	clientsService.AssertExpectations(t)
+}
+
+func TestWebSocketOrigin(t *testing.T) {
+	handler, clientsService := newTestHandler()
+	srv := httptest.NewServer(handler)
+	defer srv.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws?n=2&m=10"
+	_, err := websocket.Dial(wsURL, "", "http://evil.example")
+	require.Error(t, err)
+	clientsService.AssertNotCalled(t, "NewClient", mock.Anything)
+
+	// без Origin подключаются клиенты не из браузера
+	r := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	require.NoError(t, checkOrigin(&websocket.Config{Version: websocket.ProtocolVersionHybi13}, r))
+	r.Header.Set("Origin", "http://"+r.Host)
+	require.NoError(t, checkOrigin(&websocket.Config{Version: websocket.ProtocolVersionHybi13}, r))
+	r.Header.Set("Origin", "http://evil.example")
+	require.Error(t, checkOrigin(&websocket.Config{Version: websocket.ProtocolVersionHybi13}, r))
+}
+
+func TestWebSocketBadRequest(t *testing.T) {
+	handler, _ := newTestHandler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ws?n=1&m=0", nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Equal(t, "M must be greater than 0 seconds", strings.TrimSpace(rec.Body.String()))
+}
+
+func TestDashboard(t *testing.T) {
+	handler, _ := newTestHandler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	require.Contains(t, rec.Body.String(), "new WebSocket(")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	config, _ := symo.NewConfig("")
+	config.HTTP.Dashboard = false
+	handler = newHandler(nil, config, symo.NewAppSettings(config.App), nil)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
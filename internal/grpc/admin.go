@@ -0,0 +1,91 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/anfilat/final-stats/internal/dump"
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+// размер части дампа в одном сообщении, чтобы не упираться в ограничение gRPC на размер сообщения.
+const dumpChunkSize = 64 * 1024
+
+type adminService struct {
+	UnimplementedAdminServer
+
+	points   symo.PointsReader
+	reloader symo.ConfigReloader // nil, если конфиг нельзя перечитать
+	clock    clock.Clock
+	app      *symo.AppSettings
+	log      symo.Logger
+}
+
+func newAdminService(log symo.Logger, app *symo.AppSettings, clock clock.Clock, points symo.PointsReader,
+	reloader symo.ConfigReloader) *adminService {
+	return &adminService{
+		points:   points,
+		reloader: reloader,
+		clock:    clock,
+		app:      app,
+		log:      log,
+	}
+}
+
+// ExportPoints отдает посекундные точки за последние seconds секунд в формате JSON или CSV.
+func (a *adminService) ExportPoints(req *ExportRequest, srv Admin_ExportPointsServer) error {
+	a.log.Debug("export for ", req.Seconds)
+
+	maxSeconds := a.app.Get().MaxSeconds
+	seconds := int(req.Seconds)
+	if seconds == 0 {
+		seconds = maxSeconds
+	}
+	if seconds < 1 || seconds > maxSeconds {
+		return status.Error(codes.InvalidArgument,
+			fmt.Sprintf("seconds must be between 1 and %v", maxSeconds))
+	}
+	format, ok := dumpFormats[req.Format]
+	if !ok {
+		return status.Error(codes.InvalidArgument, fmt.Sprintf("unknown dump format %d", req.Format))
+	}
+
+	// как и для клиентов, отдаются завершенные секунды до текущей
+	now := a.clock.Now().Truncate(time.Second)
+	buf := &bytes.Buffer{}
+	if err := dump.Write(buf, format, a.points.Points(now, seconds)); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	for buf.Len() > 0 {
+		if err := srv.Send(&DumpChunk{Data: buf.Next(dumpChunkSize)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var dumpFormats = map[DumpFormat]string{
+	DumpFormat_DUMP_JSON: "json",
+	DumpFormat_DUMP_CSV:  "csv",
+}
+
+// ReloadConfig перечитывает файл конфига, как по сигналу SIGHUP.
+func (a *adminService) ReloadConfig(ctx context.Context, _ *ReloadRequest) (*ReloadResponse, error) {
+	a.log.Debug("config reload is requested")
+
+	if a.reloader == nil {
+		return nil, status.Error(codes.Unimplemented, "config reload is not available")
+	}
+	restart, err := a.reloader.ReloadConfig(ctx)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return &ReloadResponse{RestartRequired: restart}, nil
+}
@@ -0,0 +1,143 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/anfilat/final-stats/internal/dump"
+	"github.com/anfilat/final-stats/internal/mocks"
+	"github.com/anfilat/final-stats/internal/store"
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+func TestAdminExport(t *testing.T) {
+	start := time.Unix(1_600_000_000, 0)
+	points := store.NewStore(symo.MaxSeconds)
+	for i := 0; i < 5; i++ {
+		points.Append(start.Add(time.Duration(i)*time.Second), symo.Point{
+			CPU: &symo.CPUData{User: float64(i), System: 1, Idle: 99 - float64(i)},
+		})
+	}
+
+	srv, listener := startAdminServer(points, start.Add(5*time.Second+500*time.Millisecond), nil)
+	defer stopGRPCServer(srv, listener)
+
+	conn := getConnect(t, listener)
+	defer conn.Close()
+
+	client := NewAdminClient(conn)
+
+	data := export(t, client, &ExportRequest{Seconds: 3, Format: DumpFormat_DUMP_CSV})
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 4)
+	require.True(t, strings.HasPrefix(lines[1], "2020-09-13T12:26:42Z,,,,2,1,97,"))
+
+	data = export(t, client, &ExportRequest{Format: DumpFormat_DUMP_JSON})
+	source, err := dump.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	count, err := dump.Seed(store.NewStore(symo.MaxSeconds), source, start.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 5, count)
+}
+
+func TestAdminExportBadRequest(t *testing.T) {
+	srv, listener := startAdminServer(store.NewStore(symo.MaxSeconds), time.Now(), nil)
+	defer stopGRPCServer(srv, listener)
+
+	conn := getConnect(t, listener)
+	defer conn.Close()
+
+	client := NewAdminClient(conn)
+
+	tests := []*ExportRequest{
+		{Seconds: -1},
+		{Seconds: symo.MaxSeconds + 1},
+		{Seconds: 10, Format: 10},
+	}
+	for _, req := range tests {
+		reqClient, err := client.ExportPoints(context.Background(), req)
+		require.NoError(t, err)
+		_, err = reqClient.Recv()
+		require.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+}
+
+func TestAdminReloadConfig(t *testing.T) {
+	reloader := new(mocks.ConfigReloader)
+	reloader.On("ReloadConfig", mock.Anything).Return([]string{"server"}, nil).Once()
+	reloader.On("ReloadConfig", mock.Anything).Return(nil, errors.New("failed to read configuration")).Once()
+
+	srv, listener := startAdminServer(store.NewStore(symo.MaxSeconds), time.Now(), reloader)
+	defer stopGRPCServer(srv, listener)
+
+	conn := getConnect(t, listener)
+	defer conn.Close()
+	client := NewAdminClient(conn)
+
+	resp, err := client.ReloadConfig(context.Background(), &ReloadRequest{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"server"}, resp.RestartRequired)
+
+	_, err = client.ReloadConfig(context.Background(), &ReloadRequest{})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	reloader.AssertExpectations(t)
+
+	// сервер, запущенный без перезагрузки конфига
+	srv, listener = startAdminServer(store.NewStore(symo.MaxSeconds), time.Now(), nil)
+	defer stopGRPCServer(srv, listener)
+
+	conn = getConnect(t, listener)
+	defer conn.Close()
+	_, err = NewAdminClient(conn).ReloadConfig(context.Background(), &ReloadRequest{})
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+}
+func export(t *testing.T, client AdminClient, req *ExportRequest) []byte {
+	reqClient, err := client.ExportPoints(context.Background(), req)
+	require.NoError(t, err)
+
+	var data []byte
+	for {
+		chunk, err := reqClient.Recv()
+		if errors.Is(err, io.EOF) {
+			return data
+		}
+		require.NoError(t, err)
+		data = append(data, chunk.Data...)
+	}
+}
+
+func startAdminServer(points symo.PointsReader, now time.Time, reloader symo.ConfigReloader) (*grpc.Server,
+	*bufconn.Listener) {
+	listener := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer()
+
+	log := new(mocks.Logger)
+	log.On("Debug", "export for ", mock.Anything)
+	log.On("Debug", "config reload is requested")
+
+	config, _ := symo.NewConfig("")
+
+	clk := clock.NewMock()
+	clk.Set(now)
+
+	RegisterAdminServer(srv, newAdminService(log, symo.NewAppSettings(config.App), clk, points, reloader))
+
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+
+	return srv, listener
+}
@@ -0,0 +1,290 @@
+package grpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"net"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+// auth проверяет клиентов по списку доступа из конфига: опознает их по bearer токену или CN сертификата,
+// проверяет доступ к методу, ограничивает число потоков и скрывает недоступные метрики в ответах.
+type auth struct {
+	mutex      *sync.Mutex
+	identities []symo.IdentityConf
+	streams    map[string]int // число открытых потоков по имени клиента
+	log        symo.Logger
+}
+
+func newAuth(log symo.Logger, config symo.AuthConf) *auth {
+	return &auth{
+		mutex:      &sync.Mutex{},
+		identities: config.Identities,
+		streams:    make(map[string]int),
+		log:        log,
+	}
+}
+
+func (a *auth) unaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if isPublicMethod(info.FullMethod) {
+		return handler(ctx, req)
+	}
+
+	identity, err := a.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := handler(ctx, req)
+	if stats, ok := resp.(*Stats); ok {
+		hideMetrics(stats, identity.Metrics)
+	}
+	return resp, err
+}
+
+func (a *auth) streamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	if isPublicMethod(info.FullMethod) {
+		return handler(srv, ss)
+	}
+
+	identity, err := a.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+
+	if !a.openStream(identity) {
+		a.log.Debug("too many streams for ", identity.Name)
+		return status.Errorf(codes.ResourceExhausted, "identity %s has too many open streams", identity.Name)
+	}
+	defer a.closeStream(identity)
+
+	return handler(srv, &authStream{ServerStream: ss, metrics: identity.Metrics})
+}
+
+// isPublicMethod сообщает, что метод доступен без опознания. Проверки здоровья делают пробы Kubernetes,
+// у которых нет токена.
+func isPublicMethod(method string) bool {
+	return strings.HasPrefix(method, "/"+healthpb.Health_ServiceDesc.ServiceName+"/")
+}
+
+// authorize опознает клиента и проверяет, что ему доступен метод.
+func (a *auth) authorize(ctx context.Context, method string) (symo.IdentityConf, error) {
+	identity, ok := a.identify(ctx)
+	if !ok {
+		a.log.Debug("unauthenticated request to ", method)
+		return identity, status.Error(codes.Unauthenticated, "unknown token or client certificate")
+	}
+	if strings.HasPrefix(method, "/"+_Admin_serviceDesc.ServiceName+"/") && !identity.Admin {
+		a.log.Debug("permission denied for ", identity.Name, " to ", method)
+		return identity, status.Errorf(codes.PermissionDenied, "identity %s has no access to %s", identity.Name, method)
+	}
+	if method == selfStatsMethod && !allowsMetric(identity.Metrics, symo.SelfMetric) {
+		a.log.Debug("permission denied for ", identity.Name, " to ", method)
+		return identity, status.Errorf(codes.PermissionDenied, "identity %s has no access to %s", identity.Name, method)
+	}
+	return identity, nil
+}
+
+// метрики самого сервера доступны клиенту, в списке метрик которого есть группа symo.
+var selfStatsMethod = "/" + _Symo_serviceDesc.ServiceName + "/GetSelfStats"
+
+// allowsMetric сообщает, что метрика есть в allowed. Пустой allowed разрешает все метрики.
+func allowsMetric(allowed []string, metric string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, name := range allowed {
+		if name == metric {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *auth) identify(ctx context.Context) (symo.IdentityConf, bool) {
+	p, _ := peer.FromContext(ctx)
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("authorization")) > 0 {
+		if !tokenAllowed(p) {
+			a.log.Debug("token without tls is rejected from ", p.Addr)
+			return symo.IdentityConf{}, false
+		}
+		for _, value := range md.Get("authorization") {
+			token := strings.TrimPrefix(value, "Bearer ")
+			if token == value {
+				continue
+			}
+			if identity, ok := a.byToken(token); ok {
+				return identity, true
+			}
+		}
+	}
+
+	// сертификат уже проверен TLS, поэтому достаточно CN первого сертификата цепочки
+	if p != nil {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok && len(info.State.VerifiedChains) > 0 {
+			commonName := info.State.VerifiedChains[0][0].Subject.CommonName
+			for _, identity := range a.identities {
+				if identity.CommonName != "" && identity.CommonName == commonName {
+					return identity, true
+				}
+			}
+		}
+	}
+
+	return symo.IdentityConf{}, false
+}
+
+// tokenAllowed сообщает, что токен можно принять: соединение защищено TLS или клиент не удаленный -
+// подключен к unix сокету или по loopback, и токен не передавался по сети открытым текстом.
+func tokenAllowed(p *peer.Peer) bool {
+	if p == nil {
+		return false
+	}
+	if _, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+		return true
+	}
+	addr, ok := p.Addr.(*net.TCPAddr)
+	return !ok || addr.IP.IsLoopback()
+}
+
+func (a *auth) byToken(token string) (symo.IdentityConf, bool) {
+	for _, identity := range a.identities {
+		for _, known := range identity.Tokens {
+			if subtle.ConstantTimeCompare([]byte(known), []byte(token)) == 1 {
+				return identity, true
+			}
+		}
+	}
+	return symo.IdentityConf{}, false
+}
+
+func (a *auth) openStream(identity symo.IdentityConf) bool {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if identity.MaxStreams > 0 && a.streams[identity.Name] >= identity.MaxStreams {
+		return false
+	}
+	a.streams[identity.Name]++
+	return true
+}
+
+func (a *auth) closeStream(identity symo.IdentityConf) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.streams[identity.Name]--
+	if a.streams[identity.Name] == 0 {
+		delete(a.streams, identity.Name)
+	}
+}
+
+// authStream скрывает недоступные клиенту метрики в отправляемых пакетах.
+type authStream struct {
+	grpc.ServerStream
+	metrics []string
+}
+
+func (s *authStream) SendMsg(m interface{}) error {
+	if stats, ok := m.(*Stats); ok {
+		hideMetrics(stats, s.metrics)
+	}
+	return s.ServerStream.SendMsg(m)
+}
+
+// hideMetrics убирает из пакета метрики, которых нет в allowed. Пустой allowed разрешает все метрики.
+// Состояние скрытой метрики - disabled, чтобы клиент отличал запрет от отсутствия данных.
+func hideMetrics(stats *Stats, allowed []string) {
+	if len(allowed) == 0 {
+		return
+	}
+	permitted := make(map[string]bool, len(allowed))
+	for _, metric := range allowed {
+		permitted[metric] = true
+	}
+	denied := &MetricState{Status: Status_STATUS_DISABLED, Message: "access denied"}
+
+	if !permitted["loadavg"] {
+		stats.LoadAvg, stats.LoadAvgState = nil, denied
+	}
+	if !permitted["cpu"] {
+		stats.Cpu, stats.CpuState = nil, denied
+	}
+	if !permitted["loaddisks"] {
+		stats.LoadDisks, stats.LoadDisksState = nil, denied
+	}
+	if !permitted["usedfs"] {
+		stats.UsedFs, stats.UsedFsState = nil, denied
+	}
+	for _, agg := range stats.Aggregated {
+		if !permitted["loadavg"] {
+			agg.LoadAvg = nil
+		}
+		if !permitted["cpu"] {
+			agg.Cpu = nil
+		}
+		if !permitted["loaddisks"] {
+			agg.LoadDisks = nil
+		}
+		if !permitted["usedfs"] {
+			agg.UsedFs = nil
+		}
+	}
+}
+
+// tokenCredentials передает bearer токен с каждым запросом клиента.
+type tokenCredentials struct {
+	token string
+	local bool // сервер на этой машине, токен допускается и без TLS
+}
+
+func (t tokenCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + t.token}, nil
+}
+
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return !t.local
+}
+
+// WithToken возвращает параметр подключения, передающий токен из списка доступа сервера.
+// К серверу target, кроме unix сокета и loopback, токен передается только по TLS.
+func WithToken(token, target string) grpc.DialOption {
+	return grpc.WithPerRPCCredentials(tokenCredentials{token: token, local: isLocalTarget(target)})
+}
+
+// isLocalTarget сообщает, что адрес сервера - unix сокет или loopback.
+func isLocalTarget(target string) bool {
+	if strings.HasPrefix(target, "unix:") {
+		return true
+	}
+	host, _, err := net.SplitHostPort(strings.TrimPrefix(target, "tcp://"))
+	if err != nil {
+		return false
+	}
+	if host == "" || host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
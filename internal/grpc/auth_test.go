@@ -0,0 +1,245 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/anfilat/final-stats/internal/mocks"
+	"github.com/anfilat/final-stats/internal/store"
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+var testIdentities = []symo.IdentityConf{
+	{Name: "ops", Tokens: []string{"old", "ops"}, Admin: true},
+	{Name: "dashboard", Tokens: []string{"dash"}, Metrics: []string{"cpu"}, MaxStreams: 1},
+	{Name: "agent", CommonName: "agent"},
+}
+
+func TestAuthUnauthenticated(t *testing.T) {
+	srv, listener, _ := startAuthServer(nil)
+	defer stopGRPCServer(srv, listener)
+
+	for _, token := range []string{"", "wrong"} {
+		conn := getAuthConnect(t, listener, token)
+		_, err := NewSymoClient(conn).GetSnapshot(context.Background(), &SnapshotRequest{M: 1})
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+		reqClient, err := NewSymoClient(conn).GetStats(context.Background(), &StatsRequest{N: 1, M: 1})
+		require.NoError(t, err)
+		_, err = reqClient.Recv()
+		require.Equal(t, codes.Unauthenticated, status.Code(err))
+		conn.Close()
+	}
+}
+
+func TestAuthAdmin(t *testing.T) {
+	srv, listener, _ := startAuthServer(nil)
+	defer stopGRPCServer(srv, listener)
+
+	conn := getAuthConnect(t, listener, "dash")
+	defer conn.Close()
+	err := exportOnce(NewAdminClient(conn))
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// любой из токенов клиента
+	conn = getAuthConnect(t, listener, "old")
+	defer conn.Close()
+	require.NoError(t, exportOnce(NewAdminClient(conn)))
+}
+
+func TestAuthHideMetrics(t *testing.T) {
+	srv, listener, clientsService := startAuthServer(nil)
+	defer stopGRPCServer(srv, listener)
+
+	ch := make(chan *symo.Stats, 2)
+	ch <- someStats()
+	ch <- someStats()
+	clientsService.On("NewClient", mock.Anything).Return((<-chan *symo.Stats)(ch), func() {}, nil)
+
+	conn := getAuthConnect(t, listener, "dash")
+	defer conn.Close()
+	client := NewSymoClient(conn)
+
+	stats, err := client.GetSnapshot(context.Background(), &SnapshotRequest{M: 1})
+	require.NoError(t, err)
+	require.NotNil(t, stats.Cpu)
+	require.Nil(t, stats.LoadAvg)
+	require.Nil(t, stats.LoadDisks)
+	require.Nil(t, stats.UsedFs)
+	require.Equal(t, Status_STATUS_DISABLED, stats.UsedFsState.Status)
+
+	reqClient, err := client.GetStats(context.Background(), &StatsRequest{N: 1, M: 1})
+	require.NoError(t, err)
+	stats, err = reqClient.Recv()
+	require.NoError(t, err)
+	require.NotNil(t, stats.Cpu)
+	require.Nil(t, stats.LoadAvg)
+	require.Equal(t, Status_STATUS_DISABLED, stats.LoadAvgState.Status)
+}
+
+func TestAuthMaxStreams(t *testing.T) {
+	srv, listener, clientsService := startAuthServer(nil)
+	defer stopGRPCServer(srv, listener)
+
+	ch := make(chan *symo.Stats, 1)
+	ch <- someStats()
+	clientsService.On("NewClient", mock.Anything).Return((<-chan *symo.Stats)(ch), func() {}, nil)
+
+	conn := getAuthConnect(t, listener, "dash")
+	defer conn.Close()
+	client := NewSymoClient(conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first, err := client.GetStats(ctx, &StatsRequest{N: 1, M: 1})
+	require.NoError(t, err)
+	_, err = first.Recv()
+	require.NoError(t, err)
+
+	second, err := client.GetStats(context.Background(), &StatsRequest{N: 1, M: 1})
+	require.NoError(t, err)
+	_, err = second.Recv()
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	// после закрытия первого потока можно открыть новый
+	cancel()
+	require.Eventually(t, func() bool {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		third, err := client.GetStats(ctx, &StatsRequest{N: 1, M: 1})
+		require.NoError(t, err)
+		_, err = third.Recv()
+		return status.Code(err) != codes.ResourceExhausted
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestAuthCommonName(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "ca")
+	conf := symo.TLSConf{
+		Cert:     filepath.Join(dir, "server.crt"),
+		Key:      filepath.Join(dir, "server.key"),
+		ClientCA: filepath.Join(dir, "ca.crt"),
+	}
+	ca.issue(t, "localhost", conf.Cert, conf.Key)
+	ca.writeCert(t, conf.ClientCA)
+
+	reloader, err := newCertReloader(new(mocks.Logger), conf)
+	require.NoError(t, err)
+	srv, listener, _ := startAuthServer(grpc.Creds(credentials.NewTLS(reloader.serverConfig())))
+	defer stopGRPCServer(srv, listener)
+
+	for _, name := range []string{"agent", "stranger"} {
+		clientTLS := ClientTLS{
+			CA:         conf.ClientCA,
+			Cert:       filepath.Join(dir, name+".crt"),
+			Key:        filepath.Join(dir, name+".key"),
+			ServerName: "localhost",
+		}
+		ca.issue(t, name, clientTLS.Cert, clientTLS.Key)
+
+		err := exportOverTLS(listener, clientTLS)
+		if name == "agent" {
+			// опознан, но не администратор
+			require.Equal(t, codes.PermissionDenied, status.Code(err))
+		} else {
+			require.Equal(t, codes.Unauthenticated, status.Code(err))
+		}
+	}
+}
+
+func TestAuthTokenWithoutTLS(t *testing.T) {
+	log := new(mocks.Logger)
+	log.On("Debug", "token without tls is rejected from ", mock.Anything).Once()
+	auth := newAuth(log, symo.AuthConf{Enabled: true, Identities: testIdentities})
+
+	md := metadata.Pairs("authorization", "Bearer ops")
+	for _, tc := range []struct {
+		addr net.Addr
+		ok   bool
+	}{
+		{addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5000}, ok: true},
+		{addr: &net.UnixAddr{Name: "@1", Net: "unix"}, ok: true},
+		{addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5000}, ok: false},
+	} {
+		ctx := peer.NewContext(metadata.NewIncomingContext(context.Background(), md), &peer.Peer{Addr: tc.addr})
+		_, ok := auth.identify(ctx)
+		require.Equal(t, tc.ok, ok, tc.addr.String())
+	}
+	log.AssertExpectations(t)
+
+	// клиент не отправляет токен удаленному серверу без TLS
+	_, err := grpc.Dial("symo.example.com:8000", grpc.WithInsecure(), WithToken("ops", "symo.example.com:8000"))
+	require.Error(t, err)
+	for _, target := range []string{":8000", "localhost:8000", "tcp://127.0.0.1:8000", "[::1]:8000", "unix:///run/symo.sock"} {
+		require.True(t, isLocalTarget(target), target)
+	}
+}
+
+func startAuthServer(opt grpc.ServerOption) (*grpc.Server, *bufconn.Listener, *mocks.NewClienter) {
+	listener := bufconn.Listen(1024 * 1024)
+
+	log := new(mocks.Logger)
+	log.On("With", mock.Anything, mock.Anything).Return(log).Maybe()
+	log.On("Debug", mock.Anything, mock.Anything).Maybe()
+	log.On("Debug", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Maybe()
+	log.On("Debug", mock.Anything).Maybe()
+
+	config, _ := symo.NewConfig("")
+	config.Auth = symo.AuthConf{Enabled: true, Identities: testIdentities}
+	auth := newAuth(log, config.Auth)
+
+	opts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(auth.unaryInterceptor),
+		grpc.StreamInterceptor(auth.streamInterceptor),
+	}
+	if opt != nil {
+		opts = append(opts, opt)
+	}
+	srv := grpc.NewServer(opts...)
+
+	clientsService := new(mocks.NewClienter)
+	RegisterSymoServer(srv, newService(log, symo.NewAppSettings(config.App), clientsService, testSelfStater()))
+	RegisterAdminServer(srv, newAdminService(log, symo.NewAppSettings(config.App), clock.NewMock(), store.NewStore(symo.MaxSeconds), nil))
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+
+	return srv, listener, clientsService
+}
+
+func getAuthConnect(t *testing.T, listener *bufconn.Listener, token string) *grpc.ClientConn {
+	opts := []grpc.DialOption{grpc.WithInsecure(), grpc.WithContextDialer(dialer(listener))}
+	if token != "" {
+		opts = append(opts, WithToken(token, "localhost:8000"))
+	}
+	conn, err := grpc.DialContext(context.Background(), "localhost:8000", opts...)
+	require.NoError(t, err)
+	return conn
+}
+
+func exportOnce(client AdminClient) error {
+	reqClient, err := client.ExportPoints(context.Background(), &ExportRequest{Seconds: 1})
+	if err != nil {
+		return err
+	}
+	_, err = reqClient.Recv()
+	return err
+}
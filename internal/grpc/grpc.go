@@ -4,10 +4,19 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
 	"sync"
 
+	"github.com/benbjohnson/clock"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 
 	"github.com/anfilat/final-stats/internal/symo"
 )
@@ -15,48 +24,226 @@ import (
 type grpcServer struct {
 	mutex  *sync.Mutex
 	srv    *grpc.Server
+	health *healthChecker
+	app    *symo.AppSettings // общие настройки сервисов, меняются при перезагрузке конфига
 	config symo.Config
 	log    symo.Logger
+	clock  clock.Clock
 }
 
 // NewServer возвращает gRPC сервер.
-func NewServer(log symo.Logger, config symo.Config) symo.GRPCServer {
+func NewServer(log symo.Logger, config symo.Config, clock clock.Clock) symo.GRPCServer {
 	return &grpcServer{
 		mutex:  &sync.Mutex{},
+		app:    symo.NewAppSettings(config.App),
 		config: config,
 		log:    log,
+		clock:  clock,
 	}
 }
 
-func (g *grpcServer) Start(addr string, clients symo.NewClienter) error {
-	lsn, err := net.Listen("tcp", addr)
+func (g *grpcServer) Start(addrs []symo.ListenAddr, clients symo.NewClienter, points symo.PointsReader,
+	self symo.SelfStater, reloader symo.ConfigReloader) error {
+	listeners, err := g.listen(addrs)
 	if err != nil {
 		return err
 	}
 
+	var opts []grpc.ServerOption
+	if g.config.Server.TLS.Enabled() {
+		reloader, err := newCertReloader(g.log, g.config.Server.TLS)
+		if err != nil {
+			closeListeners(listeners)
+			return fmt.Errorf("failed to load tls certificates: %w", err)
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(reloader.serverConfig())))
+	}
+	if g.config.Auth.Enabled {
+		auth := newAuth(g.log, g.config.Auth)
+		opts = append(opts,
+			grpc.UnaryInterceptor(auth.unaryInterceptor),
+			grpc.StreamInterceptor(auth.streamInterceptor))
+	}
+
+	// набор коллекторов для проверки здоровья может измениться при перезагрузке конфига
 	g.mutex.Lock()
-	g.srv = grpc.NewServer()
+	srv := grpc.NewServer(opts...)
+	health := newHealthChecker(g.log, g.config.Metric, g.clock, points)
+
+	RegisterSymoServer(srv, newService(g.log, g.app, clients, self))
+	RegisterAdminServer(srv, newAdminService(g.log, g.app, g.clock, points, reloader))
+	healthpb.RegisterHealthServer(srv, health.server)
+	if g.config.Server.Reflection {
+		reflection.Register(srv)
+	}
+
+	g.srv, g.health = srv, health
+	health.start()
 	g.mutex.Unlock()
 
-	RegisterSymoServer(g.srv, newService(g.log, g.config, clients))
+	// сервер работает, пока открыт хотя бы один адрес. GracefulStop закрывает их все
+	errs := make(chan error, len(listeners))
+	for i, lsn := range listeners {
+		g.log.Debug("starting grpc server on ", addrs[i].String())
+		go func(lsn net.Listener) {
+			errs <- srv.Serve(lsn)
+		}(lsn)
+	}
+	for range listeners {
+		if err := <-errs; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listen открывает все адреса сервера. Если какой-то не открывается, уже открытые закрываются.
+func (g *grpcServer) listen(addrs []symo.ListenAddr) ([]net.Listener, error) {
+	var listeners []net.Listener
+	for _, addr := range addrs {
+		lsn, err := g.listenAddr(addr)
+		if err != nil {
+			closeListeners(listeners)
+			return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+		}
+		listeners = append(listeners, lsn)
+	}
+	return listeners, nil
+}
+
+func (g *grpcServer) listenAddr(addr symo.ListenAddr) (net.Listener, error) {
+	if addr.Network != "unix" {
+		return net.Listen(addr.Network, addr.Address)
+	}
+
+	mode, err := g.config.Server.FileMode()
+	if err != nil {
+		return nil, err
+	}
+	if err := removeStaleSocket(addr.Address); err != nil {
+		return nil, err
+	}
+	return listenUnix(addr.Address, mode)
+}
+
+// listenUnix создает сокет во временном каталоге с правами 0700, выставляет ему права mode и переносит
+// на место, поэтому к сокету нельзя подключиться, пока у него права по umask процесса.
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	dir, err := ioutil.TempDir(filepath.Dir(path), ".symo")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	tmp := filepath.Join(dir, "sock")
+	lsn, err := net.Listen("unix", tmp)
+	if err != nil {
+		return nil, err
+	}
+	// файл сокета переносится, и при закрытии его удаляет unixListener
+	lsn.(*net.UnixListener).SetUnlinkOnClose(false)
+
+	err = os.Chmod(tmp, mode)
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		_ = lsn.Close()
+		return nil, err
+	}
+	return &unixListener{Listener: lsn, path: path, once: &sync.Once{}}, nil
+}
 
-	g.log.Debug("starting grpc server on ", addr)
-	return g.srv.Serve(lsn)
+// unixListener дает каждому подключению к unix сокету свой адрес @номер. У клиентов unix сокета адреса нет,
+// и без этого все они считались бы одним клиентом в ограничениях подключений.
+type unixListener struct {
+	net.Listener
+	path string // файл сокета, удаляется при закрытии
+	once *sync.Once
+	seq  uint64
+}
+
+// Close закрывает сокет и один раз удаляет его файл, чтобы не удалить сокет, созданный позже на том же пути.
+func (l *unixListener) Close() error {
+	err := l.Listener.Close()
+	l.once.Do(func() {
+		_ = os.Remove(l.path)
+	})
+	return err
+}
+
+func (l *unixListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	l.seq++
+	return &unixConn{Conn: conn, addr: &net.UnixAddr{Name: fmt.Sprintf("@%d", l.seq), Net: "unix"}}, nil
+}
+
+type unixConn struct {
+	net.Conn
+	addr net.Addr
+}
+
+func (c *unixConn) RemoteAddr() net.Addr {
+	return c.addr
+}
+
+// removeStaleSocket удаляет файл сокета, оставшийся после аварийного завершения.
+// Сокет, который кто-то слушает, и файлы других типов не трогаются.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if err != nil || info.Mode()&os.ModeSocket == 0 {
+		return nil
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		_ = conn.Close()
+		return errors.New("socket is in use")
+	}
+	return os.Remove(path)
+}
+
+func closeListeners(listeners []net.Listener) {
+	for _, lsn := range listeners {
+		_ = lsn.Close()
+	}
+}
+
+// Reload применяет новое время хранения метрик и набор коллекторов, проверяемых сервисом здоровья.
+// Адреса, TLS и список доступа меняются только перезапуском.
+func (g *grpcServer) Reload(_ context.Context, config symo.Config) {
+	g.app.Set(config.App)
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.config.Metric = config.Metric
+	if g.health != nil {
+		g.health.setConfig(config.Metric)
+	}
 }
 
 func (g *grpcServer) Stop(ctx context.Context) {
+	g.mutex.Lock()
+	srv, health := g.srv, g.health
+	g.mutex.Unlock()
+
+	// сервер не запустился
+	if srv == nil {
+		return
+	}
+	health.stop()
+
 	stopped := make(chan interface{})
 	go func() {
-		g.mutex.Lock()
-		defer g.mutex.Unlock()
-
-		g.srv.GracefulStop()
+		srv.GracefulStop()
 		close(stopped)
 	}()
 
 	select {
 	case <-ctx.Done():
-		g.srv.Stop()
+		srv.Stop()
 	case <-stopped:
 	}
 
@@ -2,14 +2,23 @@ package grpc
 
 import (
 	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/benbjohnson/clock"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
+	"google.golang.org/grpc"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
 
 	"github.com/anfilat/final-stats/internal/mocks"
+	"github.com/anfilat/final-stats/internal/store"
 	"github.com/anfilat/final-stats/internal/symo"
 )
 
@@ -24,9 +33,9 @@ func TestGRPCStartStop(t *testing.T) {
 
 	clientsService := new(mocks.NewClienter)
 
-	grpcServer := NewServer(log, config)
+	grpcServer := NewServer(log, config, clock.NewMock())
 	go func() {
-		err := grpcServer.Start(":"+config.Server.Port, clientsService)
+		err := grpcServer.Start(config.Server.ListenAddrs(), clientsService, store.NewStore(config.App.MaxSeconds), nil, nil)
 		require.NoError(t, err)
 	}()
 
@@ -37,3 +46,187 @@ func TestGRPCStartStop(t *testing.T) {
 
 	log.AssertExpectations(t)
 }
+
+func TestGRPCBadTLS(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	config, _ := symo.NewConfig("")
+	config.Server.TLS = symo.TLSConf{Cert: "missing.crt", Key: "missing.key"}
+
+	log := new(mocks.Logger)
+
+	grpcServer := NewServer(log, config, clock.NewMock())
+	err := grpcServer.Start(config.Server.ListenAddrs(), new(mocks.NewClienter), store.NewStore(config.App.MaxSeconds), nil, nil)
+	require.Error(t, err)
+
+	grpcServer.Stop(context.Background())
+	log.AssertExpectations(t)
+}
+
+func TestGRPCReflection(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	config, _ := symo.NewConfig("")
+	config.Server.Reflection = true
+
+	log := new(mocks.Logger)
+	log.On("Debug", "grpc server is stopped")
+	log.On("Debug", "starting grpc server on ", mock.Anything)
+
+	grpcServer := NewServer(log, config, clock.NewMock())
+	go func() {
+		err := grpcServer.Start(config.Server.ListenAddrs(), new(mocks.NewClienter), store.NewStore(config.App.MaxSeconds), nil, nil)
+		require.NoError(t, err)
+	}()
+	defer grpcServer.Stop(context.Background())
+
+	require.Eventually(t, func() bool {
+		probe, err := net.Dial("tcp", ":"+config.Server.Port)
+		if err != nil {
+			return false
+		}
+		_ = probe.Close()
+		return true
+	}, time.Second, 10*time.Millisecond)
+
+	conn, err := grpc.Dial(":"+config.Server.Port, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	require.NoError(t, err)
+	err = stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	})
+	require.NoError(t, err)
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.NoError(t, stream.CloseSend())
+
+	var services []string
+	for _, service := range resp.GetListServicesResponse().Service {
+		services = append(services, service.Name)
+	}
+	require.Contains(t, services, "stats.Symo")
+	require.Contains(t, services, "stats.Admin")
+	require.Contains(t, services, "grpc.health.v1.Health")
+}
+
+func TestGRPCUnixSocket(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	socket := filepath.Join(t.TempDir(), "symo.sock")
+	config, _ := symo.NewConfig("")
+	config.Server.Listen = []string{"unix://" + socket, "tcp://127.0.0.1:" + config.Server.Port}
+
+	log := new(mocks.Logger)
+	log.On("Debug", "grpc server is stopped")
+	log.On("Debug", "starting grpc server on ", "unix://"+socket).Once()
+	log.On("Debug", "starting grpc server on ", "tcp://127.0.0.1:"+config.Server.Port).Once()
+	log.On("Debug", "export for ", int32(1))
+
+	addrs := config.Server.ListenAddrs()
+	grpcServer := NewServer(log, config, clock.NewMock())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err := grpcServer.Start(addrs, new(mocks.NewClienter), store.NewStore(config.App.MaxSeconds), nil, nil)
+		require.NoError(t, err)
+	}()
+
+	for _, addr := range addrs {
+		require.Eventually(t, func() bool {
+			probe, err := net.Dial(addr.Network, addr.Address)
+			if err != nil {
+				return false
+			}
+			_ = probe.Close()
+			return true
+		}, time.Second, 10*time.Millisecond)
+	}
+
+	info, err := os.Stat(socket)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o660), info.Mode().Perm())
+	// временный каталог, в котором создавался сокет, удален
+	files, err := ioutil.ReadDir(filepath.Dir(socket))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	conn, err := grpc.Dial("unix://"+socket, grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+	err = exportOnce(NewAdminClient(conn))
+	require.NoError(t, err)
+
+	grpcServer.Stop(context.Background())
+	<-done
+
+	_, err = os.Stat(socket)
+	require.True(t, os.IsNotExist(err))
+	log.AssertExpectations(t)
+}
+
+func TestUnixListenerPeers(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "symo.sock")
+	lsn, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	listener := &unixListener{Listener: lsn, path: socket, once: &sync.Once{}}
+	defer listener.Close()
+
+	// у каждого подключения свой адрес, чтобы ограничения считали их разными клиентами
+	for _, want := range []string{"@1", "@2"} {
+		client, err := net.Dial("unix", socket)
+		require.NoError(t, err)
+		conn, err := listener.Accept()
+		require.NoError(t, err)
+		require.Equal(t, want, conn.RemoteAddr().String())
+		require.NoError(t, conn.Close())
+		require.NoError(t, client.Close())
+	}
+}
+
+func TestGRPCStaleSocket(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	// файл сокета остался от аварийно завершенного процесса
+	socket := filepath.Join(t.TempDir(), "symo.sock")
+	lsn, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	lsn.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, lsn.Close())
+
+	config, _ := symo.NewConfig("")
+	config.Server.Listen = []string{"unix://" + socket}
+
+	log := new(mocks.Logger)
+	log.On("Debug", "grpc server is stopped")
+	log.On("Debug", "starting grpc server on ", mock.Anything)
+
+	grpcServer := NewServer(log, config, clock.NewMock())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		err := grpcServer.Start(config.Server.ListenAddrs(), new(mocks.NewClienter), store.NewStore(config.App.MaxSeconds), nil, nil)
+		require.NoError(t, err)
+	}()
+
+	require.Eventually(t, func() bool {
+		probe, err := net.Dial("unix", socket)
+		if err != nil {
+			return false
+		}
+		_ = probe.Close()
+		return true
+	}, time.Second, 10*time.Millisecond)
+
+	// второй сервер не должен удалить сокет работающего
+	second := NewServer(new(mocks.Logger), config, clock.NewMock())
+	err = second.Start(config.Server.ListenAddrs(), new(mocks.NewClienter), store.NewStore(config.App.MaxSeconds), nil, nil)
+	require.Error(t, err)
+	second.Stop(context.Background())
+
+	grpcServer.Stop(context.Background())
+	<-done
+	log.AssertExpectations(t)
+}
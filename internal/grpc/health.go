@@ -0,0 +1,124 @@
+package grpc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+// за сколько последних секунд проверяется, что сервис сбора метрик работает и коллекторы отвечают.
+const healthSeconds = 10
+
+// collectorService возвращает имя сервиса в протоколе проверки здоровья для коллектора метрики.
+func collectorService(metric string) string {
+	return "collector." + metric
+}
+
+// healthChecker раз в секунду обновляет состояния сервиса grpc.health.v1.Health.
+// Сервер ("" и stats.Symo) здоров, если за последние healthSeconds секунд есть точки и каждый включенный коллектор
+// хотя бы раз вернул данные. Состояние каждого коллектора доступно отдельно как collector.<метрика>.
+type healthChecker struct {
+	mutex  *sync.Mutex
+	server *health.Server
+	points symo.PointsReader
+	clock  clock.Clock
+	config symo.MetricConf
+	done   chan struct{}
+	wg     sync.WaitGroup
+	log    symo.Logger
+}
+
+func newHealthChecker(log symo.Logger, config symo.MetricConf, clock clock.Clock, points symo.PointsReader) *healthChecker {
+	return &healthChecker{
+		mutex:  &sync.Mutex{},
+		server: health.NewServer(),
+		points: points,
+		clock:  clock,
+		config: config,
+		done:   make(chan struct{}),
+		log:    log,
+	}
+}
+
+func (h *healthChecker) start() {
+	h.update()
+
+	ticker := h.clock.Ticker(time.Second)
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-h.done:
+				return
+			case <-ticker.C:
+				h.update()
+			}
+		}
+	}()
+}
+
+// stop переводит все сервисы в NOT_SERVING, чтобы балансировщики перестали слать запросы до остановки сервера.
+func (h *healthChecker) stop() {
+	close(h.done)
+	h.wg.Wait()
+	h.server.Shutdown()
+}
+
+// setConfig меняет набор проверяемых коллекторов. Выключенный коллектор получает состояние SERVICE_UNKNOWN.
+func (h *healthChecker) setConfig(config symo.MetricConf) {
+	h.mutex.Lock()
+	h.config = config
+	h.mutex.Unlock()
+
+	h.update()
+}
+
+func (h *healthChecker) update() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	now := h.clock.Now().Truncate(time.Second)
+	points := h.points.Points(now, healthSeconds)
+
+	serving := len(points) > 0
+	for _, metric := range symo.MetricNames {
+		settings, err := h.config.Metric(metric)
+		if err != nil {
+			h.log.Error(err)
+			continue
+		}
+		if !settings.Enabled {
+			h.server.SetServingStatus(collectorService(metric), healthpb.HealthCheckResponse_SERVICE_UNKNOWN)
+			continue
+		}
+
+		ok := false
+		for _, point := range points {
+			state, _ := point.State.Metric(metric) // имя уже найдено в конфиге
+			if state.Status == symo.StatusOK {
+				ok = true
+				break
+			}
+		}
+		serving = serving && ok
+		h.server.SetServingStatus(collectorService(metric), servingStatus(ok))
+	}
+
+	h.server.SetServingStatus("", servingStatus(serving))
+	h.server.SetServingStatus(_Symo_serviceDesc.ServiceName, servingStatus(serving))
+}
+
+func servingStatus(ok bool) healthpb.HealthCheckResponse_ServingStatus {
+	if ok {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
@@ -0,0 +1,92 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/anfilat/final-stats/internal/mocks"
+	"github.com/anfilat/final-stats/internal/store"
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+func TestHealth(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	config, _ := symo.NewConfig("")
+	config.Metric.UsedFS = false
+
+	start := time.Unix(1_600_000_000, 0)
+	mockedClock := clock.NewMock()
+	mockedClock.Set(start)
+
+	points := store.NewStore(symo.MaxSeconds)
+	checker := newHealthChecker(new(mocks.Logger), config.Metric, mockedClock, points)
+	checker.start()
+
+	// точек еще нет
+	requireStatus(t, checker, "", healthpb.HealthCheckResponse_NOT_SERVING)
+	requireStatus(t, checker, "collector.cpu", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	ok := symo.MetricState{Status: symo.StatusOK}
+	failed := symo.MetricState{Status: symo.StatusError, Message: "no data"}
+	disabled := symo.MetricState{Status: symo.StatusDisabled}
+	points.Append(start, symo.Point{
+		State: symo.MetricsState{LoadAvg: ok, CPU: ok, LoadDisks: failed, UsedFS: disabled},
+	})
+	mockedClock.Add(time.Second)
+
+	// коллектор дисков не отвечает, отключенный коллектор файловых систем не учитывается
+	require.Eventually(t, func() bool {
+		return checkStatus(checker, "collector.cpu") == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, 10*time.Millisecond)
+	requireStatus(t, checker, "collector.loaddisks", healthpb.HealthCheckResponse_NOT_SERVING)
+	requireStatus(t, checker, "collector.usedfs", healthpb.HealthCheckResponse_SERVICE_UNKNOWN)
+	requireStatus(t, checker, "", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	points.Append(start.Add(time.Second), symo.Point{
+		State: symo.MetricsState{LoadAvg: ok, CPU: ok, LoadDisks: ok, UsedFS: disabled},
+	})
+	mockedClock.Add(time.Second)
+	require.Eventually(t, func() bool {
+		return checkStatus(checker, "") == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, 10*time.Millisecond)
+	requireStatus(t, checker, "stats.Symo", healthpb.HealthCheckResponse_SERVING)
+
+	// сервис сбора метрик перестал присылать точки
+	mockedClock.Add(healthSeconds * time.Second)
+	require.Eventually(t, func() bool {
+		return checkStatus(checker, "") == healthpb.HealthCheckResponse_NOT_SERVING
+	}, time.Second, 10*time.Millisecond)
+
+	checker.stop()
+}
+
+func TestHealthWithoutAuth(t *testing.T) {
+	srv, listener, _ := startAuthServer(nil)
+	defer stopGRPCServer(srv, listener)
+
+	conn := getAuthConnect(t, listener, "")
+	defer conn.Close()
+
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	require.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+}
+
+func checkStatus(checker *healthChecker, service string) healthpb.HealthCheckResponse_ServingStatus {
+	resp, err := checker.server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		return healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+	}
+	return resp.Status
+}
+
+func requireStatus(t *testing.T, checker *healthChecker, service string, want healthpb.HealthCheckResponse_ServingStatus) {
+	require.Equal(t, want, checkStatus(checker, service))
+}
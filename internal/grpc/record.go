@@ -0,0 +1,184 @@
+package grpc
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+// Файл записи - сообщения Stats подряд, перед каждым его длина в varint.
+
+// максимальный размер одного сообщения в файле записи.
+const maxRecordSize = 16 << 20
+
+var errRecordTooLarge = errors.New("record is too large")
+
+// RecordWriter пишет поток статистики в файл записи.
+type RecordWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+// NewRecordWriter возвращает писателя файла записи.
+func NewRecordWriter(w io.Writer) *RecordWriter {
+	return &RecordWriter{w: w}
+}
+
+// Write дописывает сообщение одной записью, поэтому прерванная запись оставляет только последнее сообщение неполным.
+func (r *RecordWriter) Write(stats *Stats) error {
+	data, err := proto.Marshal(stats)
+	if err != nil {
+		return fmt.Errorf("cannot marshal stats: %w", err)
+	}
+
+	r.buf = r.buf[:0]
+	r.buf = appendUvarint(r.buf, uint64(len(data)))
+	r.buf = append(r.buf, data...)
+	_, err = r.w.Write(r.buf)
+	return err
+}
+
+func appendUvarint(buf []byte, value uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], value)
+	return append(buf, tmp[:n]...)
+}
+
+type recordReader struct {
+	r *bufio.Reader
+}
+
+// NewRecordReader возвращает источник точек, читающий файл записи. Сообщение за время T содержит метрики
+// за секунду, предшествующую T, поэтому точная запись получается клиентом с N=1 и M=1.
+// Агрегации из записи не используются.
+func NewRecordReader(r io.Reader) symo.PointsSource {
+	return &recordReader{r: bufio.NewReader(r)}
+}
+
+func (r *recordReader) Next() (time.Time, symo.Point, error) {
+	size, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			err = fmt.Errorf("cannot read record: %w", err)
+		}
+		return time.Time{}, symo.Point{}, err
+	}
+	if size > maxRecordSize {
+		return time.Time{}, symo.Point{}, errRecordTooLarge
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return time.Time{}, symo.Point{}, fmt.Errorf("cannot read record: %w", err)
+	}
+
+	stats := &Stats{}
+	if err := proto.Unmarshal(data, stats); err != nil {
+		return time.Time{}, symo.Point{}, fmt.Errorf("cannot unmarshal record: %w", err)
+	}
+
+	tm, point := statsToPoint(stats)
+	return tm, point, nil
+}
+
+func statsToPoint(stats *Stats) (time.Time, symo.Point) {
+	point := symo.Point{
+		LoadAvg:   loadAvgFromGRPC(stats.LoadAvg),
+		CPU:       cpuFromGRPC(stats.Cpu),
+		LoadDisks: loadDisksFromGRPC(stats.LoadDisks),
+		UsedFS:    usedFSFromGRPC(stats.UsedFs),
+		State: symo.MetricsState{
+			LoadAvg:   stateFromGRPC(stats.LoadAvgState),
+			CPU:       stateFromGRPC(stats.CpuState),
+			LoadDisks: stateFromGRPC(stats.LoadDisksState),
+			UsedFS:    stateFromGRPC(stats.UsedFsState),
+		},
+	}
+	return stats.Time.AsTime().Add(-time.Second), point
+}
+
+func loadAvgFromGRPC(data *LoadAvg) *symo.LoadAvgData {
+	if data == nil {
+		return nil
+	}
+	return &symo.LoadAvgData{
+		Load1:  data.Load1,
+		Load5:  data.Load5,
+		Load15: data.Load15,
+	}
+}
+
+func cpuFromGRPC(data *CPU) *symo.CPUData {
+	if data == nil {
+		return nil
+	}
+	return &symo.CPUData{
+		User:   data.User,
+		System: data.System,
+		Idle:   data.Idle,
+	}
+}
+
+func loadDisksFromGRPC(data []*LoadDisk) symo.LoadDisksData {
+	if data == nil {
+		return nil
+	}
+	result := make(symo.LoadDisksData, 0, len(data))
+	for _, diskData := range data {
+		result = append(result, symo.DiskData{
+			Name:    diskData.Name,
+			Tps:     diskData.Tps,
+			KBRead:  diskData.KBRead,
+			KBWrite: diskData.KBWrite,
+		})
+	}
+	return result
+}
+
+func usedFSFromGRPC(data []*UsedFS) symo.UsedFSData {
+	if data == nil {
+		return nil
+	}
+	result := make(symo.UsedFSData, 0, len(data))
+	for _, fsData := range data {
+		result = append(result, symo.FSData{
+			Path:      fsData.Path,
+			UsedSpace: fsData.UsedSpace,
+			UsedInode: fsData.UsedInode,
+		})
+	}
+	return result
+}
+
+func stateFromGRPC(state *MetricState) symo.MetricState {
+	if state == nil {
+		return symo.MetricState{}
+	}
+
+	var status symo.MetricStatus
+	switch state.Status {
+	case Status_STATUS_OK:
+		status = symo.StatusOK
+	case Status_STATUS_STALE:
+		status = symo.StatusStale
+	case Status_STATUS_ERROR:
+		status = symo.StatusError
+	case Status_STATUS_DISABLED:
+		status = symo.StatusDisabled
+	}
+
+	return symo.MetricState{
+		Status:  status,
+		Message: state.Message,
+	}
+}
@@ -0,0 +1,61 @@
+package grpc
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+func TestRecord(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writer := NewRecordWriter(buf)
+
+	first := someStats()
+	second := someStats()
+	second.Time = first.Time.Add(time.Second)
+	second.CPU = nil
+	require.NoError(t, writer.Write(dataToGRPC(first)))
+	require.NoError(t, writer.Write(dataToGRPC(second)))
+
+	reader := NewRecordReader(bytes.NewReader(buf.Bytes()))
+
+	// точка относится к секунде перед временем сообщения
+	tm, point, err := reader.Next()
+	require.NoError(t, err)
+	require.True(t, first.Time.Add(-time.Second).Equal(tm))
+	require.Equal(t, first.LoadAvg, point.LoadAvg)
+	require.Equal(t, first.CPU, point.CPU)
+	require.Equal(t, first.LoadDisks, point.LoadDisks)
+	require.Equal(t, first.UsedFS, point.UsedFS)
+	require.Equal(t, first.State, point.State)
+
+	tm, point, err = reader.Next()
+	require.NoError(t, err)
+	require.True(t, first.Time.Equal(tm))
+	require.Nil(t, point.CPU)
+
+	_, _, err = reader.Next()
+	require.True(t, errors.Is(err, io.EOF))
+}
+
+func TestRecordTruncated(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, NewRecordWriter(buf).Write(dataToGRPC(someStats())))
+
+	reader := NewRecordReader(bytes.NewReader(buf.Bytes()[:buf.Len()-3]))
+	_, _, err := reader.Next()
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestRecordTooLarge(t *testing.T) {
+	reader := NewRecordReader(bytes.NewReader(appendUvarint(nil, maxRecordSize+1)))
+	_, _, err := reader.Next()
+	require.ErrorIs(t, err, errRecordTooLarge)
+	require.Equal(t, symo.StatusUnknown, stateFromGRPC(nil).Status)
+}
@@ -0,0 +1,58 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+// GetSelfStats возвращает метрики самого сервера: работу сервисов сбора метрик и клиентов, хранилища и рантайм.
+func (s *service) GetSelfStats(_ context.Context, _ *SelfStatsRequest) (*SelfStats, error) {
+	s.log.Debug("self stats")
+
+	return selfStatsToGRPC(s.self.SelfStats()), nil
+}
+
+func selfStatsToGRPC(data symo.SelfStats) *SelfStats {
+	result := &SelfStats{
+		Ticks:          data.Collector.Ticks,
+		DroppedTicks:   data.Collector.DroppedTicks,
+		TickDuration:   durationpb.New(data.Collector.TickDuration),
+		TickLag:        durationpb.New(data.Collector.TickLag),
+		Clients:        int32(data.Clients.Clients),
+		DroppedSends:   data.Clients.DroppedSends,
+		SendDuration:   durationpb.New(data.Clients.SendDuration),
+		PointsRetained: int64(data.PointsRetained),
+		TicksRetained:  int64(data.TicksRetained),
+		DroppedWrites:  data.DroppedWrites,
+		HeapBytes:      data.HeapBytes,
+		Goroutines:     int32(data.Goroutines),
+	}
+
+	for _, metric := range symo.MetricNames {
+		sampler, ok := data.Collector.Samplers[metric]
+		if !ok {
+			continue
+		}
+		result.Samplers = append(result.Samplers, &SamplerStats{
+			Metric:       metric,
+			Samples:      sampler.Samples,
+			Errors:       sampler.Errors,
+			Timeouts:     sampler.Timeouts,
+			Latency:      durationpb.New(sampler.Latency),
+			LatencyTotal: durationpb.New(sampler.LatencyTotal),
+		})
+	}
+
+	for _, interval := range symo.SortedIntervals(data.Clients.Intervals) {
+		result.Intervals = append(result.Intervals, &ClientInterval{
+			N:       durationpb.New(interval.N),
+			M:       durationpb.New(interval.M),
+			Clients: int32(data.Clients.Intervals[interval]),
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,99 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/anfilat/final-stats/internal/mocks"
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+func TestGRPCSelfStats(t *testing.T) {
+	srv, listener, _, _ := startGRPCServer()
+	defer stopGRPCServer(srv, listener)
+
+	conn := getConnect(t, listener)
+	defer conn.Close()
+
+	stats, err := NewSymoClient(conn).GetSelfStats(context.Background(), &SelfStatsRequest{})
+	require.NoError(t, err)
+
+	require.Equal(t, uint64(60), stats.Ticks)
+	require.Equal(t, uint64(1), stats.DroppedTicks)
+	require.Equal(t, 2*time.Millisecond, stats.TickDuration.AsDuration())
+	require.Equal(t, time.Millisecond, stats.TickLag.AsDuration())
+
+	// коллекторы в порядке имен метрик, неопрошенные пропускаются
+	require.Len(t, stats.Samplers, 2)
+	require.Equal(t, "cpu", stats.Samplers[0].Metric)
+	require.Equal(t, uint64(3), stats.Samplers[0].Errors)
+	require.Equal(t, "usedfs", stats.Samplers[1].Metric)
+	require.Equal(t, uint64(2), stats.Samplers[1].Timeouts)
+	require.Equal(t, 40*time.Millisecond, stats.Samplers[1].Latency.AsDuration())
+
+	require.Equal(t, int32(3), stats.Clients)
+	require.Len(t, stats.Intervals, 2)
+	require.Equal(t, 500*time.Millisecond, stats.Intervals[0].N.AsDuration())
+	require.Equal(t, int32(1), stats.Intervals[0].Clients)
+	require.Equal(t, 5*time.Second, stats.Intervals[1].N.AsDuration())
+	require.Equal(t, 15*time.Second, stats.Intervals[1].M.AsDuration())
+	require.Equal(t, int32(2), stats.Intervals[1].Clients)
+	require.Equal(t, uint64(4), stats.DroppedSends)
+
+	require.Equal(t, int64(600), stats.PointsRetained)
+	require.Equal(t, int64(240), stats.TicksRetained)
+	require.Equal(t, uint64(5), stats.DroppedWrites)
+	require.Equal(t, uint64(1024), stats.HeapBytes)
+	require.Equal(t, int32(25), stats.Goroutines)
+}
+
+func TestAuthSelfStats(t *testing.T) {
+	srv, listener, _ := startAuthServer(nil)
+	defer stopGRPCServer(srv, listener)
+
+	// клиенту с ограниченным списком метрик группа symo доступна, только если она есть в списке
+	conn := getAuthConnect(t, listener, "dash")
+	defer conn.Close()
+	_, err := NewSymoClient(conn).GetSelfStats(context.Background(), &SelfStatsRequest{})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	conn = getAuthConnect(t, listener, "ops")
+	defer conn.Close()
+	_, err = NewSymoClient(conn).GetSelfStats(context.Background(), &SelfStatsRequest{})
+	require.NoError(t, err)
+}
+
+func testSelfStater() *mocks.SelfStater {
+	self := new(mocks.SelfStater)
+	self.On("SelfStats").Return(symo.SelfStats{
+		Collector: symo.CollectorStats{
+			Ticks:        60,
+			DroppedTicks: 1,
+			TickDuration: 2 * time.Millisecond,
+			TickLag:      time.Millisecond,
+			Samplers: map[string]symo.SamplerStats{
+				"usedfs": {Samples: 20, Timeouts: 2, Latency: 40 * time.Millisecond},
+				"cpu":    {Samples: 60, Errors: 3},
+			},
+		},
+		Clients: symo.ClientsStats{
+			Clients:      3,
+			DroppedSends: 4,
+			Intervals: map[symo.ClientInterval]int{
+				{N: 5 * time.Second, M: 15 * time.Second}:              2,
+				{N: 500 * time.Millisecond, M: 500 * time.Millisecond}: 1,
+			},
+		},
+		PointsRetained: 600,
+		TicksRetained:  240,
+		DroppedWrites:  5,
+		HeapBytes:      1024,
+		Goroutines:     25,
+	}).Maybe()
+	return self
+}
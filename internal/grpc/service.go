@@ -1,9 +1,14 @@
 package grpc
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
+	"time"
 
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
@@ -14,104 +19,267 @@ type service struct {
 	UnimplementedSymoServer
 
 	clients symo.NewClienter
-	config  symo.Config
+	self    symo.SelfStater
+	app     *symo.AppSettings
 	log     symo.Logger
 }
 
-func newService(log symo.Logger, config symo.Config, clients symo.NewClienter) *service {
+func newService(log symo.Logger, app *symo.AppSettings, clients symo.NewClienter, self symo.SelfStater) *service {
 	return &service{
 		clients: clients,
-		config:  config,
+		self:    self,
+		app:     app,
 		log:     log,
 	}
 }
 
 // GetStats реализует обработку клиентского запроса на получение статистики.
 func (s *service) GetStats(req *StatsRequest, srv Symo_GetStatsServer) error {
-	s.log.Debug("new client. Every ", req.N, " for ", req.M)
+	host := peerHost(srv.Context())
+	log := s.log.With(symo.LogPeer, host)
 
-	n := int(req.N)
-	m := int(req.M)
+	n, err := intervalFromGRPC("N", req.N, req.NMs)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	m, err := intervalFromGRPC("M", req.M, req.MMs)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	log.Debug("new client. Every ", n, " for ", m)
 
-	MaxSeconds := s.config.App.MaxSeconds
-	if n <= 0 {
-		return status.Error(codes.InvalidArgument, "N must be greater than 0 seconds")
+	aggs, err := aggregationsFromGRPC(req.Aggregations)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
 	}
-	if n > MaxSeconds {
-		return status.Error(codes.InvalidArgument, fmt.Sprintf("N must be less than %v seconds", MaxSeconds))
+	if _, ok := DropPolicy_name[int32(req.Policy)]; !ok {
+		return status.Error(codes.InvalidArgument, fmt.Sprintf("unknown drop policy %d", req.Policy))
 	}
-	if m <= 0 {
-		return status.Error(codes.InvalidArgument, "M must be greater than 0 seconds")
+
+	clientData := symo.ClientData{
+		N:         n,
+		M:         m,
+		Aggs:      aggs,
+		Policy:    symo.DropPolicy(req.Policy),
+		DropLimit: int(req.DropLimit),
 	}
-	if m > MaxSeconds {
-		return status.Error(codes.InvalidArgument, fmt.Sprintf("M must be less than %v seconds", MaxSeconds))
+	app := s.app.Get()
+	if err := clientData.Validate(app.MaxWindow(), app.Tick); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
 	}
 
-	ch, del, err := s.clients.NewClient(symo.ClientData{N: n, M: m})
+	clientData.Peer = host
+	ch, del, err := s.clients.NewClient(clientData)
 	if err != nil {
-		return status.Error(codes.Unavailable, "service is closing")
+		return newClientError(err)
 	}
 	defer del()
 
-L:
 	for {
 		select {
 		case <-srv.Context().Done():
-			s.log.Debug("client disconnected")
-			break L
+			log.Debug("client disconnected")
+			return nil
 		case data, ok := <-ch:
 			if !ok {
-				break L
+				return nil
+			}
+			if errors.Is(data.Err, symo.ErrOverflow) {
+				return status.Error(codes.ResourceExhausted, "client does not keep up with the stats stream")
 			}
 
 			if err := srv.Send(dataToGRPC(data)); err != nil {
-				s.log.Debug(fmt.Errorf("unable to send message: %w", err))
-				break L
+				log.Debug(fmt.Errorf("unable to send message: %w", err))
+				return nil
 			}
 		}
 	}
+}
+
+// GetSnapshot возвращает статистику, усредненную за последние M.
+func (s *service) GetSnapshot(ctx context.Context, req *SnapshotRequest) (*Stats, error) {
+	host := peerHost(ctx)
+	log := s.log.With(symo.LogPeer, host)
+
+	m, err := intervalFromGRPC("M", req.M, req.MMs)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	log.Debug("snapshot for ", m)
+
+	aggs, err := aggregationsFromGRPC(req.Aggregations)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	clientData := symo.ClientData{
+		M:    m,
+		Aggs: aggs,
+		Once: true,
+	}
+	app := s.app.Get()
+	if err := clientData.Validate(app.MaxWindow(), app.Tick); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	clientData.Peer = host
+	ch, del, err := s.clients.NewClient(clientData)
+	if err != nil {
+		return nil, newClientError(err)
+	}
+	defer del()
+
+	select {
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	case data, ok := <-ch:
+		if !ok || data.Err != nil {
+			return nil, status.Error(codes.Unavailable, "service is closing")
+		}
+		return dataToGRPC(data), nil
+	}
+}
+
+// intervalFromGRPC возвращает N или M запроса. Интервал задается в секундах или, для интервалов
+// короче секунды, в миллисекундах.
+func intervalFromGRPC(name string, seconds, milliseconds int32) (time.Duration, error) {
+	if seconds != 0 && milliseconds != 0 {
+		return 0, fmt.Errorf("%s must be set either in seconds or in milliseconds", name)
+	}
+	if milliseconds != 0 {
+		return time.Duration(milliseconds) * time.Millisecond, nil
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+func aggregationsFromGRPC(list []Aggregation) (symo.Aggregations, error) {
+	result := make([]symo.Aggregation, 0, len(list))
+	for _, agg := range list {
+		if _, ok := Aggregation_name[int32(agg)]; !ok {
+			return 0, fmt.Errorf("unknown aggregation %d", agg)
+		}
+		result = append(result, symo.Aggregation(agg))
+	}
+	return symo.NewAggregations(result...), nil
+}
 
-	return nil
+// peerHost возвращает адрес клиента без порта для ограничения подключений.
+func peerHost(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
+
+// newClientError возвращает статус отказа сервиса клиентов в подключении.
+func newClientError(err error) error {
+	if errors.Is(err, symo.ErrLimit) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	return status.Error(codes.Unavailable, "service is closing")
 }
 
 func dataToGRPC(data *symo.Stats) *Stats {
 	result := &Stats{}
+	result.Seq = data.Seq
+	result.Dropped = data.Dropped
 	result.Time = timestamppb.New(data.Time)
+	result.LoadAvg = loadAvgToGRPC(data.LoadAvg)
+	result.Cpu = cpuToGRPC(data.CPU)
+	result.LoadDisks = loadDisksToGRPC(data.LoadDisks)
+	result.UsedFs = usedFSToGRPC(data.UsedFS)
 
-	if data.LoadAvg != nil {
-		result.LoadAvg = &LoadAvg{
-			Load1:  data.LoadAvg.Load1,
-			Load5:  data.LoadAvg.Load5,
-			Load15: data.LoadAvg.Load15,
-		}
+	result.LoadAvgState = stateToGRPC(data.State.LoadAvg)
+	result.CpuState = stateToGRPC(data.State.CPU)
+	result.LoadDisksState = stateToGRPC(data.State.LoadDisks)
+	result.UsedFsState = stateToGRPC(data.State.UsedFS)
+
+	for _, aggData := range data.Aggregated {
+		result.Aggregated = append(result.Aggregated, &Aggregated{
+			Aggregation: Aggregation(aggData.Aggregation),
+			LoadAvg:     loadAvgToGRPC(aggData.LoadAvg),
+			Cpu:         cpuToGRPC(aggData.CPU),
+			LoadDisks:   loadDisksToGRPC(aggData.LoadDisks),
+			UsedFs:      usedFSToGRPC(aggData.UsedFS),
+		})
 	}
-	if data.CPU != nil {
-		result.Cpu = &CPU{
-			User:   data.CPU.User,
-			System: data.CPU.System,
-			Idle:   data.CPU.Idle,
-		}
+
+	return result
+}
+
+func loadAvgToGRPC(data *symo.LoadAvgData) *LoadAvg {
+	if data == nil {
+		return nil
 	}
-	if data.LoadDisks != nil {
-		result.LoadDisks = make([]*LoadDisk, 0, len(data.LoadDisks))
-		for _, diskData := range data.LoadDisks {
-			result.LoadDisks = append(result.LoadDisks, &LoadDisk{
-				Name:    diskData.Name,
-				Tps:     diskData.Tps,
-				KBRead:  diskData.KBRead,
-				KBWrite: diskData.KBWrite,
-			})
-		}
+	return &LoadAvg{
+		Load1:  data.Load1,
+		Load5:  data.Load5,
+		Load15: data.Load15,
 	}
-	if data.UsedFS != nil {
-		result.UsedFs = make([]*UsedFS, 0, len(data.UsedFS))
-		for _, fsData := range data.UsedFS {
-			result.UsedFs = append(result.UsedFs, &UsedFS{
-				Path:      fsData.Path,
-				UsedSpace: fsData.UsedSpace,
-				UsedInode: fsData.UsedInode,
-			})
-		}
+}
+
+func cpuToGRPC(data *symo.CPUData) *CPU {
+	if data == nil {
+		return nil
+	}
+	return &CPU{
+		User:   data.User,
+		System: data.System,
+		Idle:   data.Idle,
+	}
+}
+
+func loadDisksToGRPC(data symo.LoadDisksData) []*LoadDisk {
+	if data == nil {
+		return nil
+	}
+	result := make([]*LoadDisk, 0, len(data))
+	for _, diskData := range data {
+		result = append(result, &LoadDisk{
+			Name:    diskData.Name,
+			Tps:     diskData.Tps,
+			KBRead:  diskData.KBRead,
+			KBWrite: diskData.KBWrite,
+		})
+	}
+	return result
+}
+
+func usedFSToGRPC(data symo.UsedFSData) []*UsedFS {
+	if data == nil {
+		return nil
+	}
+	result := make([]*UsedFS, 0, len(data))
+	for _, fsData := range data {
+		result = append(result, &UsedFS{
+			Path:      fsData.Path,
+			UsedSpace: fsData.UsedSpace,
+			UsedInode: fsData.UsedInode,
+		})
 	}
 	return result
 }
+
+func stateToGRPC(state symo.MetricState) *MetricState {
+	var status Status
+	switch state.Status {
+	case symo.StatusOK:
+		status = Status_STATUS_OK
+	case symo.StatusStale:
+		status = Status_STATUS_STALE
+	case symo.StatusError:
+		status = Status_STATUS_ERROR
+	case symo.StatusDisabled:
+		status = Status_STATUS_DISABLED
+	}
+
+	return &MetricState{
+		Status:  status,
+		Message: state.Message,
+	}
+}
@@ -51,6 +51,132 @@ func TestGRPC(t *testing.T) {
 	require.NotNil(t, stats.Cpu)
 	require.NotNil(t, stats.LoadDisks)
 	require.NotNil(t, stats.UsedFs)
+	require.Equal(t, Status_STATUS_OK, stats.LoadAvgState.Status)
+	require.Equal(t, Status_STATUS_ERROR, stats.CpuState.Status)
+	require.Equal(t, "cpu error", stats.CpuState.Message)
+	require.Equal(t, Status_STATUS_STALE, stats.LoadDisksState.Status)
+	require.Equal(t, Status_STATUS_DISABLED, stats.UsedFsState.Status)
+}
+
+func TestGRPCAggregations(t *testing.T) {
+	srv, listener, clientsService, log := startGRPCServer()
+	defer stopGRPCServer(srv, listener)
+
+	conn := getConnect(t, listener)
+	defer conn.Close()
+
+	log.On("Debug", "client disconnected")
+
+	ch := make(chan *symo.Stats, 1)
+	del := func() {}
+	clientData := symo.ClientData{N: 1 * time.Second, M: 5 * time.Second, Aggs: symo.NewAggregations(symo.AggMax, symo.AggP95), Peer: "bufconn"}
+	clientsService.On("NewClient", clientData).Return((<-chan *symo.Stats)(ch), del, nil)
+
+	client := NewSymoClient(conn)
+
+	ctx := context.Background()
+	req := &StatsRequest{
+		N:            1,
+		M:            5,
+		Aggregations: []Aggregation{Aggregation_AGG_P95, Aggregation_AGG_MAX},
+	}
+	reqClient, err := client.GetStats(ctx, req)
+	require.NoError(t, err)
+
+	data := someStats()
+	data.Aggregated = []symo.AggregatedData{{
+		Aggregation: symo.AggMax,
+		LoadAvg:     &symo.LoadAvgData{Load1: 3, Load5: 2, Load15: 1},
+	}}
+	ch <- data
+	stats, err := reqClient.Recv()
+	require.NoError(t, err)
+	require.Len(t, stats.Aggregated, 1)
+	require.Equal(t, Aggregation_AGG_MAX, stats.Aggregated[0].Aggregation)
+	require.Equal(t, 3.0, stats.Aggregated[0].LoadAvg.Load1)
+	require.Nil(t, stats.Aggregated[0].Cpu)
+
+	clientsService.AssertExpectations(t)
+}
+
+func TestGRPCDroppedAndOverflow(t *testing.T) {
+	srv, listener, clientsService, _ := startGRPCServer()
+	defer stopGRPCServer(srv, listener)
+
+	conn := getConnect(t, listener)
+	defer conn.Close()
+
+	ch := make(chan *symo.Stats, 3)
+	del := func() {}
+	clientData := symo.ClientData{N: 1 * time.Second, M: 1 * time.Second, Policy: symo.PolicyDisconnect, DropLimit: 5, Peer: "bufconn"}
+	clientsService.On("NewClient", clientData).Return((<-chan *symo.Stats)(ch), del, nil)
+
+	client := NewSymoClient(conn)
+
+	ctx := context.Background()
+	req := &StatsRequest{
+		N:         1,
+		M:         1,
+		Policy:    DropPolicy_POLICY_DISCONNECT,
+		DropLimit: 5,
+	}
+	reqClient, err := client.GetStats(ctx, req)
+	require.NoError(t, err)
+
+	first := someStats()
+	first.Seq = 1
+	second := someStats()
+	second.Seq = 4
+	second.Dropped = 2
+	ch <- first
+	ch <- second
+	ch <- &symo.Stats{Seq: 5, Err: symo.ErrOverflow}
+
+	stats, err := reqClient.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), stats.Seq)
+	require.Equal(t, uint64(0), stats.Dropped)
+
+	stats, err = reqClient.Recv()
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), stats.Seq)
+	require.Equal(t, uint64(2), stats.Dropped)
+
+	_, err = reqClient.Recv()
+	er, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.ResourceExhausted, er.Code())
+}
+
+func TestGRPCSnapshot(t *testing.T) {
+	srv, listener, clientsService, _ := startGRPCServer()
+	defer stopGRPCServer(srv, listener)
+
+	conn := getConnect(t, listener)
+	defer conn.Close()
+
+	ch := make(chan *symo.Stats, 1)
+	del := func() {}
+	clientData := symo.ClientData{M: 15 * time.Second, Aggs: symo.NewAggregations(symo.AggLast), Once: true, Peer: "bufconn"}
+	clientsService.On("NewClient", clientData).Return((<-chan *symo.Stats)(ch), del, nil)
+
+	client := NewSymoClient(conn)
+
+	ch <- someStats()
+	stats, err := client.GetSnapshot(context.Background(), &SnapshotRequest{
+		M:            15,
+		Aggregations: []Aggregation{Aggregation_AGG_LAST},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, stats.Cpu)
+	require.Equal(t, "sda", stats.LoadDisks[0].Name)
+
+	_, err = client.GetSnapshot(context.Background(), &SnapshotRequest{M: symo.MaxSeconds + 1})
+	er, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, codes.InvalidArgument, er.Code())
+
+	clientsService.AssertExpectations(t)
 }
 
 func TestGRPCFails(t *testing.T) {
@@ -58,6 +184,10 @@ func TestGRPCFails(t *testing.T) {
 		name    string
 		n       int32
 		m       int32
+		nMs     int32
+		mMs     int32
+		aggs    []Aggregation
+		policy  DropPolicy
 		message string
 	}{
 		{
@@ -96,6 +226,40 @@ func TestGRPCFails(t *testing.T) {
 			m:       symo.MaxSeconds + 1,
 			message: fmt.Sprintf("M must be less than %v seconds", symo.MaxSeconds),
 		},
+		{
+			name:    "n in seconds and milliseconds",
+			n:       1,
+			nMs:     500,
+			m:       1,
+			message: "N must be set either in seconds or in milliseconds",
+		},
+		{
+			name:    "m in seconds and milliseconds",
+			n:       1,
+			m:       1,
+			mMs:     500,
+			message: "M must be set either in seconds or in milliseconds",
+		},
+		{
+			name:    "n shorter than the tick",
+			nMs:     500,
+			m:       1,
+			message: "N must be a multiple of the tick 1s",
+		},
+		{
+			name:    "unknown drop policy",
+			n:       1,
+			m:       1,
+			policy:  100,
+			message: "unknown drop policy 100",
+		},
+		{
+			name:    "unknown aggregation",
+			n:       1,
+			m:       1,
+			aggs:    []Aggregation{Aggregation_AGG_MAX, 100},
+			message: "unknown aggregation 100",
+		},
 	}
 
 	for _, tt := range tests {
@@ -111,8 +275,12 @@ func TestGRPCFails(t *testing.T) {
 
 			ctx := context.Background()
 			req := &StatsRequest{
-				N: tt.n,
-				M: tt.m,
+				N:            tt.n,
+				M:            tt.m,
+				NMs:          tt.nMs,
+				MMs:          tt.mMs,
+				Aggregations: tt.aggs,
+				Policy:       tt.policy,
 			}
 			reqClient, err := client.GetStats(ctx, req)
 			require.NoError(t, err)
@@ -157,13 +325,16 @@ func startGRPCServer() (*grpc.Server, *bufconn.Listener, *mocks.NewClienter, *mo
 	srv := grpc.NewServer()
 
 	log := new(mocks.Logger)
+	log.On("With", symo.LogPeer, "bufconn").Return(log)
 	log.On("Debug", "new client. Every ", mock.Anything, " for ", mock.Anything)
+	log.On("Debug", "snapshot for ", mock.Anything)
+	log.On("Debug", "self stats").Maybe()
 
 	config, _ := symo.NewConfig("")
 
 	clientsService := new(mocks.NewClienter)
 
-	RegisterSymoServer(srv, newService(log, config, clientsService))
+	RegisterSymoServer(srv, newService(log, symo.NewAppSettings(config.App), clientsService, testSelfStater()))
 
 	go func() {
 		_ = srv.Serve(listener)
@@ -218,5 +389,31 @@ func someStats() *symo.Stats {
 				UsedInode: 7.77,
 			},
 		},
+		State: symo.MetricsState{
+			LoadAvg:   symo.MetricState{Status: symo.StatusOK},
+			CPU:       symo.MetricState{Status: symo.StatusError, Message: "cpu error"},
+			LoadDisks: symo.MetricState{Status: symo.StatusStale},
+			UsedFS:    symo.MetricState{Status: symo.StatusDisabled},
+		},
 	}
 }
+
+func TestGRPCClientLimit(t *testing.T) {
+	srv, listener, clientsService, _ := startGRPCServer()
+	defer stopGRPCServer(srv, listener)
+
+	conn := getConnect(t, listener)
+	defer conn.Close()
+
+	err := fmt.Errorf("%w: too many clients", symo.ErrLimit)
+	clientsService.On("NewClient", mock.Anything).Return((<-chan *symo.Stats)(nil), func() {}, err)
+
+	client := NewSymoClient(conn)
+	reqClient, err := client.GetStats(context.Background(), &StatsRequest{N: 1, M: 1})
+	require.NoError(t, err)
+	_, err = reqClient.Recv()
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	_, err = client.GetSnapshot(context.Background(), &SnapshotRequest{M: 1})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
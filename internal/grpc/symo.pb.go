@@ -10,6 +10,7 @@ import (
 	proto "github.com/golang/protobuf/proto"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
@@ -26,6 +27,222 @@ const (
 // of the legacy proto package is being used.
 const _ = proto.ProtoPackageIsVersion4
 
+// STATUS_UNKNOWN - состояние не задано. В записях, сделанных до его появления, STATUS_OK был нулем
+// и читается как STATUS_UNKNOWN.
+type Status int32
+
+const (
+	Status_STATUS_UNKNOWN  Status = 0
+	Status_STATUS_STALE    Status = 1
+	Status_STATUS_ERROR    Status = 2
+	Status_STATUS_DISABLED Status = 3
+	Status_STATUS_OK       Status = 4
+)
+
+// Enum value maps for Status.
+var (
+	Status_name = map[int32]string{
+		0: "STATUS_UNKNOWN",
+		1: "STATUS_STALE",
+		2: "STATUS_ERROR",
+		3: "STATUS_DISABLED",
+		4: "STATUS_OK",
+	}
+	Status_value = map[string]int32{
+		"STATUS_UNKNOWN":  0,
+		"STATUS_STALE":    1,
+		"STATUS_ERROR":    2,
+		"STATUS_DISABLED": 3,
+		"STATUS_OK":       4,
+	}
+)
+
+func (x Status) Enum() *Status {
+	p := new(Status)
+	*p = x
+	return p
+}
+
+func (x Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_symo_proto_enumTypes[0].Descriptor()
+}
+
+func (Status) Type() protoreflect.EnumType {
+	return &file_symo_proto_enumTypes[0]
+}
+
+func (x Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Status.Descriptor instead.
+func (Status) EnumDescriptor() ([]byte, []int) {
+	return file_symo_proto_rawDescGZIP(), []int{0}
+}
+
+type Aggregation int32
+
+const (
+	Aggregation_AGG_MEAN Aggregation = 0
+	Aggregation_AGG_MIN  Aggregation = 1
+	Aggregation_AGG_MAX  Aggregation = 2
+	Aggregation_AGG_P50  Aggregation = 3
+	Aggregation_AGG_P95  Aggregation = 4
+	Aggregation_AGG_P99  Aggregation = 5
+	Aggregation_AGG_LAST Aggregation = 6
+)
+
+// Enum value maps for Aggregation.
+var (
+	Aggregation_name = map[int32]string{
+		0: "AGG_MEAN",
+		1: "AGG_MIN",
+		2: "AGG_MAX",
+		3: "AGG_P50",
+		4: "AGG_P95",
+		5: "AGG_P99",
+		6: "AGG_LAST",
+	}
+	Aggregation_value = map[string]int32{
+		"AGG_MEAN": 0,
+		"AGG_MIN":  1,
+		"AGG_MAX":  2,
+		"AGG_P50":  3,
+		"AGG_P95":  4,
+		"AGG_P99":  5,
+		"AGG_LAST": 6,
+	}
+)
+
+func (x Aggregation) Enum() *Aggregation {
+	p := new(Aggregation)
+	*p = x
+	return p
+}
+
+func (x Aggregation) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Aggregation) Descriptor() protoreflect.EnumDescriptor {
+	return file_symo_proto_enumTypes[1].Descriptor()
+}
+
+func (Aggregation) Type() protoreflect.EnumType {
+	return &file_symo_proto_enumTypes[1]
+}
+
+func (x Aggregation) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Aggregation.Descriptor instead.
+func (Aggregation) EnumDescriptor() ([]byte, []int) {
+	return file_symo_proto_rawDescGZIP(), []int{1}
+}
+
+type DropPolicy int32
+
+const (
+	DropPolicy_POLICY_DEFAULT     DropPolicy = 0
+	DropPolicy_POLICY_DROP_NEWEST DropPolicy = 1
+	DropPolicy_POLICY_DROP_OLDEST DropPolicy = 2
+	DropPolicy_POLICY_DISCONNECT  DropPolicy = 3
+)
+
+// Enum value maps for DropPolicy.
+var (
+	DropPolicy_name = map[int32]string{
+		0: "POLICY_DEFAULT",
+		1: "POLICY_DROP_NEWEST",
+		2: "POLICY_DROP_OLDEST",
+		3: "POLICY_DISCONNECT",
+	}
+	DropPolicy_value = map[string]int32{
+		"POLICY_DEFAULT":     0,
+		"POLICY_DROP_NEWEST": 1,
+		"POLICY_DROP_OLDEST": 2,
+		"POLICY_DISCONNECT":  3,
+	}
+)
+
+func (x DropPolicy) Enum() *DropPolicy {
+	p := new(DropPolicy)
+	*p = x
+	return p
+}
+
+func (x DropPolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DropPolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_symo_proto_enumTypes[2].Descriptor()
+}
+
+func (DropPolicy) Type() protoreflect.EnumType {
+	return &file_symo_proto_enumTypes[2]
+}
+
+func (x DropPolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DropPolicy.Descriptor instead.
+func (DropPolicy) EnumDescriptor() ([]byte, []int) {
+	return file_symo_proto_rawDescGZIP(), []int{2}
+}
+
+type DumpFormat int32
+
+const (
+	DumpFormat_DUMP_JSON DumpFormat = 0
+	DumpFormat_DUMP_CSV  DumpFormat = 1
+)
+
+// Enum value maps for DumpFormat.
+var (
+	DumpFormat_name = map[int32]string{
+		0: "DUMP_JSON",
+		1: "DUMP_CSV",
+	}
+	DumpFormat_value = map[string]int32{
+		"DUMP_JSON": 0,
+		"DUMP_CSV":  1,
+	}
+)
+
+func (x DumpFormat) Enum() *DumpFormat {
+	p := new(DumpFormat)
+	*p = x
+	return p
+}
+
+func (x DumpFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DumpFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_symo_proto_enumTypes[3].Descriptor()
+}
+
+func (DumpFormat) Type() protoreflect.EnumType {
+	return &file_symo_proto_enumTypes[3]
+}
+
+func (x DumpFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DumpFormat.Descriptor instead.
+func (DumpFormat) EnumDescriptor() ([]byte, []int) {
+	return file_symo_proto_rawDescGZIP(), []int{3}
+}
+
 type LoadAvg struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -286,22 +503,163 @@ func (x *UsedFS) GetUsedInode() float64 {
 	return 0
 }
 
+type MetricState struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Status  Status `protobuf:"varint,1,opt,name=status,proto3,enum=stats.Status" json:"status,omitempty"`
+	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (x *MetricState) Reset() {
+	*x = MetricState{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_symo_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MetricState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetricState) ProtoMessage() {}
+
+func (x *MetricState) ProtoReflect() protoreflect.Message {
+	mi := &file_symo_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetricState.ProtoReflect.Descriptor instead.
+func (*MetricState) Descriptor() ([]byte, []int) {
+	return file_symo_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *MetricState) GetStatus() Status {
+	if x != nil {
+		return x.Status
+	}
+	return Status_STATUS_UNKNOWN
+}
+
+func (x *MetricState) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type Aggregated struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Aggregation Aggregation `protobuf:"varint,1,opt,name=aggregation,proto3,enum=stats.Aggregation" json:"aggregation,omitempty"`
+	LoadAvg     *LoadAvg    `protobuf:"bytes,2,opt,name=load_avg,json=loadAvg,proto3" json:"load_avg,omitempty"`
+	Cpu         *CPU        `protobuf:"bytes,3,opt,name=cpu,proto3" json:"cpu,omitempty"`
+	LoadDisks   []*LoadDisk `protobuf:"bytes,4,rep,name=load_disks,json=loadDisks,proto3" json:"load_disks,omitempty"`
+	UsedFs      []*UsedFS   `protobuf:"bytes,5,rep,name=used_fs,json=usedFs,proto3" json:"used_fs,omitempty"`
+}
+
+func (x *Aggregated) Reset() {
+	*x = Aggregated{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_symo_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Aggregated) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Aggregated) ProtoMessage() {}
+
+func (x *Aggregated) ProtoReflect() protoreflect.Message {
+	mi := &file_symo_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Aggregated.ProtoReflect.Descriptor instead.
+func (*Aggregated) Descriptor() ([]byte, []int) {
+	return file_symo_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Aggregated) GetAggregation() Aggregation {
+	if x != nil {
+		return x.Aggregation
+	}
+	return Aggregation_AGG_MEAN
+}
+
+func (x *Aggregated) GetLoadAvg() *LoadAvg {
+	if x != nil {
+		return x.LoadAvg
+	}
+	return nil
+}
+
+func (x *Aggregated) GetCpu() *CPU {
+	if x != nil {
+		return x.Cpu
+	}
+	return nil
+}
+
+func (x *Aggregated) GetLoadDisks() []*LoadDisk {
+	if x != nil {
+		return x.LoadDisks
+	}
+	return nil
+}
+
+func (x *Aggregated) GetUsedFs() []*UsedFS {
+	if x != nil {
+		return x.UsedFs
+	}
+	return nil
+}
+
 type Stats struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Time      *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
-	LoadAvg   *LoadAvg               `protobuf:"bytes,2,opt,name=load_avg,json=loadAvg,proto3" json:"load_avg,omitempty"`
-	Cpu       *CPU                   `protobuf:"bytes,3,opt,name=cpu,proto3" json:"cpu,omitempty"`
-	LoadDisks []*LoadDisk            `protobuf:"bytes,4,rep,name=load_disks,json=loadDisks,proto3" json:"load_disks,omitempty"`
-	UsedFs    []*UsedFS              `protobuf:"bytes,5,rep,name=used_fs,json=usedFs,proto3" json:"used_fs,omitempty"`
+	Time           *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	LoadAvg        *LoadAvg               `protobuf:"bytes,2,opt,name=load_avg,json=loadAvg,proto3" json:"load_avg,omitempty"`
+	Cpu            *CPU                   `protobuf:"bytes,3,opt,name=cpu,proto3" json:"cpu,omitempty"`
+	LoadDisks      []*LoadDisk            `protobuf:"bytes,4,rep,name=load_disks,json=loadDisks,proto3" json:"load_disks,omitempty"`
+	UsedFs         []*UsedFS              `protobuf:"bytes,5,rep,name=used_fs,json=usedFs,proto3" json:"used_fs,omitempty"`
+	LoadAvgState   *MetricState           `protobuf:"bytes,6,opt,name=load_avg_state,json=loadAvgState,proto3" json:"load_avg_state,omitempty"`
+	CpuState       *MetricState           `protobuf:"bytes,7,opt,name=cpu_state,json=cpuState,proto3" json:"cpu_state,omitempty"`
+	LoadDisksState *MetricState           `protobuf:"bytes,8,opt,name=load_disks_state,json=loadDisksState,proto3" json:"load_disks_state,omitempty"`
+	UsedFsState    *MetricState           `protobuf:"bytes,9,opt,name=used_fs_state,json=usedFsState,proto3" json:"used_fs_state,omitempty"`
+	Aggregated     []*Aggregated          `protobuf:"bytes,10,rep,name=aggregated,proto3" json:"aggregated,omitempty"`
+	Seq            uint64                 `protobuf:"varint,11,opt,name=seq,proto3" json:"seq,omitempty"`
+	Dropped        uint64                 `protobuf:"varint,12,opt,name=dropped,proto3" json:"dropped,omitempty"`
 }
 
 func (x *Stats) Reset() {
 	*x = Stats{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_symo_proto_msgTypes[4]
+		mi := &file_symo_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -314,7 +672,7 @@ func (x *Stats) String() string {
 func (*Stats) ProtoMessage() {}
 
 func (x *Stats) ProtoReflect() protoreflect.Message {
-	mi := &file_symo_proto_msgTypes[4]
+	mi := &file_symo_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -327,7 +685,7 @@ func (x *Stats) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Stats.ProtoReflect.Descriptor instead.
 func (*Stats) Descriptor() ([]byte, []int) {
-	return file_symo_proto_rawDescGZIP(), []int{4}
+	return file_symo_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *Stats) GetTime() *timestamppb.Timestamp {
@@ -365,19 +723,74 @@ func (x *Stats) GetUsedFs() []*UsedFS {
 	return nil
 }
 
+func (x *Stats) GetLoadAvgState() *MetricState {
+	if x != nil {
+		return x.LoadAvgState
+	}
+	return nil
+}
+
+func (x *Stats) GetCpuState() *MetricState {
+	if x != nil {
+		return x.CpuState
+	}
+	return nil
+}
+
+func (x *Stats) GetLoadDisksState() *MetricState {
+	if x != nil {
+		return x.LoadDisksState
+	}
+	return nil
+}
+
+func (x *Stats) GetUsedFsState() *MetricState {
+	if x != nil {
+		return x.UsedFsState
+	}
+	return nil
+}
+
+func (x *Stats) GetAggregated() []*Aggregated {
+	if x != nil {
+		return x.Aggregated
+	}
+	return nil
+}
+
+func (x *Stats) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *Stats) GetDropped() uint64 {
+	if x != nil {
+		return x.Dropped
+	}
+	return 0
+}
+
 type StatsRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	N int32 `protobuf:"varint,1,opt,name=N,proto3" json:"N,omitempty"`
-	M int32 `protobuf:"varint,2,opt,name=M,proto3" json:"M,omitempty"`
+	N            int32         `protobuf:"varint,1,opt,name=N,proto3" json:"N,omitempty"`
+	M            int32         `protobuf:"varint,2,opt,name=M,proto3" json:"M,omitempty"`
+	Aggregations []Aggregation `protobuf:"varint,3,rep,packed,name=aggregations,proto3,enum=stats.Aggregation" json:"aggregations,omitempty"`
+	Policy       DropPolicy    `protobuf:"varint,4,opt,name=policy,proto3,enum=stats.DropPolicy" json:"policy,omitempty"`
+	DropLimit    int32         `protobuf:"varint,5,opt,name=drop_limit,json=dropLimit,proto3" json:"drop_limit,omitempty"`
+	// N и M в миллисекундах для интервалов короче секунды. Задаются вместо N и M
+	NMs int32 `protobuf:"varint,6,opt,name=n_ms,json=nMs,proto3" json:"n_ms,omitempty"`
+	MMs int32 `protobuf:"varint,7,opt,name=m_ms,json=mMs,proto3" json:"m_ms,omitempty"`
 }
 
 func (x *StatsRequest) Reset() {
 	*x = StatsRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_symo_proto_msgTypes[5]
+		mi := &file_symo_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -390,7 +803,7 @@ func (x *StatsRequest) String() string {
 func (*StatsRequest) ProtoMessage() {}
 
 func (x *StatsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_symo_proto_msgTypes[5]
+	mi := &file_symo_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -403,7 +816,7 @@ func (x *StatsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StatsRequest.ProtoReflect.Descriptor instead.
 func (*StatsRequest) Descriptor() ([]byte, []int) {
-	return file_symo_proto_rawDescGZIP(), []int{5}
+	return file_symo_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *StatsRequest) GetN() int32 {
@@ -420,55 +833,853 @@ func (x *StatsRequest) GetM() int32 {
 	return 0
 }
 
-var File_symo_proto protoreflect.FileDescriptor
+func (x *StatsRequest) GetAggregations() []Aggregation {
+	if x != nil {
+		return x.Aggregations
+	}
+	return nil
+}
 
-var file_symo_proto_rawDesc = []byte{
-	0x0a, 0x0a, 0x73, 0x79, 0x6d, 0x6f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x05, 0x73, 0x74,
-	0x61, 0x74, 0x73, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70,
-	0x72, 0x6f, 0x74, 0x6f, 0x22, 0x4d, 0x0a, 0x07, 0x4c, 0x6f, 0x61, 0x64, 0x41, 0x76, 0x67, 0x12,
-	0x14, 0x0a, 0x05, 0x4c, 0x6f, 0x61, 0x64, 0x31, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05,
-	0x4c, 0x6f, 0x61, 0x64, 0x31, 0x12, 0x14, 0x0a, 0x05, 0x4c, 0x6f, 0x61, 0x64, 0x35, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x4c, 0x6f, 0x61, 0x64, 0x35, 0x12, 0x16, 0x0a, 0x06, 0x4c,
-	0x6f, 0x61, 0x64, 0x31, 0x35, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06, 0x4c, 0x6f, 0x61,
-	0x64, 0x31, 0x35, 0x22, 0x45, 0x0a, 0x03, 0x43, 0x50, 0x55, 0x12, 0x12, 0x0a, 0x04, 0x55, 0x73,
-	0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x55, 0x73, 0x65, 0x72, 0x12, 0x16,
-	0x0a, 0x06, 0x53, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06,
-	0x53, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x12, 0x12, 0x0a, 0x04, 0x49, 0x64, 0x6c, 0x65, 0x18, 0x03,
-	0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x49, 0x64, 0x6c, 0x65, 0x22, 0x62, 0x0a, 0x08, 0x4c, 0x6f,
-	0x61, 0x64, 0x44, 0x69, 0x73, 0x6b, 0x12, 0x12, 0x0a, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x54, 0x70,
-	0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x03, 0x54, 0x70, 0x73, 0x12, 0x16, 0x0a, 0x06,
-	0x4b, 0x42, 0x52, 0x65, 0x61, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06, 0x4b, 0x42,
-	0x52, 0x65, 0x61, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x4b, 0x42, 0x57, 0x72, 0x69, 0x74, 0x65, 0x18,
-	0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x07, 0x4b, 0x42, 0x57, 0x72, 0x69, 0x74, 0x65, 0x22, 0x58,
-	0x0a, 0x06, 0x55, 0x73, 0x65, 0x64, 0x46, 0x53, 0x12, 0x12, 0x0a, 0x04, 0x50, 0x61, 0x74, 0x68,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x50, 0x61, 0x74, 0x68, 0x12, 0x1c, 0x0a, 0x09,
-	0x55, 0x73, 0x65, 0x64, 0x53, 0x70, 0x61, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52,
-	0x09, 0x55, 0x73, 0x65, 0x64, 0x53, 0x70, 0x61, 0x63, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x55, 0x73,
+func (x *StatsRequest) GetPolicy() DropPolicy {
+	if x != nil {
+		return x.Policy
+	}
+	return DropPolicy_POLICY_DEFAULT
+}
+
+func (x *StatsRequest) GetDropLimit() int32 {
+	if x != nil {
+		return x.DropLimit
+	}
+	return 0
+}
+
+func (x *StatsRequest) GetNMs() int32 {
+	if x != nil {
+		return x.NMs
+	}
+	return 0
+}
+
+func (x *StatsRequest) GetMMs() int32 {
+	if x != nil {
+		return x.MMs
+	}
+	return 0
+}
+
+type SnapshotRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	M            int32         `protobuf:"varint,1,opt,name=M,proto3" json:"M,omitempty"`
+	Aggregations []Aggregation `protobuf:"varint,2,rep,packed,name=aggregations,proto3,enum=stats.Aggregation" json:"aggregations,omitempty"`
+	MMs          int32         `protobuf:"varint,3,opt,name=m_ms,json=mMs,proto3" json:"m_ms,omitempty"`
+}
+
+func (x *SnapshotRequest) Reset() {
+	*x = SnapshotRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_symo_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SnapshotRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotRequest) ProtoMessage() {}
+
+func (x *SnapshotRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_symo_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotRequest.ProtoReflect.Descriptor instead.
+func (*SnapshotRequest) Descriptor() ([]byte, []int) {
+	return file_symo_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SnapshotRequest) GetM() int32 {
+	if x != nil {
+		return x.M
+	}
+	return 0
+}
+
+func (x *SnapshotRequest) GetAggregations() []Aggregation {
+	if x != nil {
+		return x.Aggregations
+	}
+	return nil
+}
+
+func (x *SnapshotRequest) GetMMs() int32 {
+	if x != nil {
+		return x.MMs
+	}
+	return 0
+}
+
+type SelfStatsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *SelfStatsRequest) Reset() {
+	*x = SelfStatsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_symo_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SelfStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SelfStatsRequest) ProtoMessage() {}
+
+func (x *SelfStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_symo_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SelfStatsRequest.ProtoReflect.Descriptor instead.
+func (*SelfStatsRequest) Descriptor() ([]byte, []int) {
+	return file_symo_proto_rawDescGZIP(), []int{9}
+}
+
+// опросы коллектора одной метрики с запуска сервера
+type SamplerStats struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Metric       string               `protobuf:"bytes,1,opt,name=metric,proto3" json:"metric,omitempty"`
+	Samples      uint64               `protobuf:"varint,2,opt,name=samples,proto3" json:"samples,omitempty"`
+	Errors       uint64               `protobuf:"varint,3,opt,name=errors,proto3" json:"errors,omitempty"`
+	Timeouts     uint64               `protobuf:"varint,4,opt,name=timeouts,proto3" json:"timeouts,omitempty"`
+	Latency      *durationpb.Duration `protobuf:"bytes,5,opt,name=latency,proto3" json:"latency,omitempty"`
+	LatencyTotal *durationpb.Duration `protobuf:"bytes,6,opt,name=latency_total,json=latencyTotal,proto3" json:"latency_total,omitempty"`
+}
+
+func (x *SamplerStats) Reset() {
+	*x = SamplerStats{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_symo_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SamplerStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SamplerStats) ProtoMessage() {}
+
+func (x *SamplerStats) ProtoReflect() protoreflect.Message {
+	mi := &file_symo_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SamplerStats.ProtoReflect.Descriptor instead.
+func (*SamplerStats) Descriptor() ([]byte, []int) {
+	return file_symo_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SamplerStats) GetMetric() string {
+	if x != nil {
+		return x.Metric
+	}
+	return ""
+}
+
+func (x *SamplerStats) GetSamples() uint64 {
+	if x != nil {
+		return x.Samples
+	}
+	return 0
+}
+
+func (x *SamplerStats) GetErrors() uint64 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+func (x *SamplerStats) GetTimeouts() uint64 {
+	if x != nil {
+		return x.Timeouts
+	}
+	return 0
+}
+
+func (x *SamplerStats) GetLatency() *durationpb.Duration {
+	if x != nil {
+		return x.Latency
+	}
+	return nil
+}
+
+func (x *SamplerStats) GetLatencyTotal() *durationpb.Duration {
+	if x != nil {
+		return x.LatencyTotal
+	}
+	return nil
+}
+
+// число подписанных клиентов и экспортеров с такими N и M
+type ClientInterval struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	N       *durationpb.Duration `protobuf:"bytes,1,opt,name=N,proto3" json:"N,omitempty"`
+	M       *durationpb.Duration `protobuf:"bytes,2,opt,name=M,proto3" json:"M,omitempty"`
+	Clients int32                `protobuf:"varint,3,opt,name=clients,proto3" json:"clients,omitempty"`
+}
+
+func (x *ClientInterval) Reset() {
+	*x = ClientInterval{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_symo_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ClientInterval) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClientInterval) ProtoMessage() {}
+
+func (x *ClientInterval) ProtoReflect() protoreflect.Message {
+	mi := &file_symo_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClientInterval.ProtoReflect.Descriptor instead.
+func (*ClientInterval) Descriptor() ([]byte, []int) {
+	return file_symo_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ClientInterval) GetN() *durationpb.Duration {
+	if x != nil {
+		return x.N
+	}
+	return nil
+}
+
+func (x *ClientInterval) GetM() *durationpb.Duration {
+	if x != nil {
+		return x.M
+	}
+	return nil
+}
+
+func (x *ClientInterval) GetClients() int32 {
+	if x != nil {
+		return x.Clients
+	}
+	return 0
+}
+
+// метрики самого сервера, группа symo
+type SelfStats struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ticks          uint64               `protobuf:"varint,1,opt,name=ticks,proto3" json:"ticks,omitempty"`
+	DroppedTicks   uint64               `protobuf:"varint,2,opt,name=dropped_ticks,json=droppedTicks,proto3" json:"dropped_ticks,omitempty"`
+	TickDuration   *durationpb.Duration `protobuf:"bytes,3,opt,name=tick_duration,json=tickDuration,proto3" json:"tick_duration,omitempty"`
+	TickLag        *durationpb.Duration `protobuf:"bytes,4,opt,name=tick_lag,json=tickLag,proto3" json:"tick_lag,omitempty"`
+	Samplers       []*SamplerStats      `protobuf:"bytes,5,rep,name=samplers,proto3" json:"samplers,omitempty"`
+	Clients        int32                `protobuf:"varint,6,opt,name=clients,proto3" json:"clients,omitempty"`
+	Intervals      []*ClientInterval    `protobuf:"bytes,7,rep,name=intervals,proto3" json:"intervals,omitempty"`
+	DroppedSends   uint64               `protobuf:"varint,8,opt,name=dropped_sends,json=droppedSends,proto3" json:"dropped_sends,omitempty"`
+	SendDuration   *durationpb.Duration `protobuf:"bytes,9,opt,name=send_duration,json=sendDuration,proto3" json:"send_duration,omitempty"`
+	PointsRetained int64                `protobuf:"varint,10,opt,name=points_retained,json=pointsRetained,proto3" json:"points_retained,omitempty"`
+	TicksRetained  int64                `protobuf:"varint,11,opt,name=ticks_retained,json=ticksRetained,proto3" json:"ticks_retained,omitempty"`
+	HeapBytes      uint64               `protobuf:"varint,12,opt,name=heap_bytes,json=heapBytes,proto3" json:"heap_bytes,omitempty"`
+	Goroutines     int32                `protobuf:"varint,13,opt,name=goroutines,proto3" json:"goroutines,omitempty"`
+	DroppedWrites  uint64               `protobuf:"varint,14,opt,name=dropped_writes,json=droppedWrites,proto3" json:"dropped_writes,omitempty"`
+}
+
+func (x *SelfStats) Reset() {
+	*x = SelfStats{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_symo_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SelfStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SelfStats) ProtoMessage() {}
+
+func (x *SelfStats) ProtoReflect() protoreflect.Message {
+	mi := &file_symo_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SelfStats.ProtoReflect.Descriptor instead.
+func (*SelfStats) Descriptor() ([]byte, []int) {
+	return file_symo_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *SelfStats) GetTicks() uint64 {
+	if x != nil {
+		return x.Ticks
+	}
+	return 0
+}
+
+func (x *SelfStats) GetDroppedTicks() uint64 {
+	if x != nil {
+		return x.DroppedTicks
+	}
+	return 0
+}
+
+func (x *SelfStats) GetTickDuration() *durationpb.Duration {
+	if x != nil {
+		return x.TickDuration
+	}
+	return nil
+}
+
+func (x *SelfStats) GetTickLag() *durationpb.Duration {
+	if x != nil {
+		return x.TickLag
+	}
+	return nil
+}
+
+func (x *SelfStats) GetSamplers() []*SamplerStats {
+	if x != nil {
+		return x.Samplers
+	}
+	return nil
+}
+
+func (x *SelfStats) GetClients() int32 {
+	if x != nil {
+		return x.Clients
+	}
+	return 0
+}
+
+func (x *SelfStats) GetIntervals() []*ClientInterval {
+	if x != nil {
+		return x.Intervals
+	}
+	return nil
+}
+
+func (x *SelfStats) GetDroppedSends() uint64 {
+	if x != nil {
+		return x.DroppedSends
+	}
+	return 0
+}
+
+func (x *SelfStats) GetSendDuration() *durationpb.Duration {
+	if x != nil {
+		return x.SendDuration
+	}
+	return nil
+}
+
+func (x *SelfStats) GetPointsRetained() int64 {
+	if x != nil {
+		return x.PointsRetained
+	}
+	return 0
+}
+
+func (x *SelfStats) GetTicksRetained() int64 {
+	if x != nil {
+		return x.TicksRetained
+	}
+	return 0
+}
+
+func (x *SelfStats) GetHeapBytes() uint64 {
+	if x != nil {
+		return x.HeapBytes
+	}
+	return 0
+}
+
+func (x *SelfStats) GetGoroutines() int32 {
+	if x != nil {
+		return x.Goroutines
+	}
+	return 0
+}
+
+func (x *SelfStats) GetDroppedWrites() uint64 {
+	if x != nil {
+		return x.DroppedWrites
+	}
+	return 0
+}
+
+type ExportRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Seconds int32      `protobuf:"varint,1,opt,name=seconds,proto3" json:"seconds,omitempty"`
+	Format  DumpFormat `protobuf:"varint,2,opt,name=format,proto3,enum=stats.DumpFormat" json:"format,omitempty"`
+}
+
+func (x *ExportRequest) Reset() {
+	*x = ExportRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_symo_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportRequest) ProtoMessage() {}
+
+func (x *ExportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_symo_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportRequest.ProtoReflect.Descriptor instead.
+func (*ExportRequest) Descriptor() ([]byte, []int) {
+	return file_symo_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ExportRequest) GetSeconds() int32 {
+	if x != nil {
+		return x.Seconds
+	}
+	return 0
+}
+
+func (x *ExportRequest) GetFormat() DumpFormat {
+	if x != nil {
+		return x.Format
+	}
+	return DumpFormat_DUMP_JSON
+}
+
+type DumpChunk struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (x *DumpChunk) Reset() {
+	*x = DumpChunk{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_symo_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DumpChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DumpChunk) ProtoMessage() {}
+
+func (x *DumpChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_symo_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DumpChunk.ProtoReflect.Descriptor instead.
+func (*DumpChunk) Descriptor() ([]byte, []int) {
+	return file_symo_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *DumpChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type ReloadRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ReloadRequest) Reset() {
+	*x = ReloadRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_symo_proto_msgTypes[15]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReloadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReloadRequest) ProtoMessage() {}
+
+func (x *ReloadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_symo_proto_msgTypes[15]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReloadRequest.ProtoReflect.Descriptor instead.
+func (*ReloadRequest) Descriptor() ([]byte, []int) {
+	return file_symo_proto_rawDescGZIP(), []int{15}
+}
+
+type ReloadResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RestartRequired []string `protobuf:"bytes,1,rep,name=restart_required,json=restartRequired,proto3" json:"restart_required,omitempty"`
+}
+
+func (x *ReloadResponse) Reset() {
+	*x = ReloadResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_symo_proto_msgTypes[16]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ReloadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReloadResponse) ProtoMessage() {}
+
+func (x *ReloadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_symo_proto_msgTypes[16]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReloadResponse.ProtoReflect.Descriptor instead.
+func (*ReloadResponse) Descriptor() ([]byte, []int) {
+	return file_symo_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ReloadResponse) GetRestartRequired() []string {
+	if x != nil {
+		return x.RestartRequired
+	}
+	return nil
+}
+
+var File_symo_proto protoreflect.FileDescriptor
+
+var file_symo_proto_rawDesc = []byte{
+	0x0a, 0x0a, 0x73, 0x79, 0x6d, 0x6f, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x05, 0x73, 0x74,
+	0x61, 0x74, 0x73, 0x1a, 0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x22, 0x4d, 0x0a, 0x07, 0x4c, 0x6f, 0x61, 0x64, 0x41, 0x76, 0x67, 0x12,
+	0x14, 0x0a, 0x05, 0x4c, 0x6f, 0x61, 0x64, 0x31, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05,
+	0x4c, 0x6f, 0x61, 0x64, 0x31, 0x12, 0x14, 0x0a, 0x05, 0x4c, 0x6f, 0x61, 0x64, 0x35, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x4c, 0x6f, 0x61, 0x64, 0x35, 0x12, 0x16, 0x0a, 0x06, 0x4c,
+	0x6f, 0x61, 0x64, 0x31, 0x35, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06, 0x4c, 0x6f, 0x61,
+	0x64, 0x31, 0x35, 0x22, 0x45, 0x0a, 0x03, 0x43, 0x50, 0x55, 0x12, 0x12, 0x0a, 0x04, 0x55, 0x73,
+	0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x55, 0x73, 0x65, 0x72, 0x12, 0x16,
+	0x0a, 0x06, 0x53, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06,
+	0x53, 0x79, 0x73, 0x74, 0x65, 0x6d, 0x12, 0x12, 0x0a, 0x04, 0x49, 0x64, 0x6c, 0x65, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x49, 0x64, 0x6c, 0x65, 0x22, 0x62, 0x0a, 0x08, 0x4c, 0x6f,
+	0x61, 0x64, 0x44, 0x69, 0x73, 0x6b, 0x12, 0x12, 0x0a, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x54, 0x70,
+	0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x03, 0x54, 0x70, 0x73, 0x12, 0x16, 0x0a, 0x06,
+	0x4b, 0x42, 0x52, 0x65, 0x61, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06, 0x4b, 0x42,
+	0x52, 0x65, 0x61, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x4b, 0x42, 0x57, 0x72, 0x69, 0x74, 0x65, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x07, 0x4b, 0x42, 0x57, 0x72, 0x69, 0x74, 0x65, 0x22, 0x58,
+	0x0a, 0x06, 0x55, 0x73, 0x65, 0x64, 0x46, 0x53, 0x12, 0x12, 0x0a, 0x04, 0x50, 0x61, 0x74, 0x68,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x50, 0x61, 0x74, 0x68, 0x12, 0x1c, 0x0a, 0x09,
+	0x55, 0x73, 0x65, 0x64, 0x53, 0x70, 0x61, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x09, 0x55, 0x73, 0x65, 0x64, 0x53, 0x70, 0x61, 0x63, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x55, 0x73,
 	0x65, 0x64, 0x49, 0x6e, 0x6f, 0x64, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x55,
-	0x73, 0x65, 0x64, 0x49, 0x6e, 0x6f, 0x64, 0x65, 0x22, 0xd8, 0x01, 0x0a, 0x05, 0x53, 0x74, 0x61,
-	0x74, 0x73, 0x12, 0x2e, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
-	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x04, 0x74, 0x69,
-	0x6d, 0x65, 0x12, 0x29, 0x0a, 0x08, 0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x61, 0x76, 0x67, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e, 0x4c, 0x6f, 0x61,
-	0x64, 0x41, 0x76, 0x67, 0x52, 0x07, 0x6c, 0x6f, 0x61, 0x64, 0x41, 0x76, 0x67, 0x12, 0x1c, 0x0a,
-	0x03, 0x63, 0x70, 0x75, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x73, 0x74, 0x61,
-	0x74, 0x73, 0x2e, 0x43, 0x50, 0x55, 0x52, 0x03, 0x63, 0x70, 0x75, 0x12, 0x2e, 0x0a, 0x0a, 0x6c,
-	0x6f, 0x61, 0x64, 0x5f, 0x64, 0x69, 0x73, 0x6b, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32,
-	0x0f, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e, 0x4c, 0x6f, 0x61, 0x64, 0x44, 0x69, 0x73, 0x6b,
-	0x52, 0x09, 0x6c, 0x6f, 0x61, 0x64, 0x44, 0x69, 0x73, 0x6b, 0x73, 0x12, 0x26, 0x0a, 0x07, 0x75,
-	0x73, 0x65, 0x64, 0x5f, 0x66, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x73,
-	0x74, 0x61, 0x74, 0x73, 0x2e, 0x55, 0x73, 0x65, 0x64, 0x46, 0x53, 0x52, 0x06, 0x75, 0x73, 0x65,
-	0x64, 0x46, 0x73, 0x22, 0x2a, 0x0a, 0x0c, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75,
-	0x65, 0x73, 0x74, 0x12, 0x0c, 0x0a, 0x01, 0x4e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x01,
-	0x4e, 0x12, 0x0c, 0x0a, 0x01, 0x4d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x01, 0x4d, 0x32,
-	0x39, 0x0a, 0x04, 0x53, 0x79, 0x6d, 0x6f, 0x12, 0x31, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x53, 0x74,
-	0x61, 0x74, 0x73, 0x12, 0x13, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e, 0x53, 0x74, 0x61, 0x74,
-	0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0c, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x73,
-	0x2e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x22, 0x00, 0x30, 0x01, 0x42, 0x08, 0x5a, 0x06, 0x2e, 0x3b,
-	0x67, 0x72, 0x70, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x73, 0x65, 0x64, 0x49, 0x6e, 0x6f, 0x64, 0x65, 0x22, 0x4e, 0x0a, 0x0b, 0x4d, 0x65, 0x74, 0x72,
+	0x69, 0x63, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x25, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x0d, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x18,
+	0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0xe3, 0x01, 0x0a, 0x0a, 0x41, 0x67, 0x67,
+	0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x64, 0x12, 0x34, 0x0a, 0x0b, 0x61, 0x67, 0x67, 0x72, 0x65,
+	0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x12, 0x2e, 0x73,
+	0x74, 0x61, 0x74, 0x73, 0x2e, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x52, 0x0b, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x29, 0x0a,
+	0x08, 0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x61, 0x76, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x0e, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e, 0x4c, 0x6f, 0x61, 0x64, 0x41, 0x76, 0x67, 0x52,
+	0x07, 0x6c, 0x6f, 0x61, 0x64, 0x41, 0x76, 0x67, 0x12, 0x1c, 0x0a, 0x03, 0x63, 0x70, 0x75, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0a, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e, 0x43, 0x50,
+	0x55, 0x52, 0x03, 0x63, 0x70, 0x75, 0x12, 0x2e, 0x0a, 0x0a, 0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x64,
+	0x69, 0x73, 0x6b, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x73, 0x74, 0x61,
+	0x74, 0x73, 0x2e, 0x4c, 0x6f, 0x61, 0x64, 0x44, 0x69, 0x73, 0x6b, 0x52, 0x09, 0x6c, 0x6f, 0x61,
+	0x64, 0x44, 0x69, 0x73, 0x6b, 0x73, 0x12, 0x26, 0x0a, 0x07, 0x75, 0x73, 0x65, 0x64, 0x5f, 0x66,
+	0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e,
+	0x55, 0x73, 0x65, 0x64, 0x46, 0x53, 0x52, 0x06, 0x75, 0x73, 0x65, 0x64, 0x46, 0x73, 0x22, 0x98,
+	0x04, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x2e, 0x0a, 0x04, 0x74, 0x69, 0x6d, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x52, 0x04, 0x74, 0x69, 0x6d, 0x65, 0x12, 0x29, 0x0a, 0x08, 0x6c, 0x6f, 0x61, 0x64,
+	0x5f, 0x61, 0x76, 0x67, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x73, 0x74, 0x61,
+	0x74, 0x73, 0x2e, 0x4c, 0x6f, 0x61, 0x64, 0x41, 0x76, 0x67, 0x52, 0x07, 0x6c, 0x6f, 0x61, 0x64,
+	0x41, 0x76, 0x67, 0x12, 0x1c, 0x0a, 0x03, 0x63, 0x70, 0x75, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x0a, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e, 0x43, 0x50, 0x55, 0x52, 0x03, 0x63, 0x70,
+	0x75, 0x12, 0x2e, 0x0a, 0x0a, 0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x64, 0x69, 0x73, 0x6b, 0x73, 0x18,
+	0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e, 0x4c, 0x6f,
+	0x61, 0x64, 0x44, 0x69, 0x73, 0x6b, 0x52, 0x09, 0x6c, 0x6f, 0x61, 0x64, 0x44, 0x69, 0x73, 0x6b,
+	0x73, 0x12, 0x26, 0x0a, 0x07, 0x75, 0x73, 0x65, 0x64, 0x5f, 0x66, 0x73, 0x18, 0x05, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e, 0x55, 0x73, 0x65, 0x64, 0x46,
+	0x53, 0x52, 0x06, 0x75, 0x73, 0x65, 0x64, 0x46, 0x73, 0x12, 0x38, 0x0a, 0x0e, 0x6c, 0x6f, 0x61,
+	0x64, 0x5f, 0x61, 0x76, 0x67, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x12, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63,
+	0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x0c, 0x6c, 0x6f, 0x61, 0x64, 0x41, 0x76, 0x67, 0x53, 0x74,
+	0x61, 0x74, 0x65, 0x12, 0x2f, 0x0a, 0x09, 0x63, 0x70, 0x75, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x65,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e, 0x4d,
+	0x65, 0x74, 0x72, 0x69, 0x63, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x08, 0x63, 0x70, 0x75, 0x53,
+	0x74, 0x61, 0x74, 0x65, 0x12, 0x3c, 0x0a, 0x10, 0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x64, 0x69, 0x73,
+	0x6b, 0x73, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12,
+	0x2e, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x53, 0x74, 0x61,
+	0x74, 0x65, 0x52, 0x0e, 0x6c, 0x6f, 0x61, 0x64, 0x44, 0x69, 0x73, 0x6b, 0x73, 0x53, 0x74, 0x61,
+	0x74, 0x65, 0x12, 0x36, 0x0a, 0x0d, 0x75, 0x73, 0x65, 0x64, 0x5f, 0x66, 0x73, 0x5f, 0x73, 0x74,
+	0x61, 0x74, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x73, 0x74, 0x61, 0x74,
+	0x73, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x0b, 0x75,
+	0x73, 0x65, 0x64, 0x46, 0x73, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x31, 0x0a, 0x0a, 0x61, 0x67,
+	0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x64, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11,
+	0x2e, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65,
+	0x64, 0x52, 0x0a, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x65, 0x64, 0x12, 0x10, 0x0a,
+	0x03, 0x73, 0x65, 0x71, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x73, 0x65, 0x71, 0x12,
+	0x18, 0x0a, 0x07, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x07, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x22, 0xd2, 0x01, 0x0a, 0x0c, 0x53, 0x74,
+	0x61, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0c, 0x0a, 0x01, 0x4e, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x01, 0x4e, 0x12, 0x0c, 0x0a, 0x01, 0x4d, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x05, 0x52, 0x01, 0x4d, 0x12, 0x36, 0x0a, 0x0c, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0e, 0x32, 0x12, 0x2e, 0x73,
+	0x74, 0x61, 0x74, 0x73, 0x2e, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x52, 0x0c, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x29,
+	0x0a, 0x06, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11,
+	0x2e, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e, 0x44, 0x72, 0x6f, 0x70, 0x50, 0x6f, 0x6c, 0x69, 0x63,
+	0x79, 0x52, 0x06, 0x70, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x1d, 0x0a, 0x0a, 0x64, 0x72, 0x6f,
+	0x70, 0x5f, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x09, 0x64,
+	0x72, 0x6f, 0x70, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x11, 0x0a, 0x04, 0x6e, 0x5f, 0x6d, 0x73,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x6e, 0x4d, 0x73, 0x12, 0x11, 0x0a, 0x04, 0x6d,
+	0x5f, 0x6d, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x6d, 0x4d, 0x73, 0x22, 0x6a,
+	0x0a, 0x0f, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x0c, 0x0a, 0x01, 0x4d, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x01, 0x4d, 0x12,
+	0x36, 0x0a, 0x0c, 0x61, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18,
+	0x02, 0x20, 0x03, 0x28, 0x0e, 0x32, 0x12, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e, 0x41, 0x67,
+	0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0c, 0x61, 0x67, 0x67, 0x72, 0x65,
+	0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x11, 0x0a, 0x04, 0x6d, 0x5f, 0x6d, 0x73, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x6d, 0x4d, 0x73, 0x22, 0x12, 0x0a, 0x10, 0x53, 0x65,
+	0x6c, 0x66, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0xe9,
+	0x01, 0x0a, 0x0c, 0x53, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12,
+	0x16, 0x0a, 0x06, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x6d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x61, 0x6d, 0x70, 0x6c,
+	0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65,
+	0x73, 0x12, 0x16, 0x0a, 0x06, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x04, 0x52, 0x06, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x12, 0x1a, 0x0a, 0x08, 0x74, 0x69, 0x6d,
+	0x65, 0x6f, 0x75, 0x74, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x74, 0x69, 0x6d,
+	0x65, 0x6f, 0x75, 0x74, 0x73, 0x12, 0x33, 0x0a, 0x07, 0x6c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79,
+	0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x52, 0x07, 0x6c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x3e, 0x0a, 0x0d, 0x6c, 0x61,
+	0x74, 0x65, 0x6e, 0x63, 0x79, 0x5f, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x18, 0x06, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0c, 0x6c, 0x61,
+	0x74, 0x65, 0x6e, 0x63, 0x79, 0x54, 0x6f, 0x74, 0x61, 0x6c, 0x22, 0x7c, 0x0a, 0x0e, 0x43, 0x6c,
+	0x69, 0x65, 0x6e, 0x74, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x12, 0x27, 0x0a, 0x01,
+	0x4e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x52, 0x01, 0x4e, 0x12, 0x27, 0x0a, 0x01, 0x4d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x01, 0x4d, 0x12, 0x18,
+	0x0a, 0x07, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x07, 0x63, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x73, 0x22, 0xd7, 0x04, 0x0a, 0x09, 0x53, 0x65, 0x6c,
+	0x66, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x69, 0x63, 0x6b, 0x73, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x74, 0x69, 0x63, 0x6b, 0x73, 0x12, 0x23, 0x0a, 0x0d,
+	0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x5f, 0x74, 0x69, 0x63, 0x6b, 0x73, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x0c, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x54, 0x69, 0x63, 0x6b,
+	0x73, 0x12, 0x3e, 0x0a, 0x0d, 0x74, 0x69, 0x63, 0x6b, 0x5f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x52, 0x0c, 0x74, 0x69, 0x63, 0x6b, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x12, 0x34, 0x0a, 0x08, 0x74, 0x69, 0x63, 0x6b, 0x5f, 0x6c, 0x61, 0x67, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x07,
+	0x74, 0x69, 0x63, 0x6b, 0x4c, 0x61, 0x67, 0x12, 0x2f, 0x0a, 0x08, 0x73, 0x61, 0x6d, 0x70, 0x6c,
+	0x65, 0x72, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x73, 0x74, 0x61, 0x74,
+	0x73, 0x2e, 0x53, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x72, 0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x08,
+	0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x72, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6c, 0x69, 0x65,
+	0x6e, 0x74, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x63, 0x6c, 0x69, 0x65, 0x6e,
+	0x74, 0x73, 0x12, 0x33, 0x0a, 0x09, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x73, 0x18,
+	0x07, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e, 0x43, 0x6c,
+	0x69, 0x65, 0x6e, 0x74, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x52, 0x09, 0x69, 0x6e,
+	0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x73, 0x12, 0x23, 0x0a, 0x0d, 0x64, 0x72, 0x6f, 0x70, 0x70,
+	0x65, 0x64, 0x5f, 0x73, 0x65, 0x6e, 0x64, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0c,
+	0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x53, 0x65, 0x6e, 0x64, 0x73, 0x12, 0x3e, 0x0a, 0x0d,
+	0x73, 0x65, 0x6e, 0x64, 0x5f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x09, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x0c,
+	0x73, 0x65, 0x6e, 0x64, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x27, 0x0a, 0x0f,
+	0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x5f, 0x72, 0x65, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x64, 0x18,
+	0x0a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x70, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x74,
+	0x61, 0x69, 0x6e, 0x65, 0x64, 0x12, 0x25, 0x0a, 0x0e, 0x74, 0x69, 0x63, 0x6b, 0x73, 0x5f, 0x72,
+	0x65, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x64, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x74,
+	0x69, 0x63, 0x6b, 0x73, 0x52, 0x65, 0x74, 0x61, 0x69, 0x6e, 0x65, 0x64, 0x12, 0x1d, 0x0a, 0x0a,
+	0x68, 0x65, 0x61, 0x70, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x09, 0x68, 0x65, 0x61, 0x70, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x67,
+	0x6f, 0x72, 0x6f, 0x75, 0x74, 0x69, 0x6e, 0x65, 0x73, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x0a, 0x67, 0x6f, 0x72, 0x6f, 0x75, 0x74, 0x69, 0x6e, 0x65, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x64,
+	0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x5f, 0x77, 0x72, 0x69, 0x74, 0x65, 0x73, 0x18, 0x0e, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x0d, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x57, 0x72, 0x69, 0x74,
+	0x65, 0x73, 0x22, 0x54, 0x0a, 0x0d, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x73, 0x12, 0x29, 0x0a,
+	0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x11, 0x2e,
+	0x73, 0x74, 0x61, 0x74, 0x73, 0x2e, 0x44, 0x75, 0x6d, 0x70, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74,
+	0x52, 0x06, 0x66, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x22, 0x1f, 0x0a, 0x09, 0x44, 0x75, 0x6d, 0x70,
+	0x43, 0x68, 0x75, 0x6e, 0x6b, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0x0f, 0x0a, 0x0d, 0x52, 0x65, 0x6c,
+	0x6f, 0x61, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x3b, 0x0a, 0x0e, 0x52, 0x65,
+	0x6c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x29, 0x0a, 0x10,
+	0x72, 0x65, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x72, 0x65, 0x71, 0x75, 0x69, 0x72, 0x65, 0x64,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0f, 0x72, 0x65, 0x73, 0x74, 0x61, 0x72, 0x74, 0x52,
+	0x65, 0x71, 0x75, 0x69, 0x72, 0x65, 0x64, 0x2a, 0x64, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75,
+	0x73, 0x12, 0x12, 0x0a, 0x0e, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x55, 0x4e, 0x4b, 0x4e,
+	0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x10, 0x0a, 0x0c, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f,
+	0x53, 0x54, 0x41, 0x4c, 0x45, 0x10, 0x01, 0x12, 0x10, 0x0a, 0x0c, 0x53, 0x54, 0x41, 0x54, 0x55,
+	0x53, 0x5f, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x02, 0x12, 0x13, 0x0a, 0x0f, 0x53, 0x54, 0x41,
+	0x54, 0x55, 0x53, 0x5f, 0x44, 0x49, 0x53, 0x41, 0x42, 0x4c, 0x45, 0x44, 0x10, 0x03, 0x12, 0x0d,
+	0x0a, 0x09, 0x53, 0x54, 0x41, 0x54, 0x55, 0x53, 0x5f, 0x4f, 0x4b, 0x10, 0x04, 0x2a, 0x6a, 0x0a,
+	0x0b, 0x41, 0x67, 0x67, 0x72, 0x65, 0x67, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x0c, 0x0a, 0x08,
+	0x41, 0x47, 0x47, 0x5f, 0x4d, 0x45, 0x41, 0x4e, 0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x41, 0x47,
+	0x47, 0x5f, 0x4d, 0x49, 0x4e, 0x10, 0x01, 0x12, 0x0b, 0x0a, 0x07, 0x41, 0x47, 0x47, 0x5f, 0x4d,
+	0x41, 0x58, 0x10, 0x02, 0x12, 0x0b, 0x0a, 0x07, 0x41, 0x47, 0x47, 0x5f, 0x50, 0x35, 0x30, 0x10,
+	0x03, 0x12, 0x0b, 0x0a, 0x07, 0x41, 0x47, 0x47, 0x5f, 0x50, 0x39, 0x35, 0x10, 0x04, 0x12, 0x0b,
+	0x0a, 0x07, 0x41, 0x47, 0x47, 0x5f, 0x50, 0x39, 0x39, 0x10, 0x05, 0x12, 0x0c, 0x0a, 0x08, 0x41,
+	0x47, 0x47, 0x5f, 0x4c, 0x41, 0x53, 0x54, 0x10, 0x06, 0x2a, 0x67, 0x0a, 0x0a, 0x44, 0x72, 0x6f,
+	0x70, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x12, 0x12, 0x0a, 0x0e, 0x50, 0x4f, 0x4c, 0x49, 0x43,
+	0x59, 0x5f, 0x44, 0x45, 0x46, 0x41, 0x55, 0x4c, 0x54, 0x10, 0x00, 0x12, 0x16, 0x0a, 0x12, 0x50,
+	0x4f, 0x4c, 0x49, 0x43, 0x59, 0x5f, 0x44, 0x52, 0x4f, 0x50, 0x5f, 0x4e, 0x45, 0x57, 0x45, 0x53,
+	0x54, 0x10, 0x01, 0x12, 0x16, 0x0a, 0x12, 0x50, 0x4f, 0x4c, 0x49, 0x43, 0x59, 0x5f, 0x44, 0x52,
+	0x4f, 0x50, 0x5f, 0x4f, 0x4c, 0x44, 0x45, 0x53, 0x54, 0x10, 0x02, 0x12, 0x15, 0x0a, 0x11, 0x50,
+	0x4f, 0x4c, 0x49, 0x43, 0x59, 0x5f, 0x44, 0x49, 0x53, 0x43, 0x4f, 0x4e, 0x4e, 0x45, 0x43, 0x54,
+	0x10, 0x03, 0x2a, 0x29, 0x0a, 0x0a, 0x44, 0x75, 0x6d, 0x70, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74,
+	0x12, 0x0d, 0x0a, 0x09, 0x44, 0x55, 0x4d, 0x50, 0x5f, 0x4a, 0x53, 0x4f, 0x4e, 0x10, 0x00, 0x12,
+	0x0c, 0x0a, 0x08, 0x44, 0x55, 0x4d, 0x50, 0x5f, 0x43, 0x53, 0x56, 0x10, 0x01, 0x32, 0xad, 0x01,
+	0x0a, 0x04, 0x53, 0x79, 0x6d, 0x6f, 0x12, 0x31, 0x0a, 0x08, 0x47, 0x65, 0x74, 0x53, 0x74, 0x61,
+	0x74, 0x73, 0x12, 0x13, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0c, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e,
+	0x53, 0x74, 0x61, 0x74, 0x73, 0x22, 0x00, 0x30, 0x01, 0x12, 0x35, 0x0a, 0x0b, 0x47, 0x65, 0x74,
+	0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x12, 0x16, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x73,
+	0x2e, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x0c, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x73, 0x22, 0x00,
+	0x12, 0x3b, 0x0a, 0x0c, 0x47, 0x65, 0x74, 0x53, 0x65, 0x6c, 0x66, 0x53, 0x74, 0x61, 0x74, 0x73,
+	0x12, 0x17, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e, 0x53, 0x65, 0x6c, 0x66, 0x53, 0x74, 0x61,
+	0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x10, 0x2e, 0x73, 0x74, 0x61, 0x74,
+	0x73, 0x2e, 0x53, 0x65, 0x6c, 0x66, 0x53, 0x74, 0x61, 0x74, 0x73, 0x22, 0x00, 0x32, 0x82, 0x01,
+	0x0a, 0x05, 0x41, 0x64, 0x6d, 0x69, 0x6e, 0x12, 0x3a, 0x0a, 0x0c, 0x45, 0x78, 0x70, 0x6f, 0x72,
+	0x74, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x12, 0x14, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e,
+	0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x10, 0x2e,
+	0x73, 0x74, 0x61, 0x74, 0x73, 0x2e, 0x44, 0x75, 0x6d, 0x70, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x22,
+	0x00, 0x30, 0x01, 0x12, 0x3d, 0x0a, 0x0c, 0x52, 0x65, 0x6c, 0x6f, 0x61, 0x64, 0x43, 0x6f, 0x6e,
+	0x66, 0x69, 0x67, 0x12, 0x14, 0x2e, 0x73, 0x74, 0x61, 0x74, 0x73, 0x2e, 0x52, 0x65, 0x6c, 0x6f,
+	0x61, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x15, 0x2e, 0x73, 0x74, 0x61, 0x74,
+	0x73, 0x2e, 0x52, 0x65, 0x6c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x22, 0x00, 0x42, 0x08, 0x5a, 0x06, 0x2e, 0x3b, 0x67, 0x72, 0x70, 0x63, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -483,29 +1694,78 @@ func file_symo_proto_rawDescGZIP() []byte {
 	return file_symo_proto_rawDescData
 }
 
-var file_symo_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_symo_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
+var file_symo_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
 var file_symo_proto_goTypes = []interface{}{
-	(*LoadAvg)(nil),               // 0: stats.LoadAvg
-	(*CPU)(nil),                   // 1: stats.CPU
-	(*LoadDisk)(nil),              // 2: stats.LoadDisk
-	(*UsedFS)(nil),                // 3: stats.UsedFS
-	(*Stats)(nil),                 // 4: stats.Stats
-	(*StatsRequest)(nil),          // 5: stats.StatsRequest
-	(*timestamppb.Timestamp)(nil), // 6: google.protobuf.Timestamp
+	(Status)(0),                   // 0: stats.Status
+	(Aggregation)(0),              // 1: stats.Aggregation
+	(DropPolicy)(0),               // 2: stats.DropPolicy
+	(DumpFormat)(0),               // 3: stats.DumpFormat
+	(*LoadAvg)(nil),               // 4: stats.LoadAvg
+	(*CPU)(nil),                   // 5: stats.CPU
+	(*LoadDisk)(nil),              // 6: stats.LoadDisk
+	(*UsedFS)(nil),                // 7: stats.UsedFS
+	(*MetricState)(nil),           // 8: stats.MetricState
+	(*Aggregated)(nil),            // 9: stats.Aggregated
+	(*Stats)(nil),                 // 10: stats.Stats
+	(*StatsRequest)(nil),          // 11: stats.StatsRequest
+	(*SnapshotRequest)(nil),       // 12: stats.SnapshotRequest
+	(*SelfStatsRequest)(nil),      // 13: stats.SelfStatsRequest
+	(*SamplerStats)(nil),          // 14: stats.SamplerStats
+	(*ClientInterval)(nil),        // 15: stats.ClientInterval
+	(*SelfStats)(nil),             // 16: stats.SelfStats
+	(*ExportRequest)(nil),         // 17: stats.ExportRequest
+	(*DumpChunk)(nil),             // 18: stats.DumpChunk
+	(*ReloadRequest)(nil),         // 19: stats.ReloadRequest
+	(*ReloadResponse)(nil),        // 20: stats.ReloadResponse
+	(*timestamppb.Timestamp)(nil), // 21: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),   // 22: google.protobuf.Duration
 }
 var file_symo_proto_depIdxs = []int32{
-	6, // 0: stats.Stats.time:type_name -> google.protobuf.Timestamp
-	0, // 1: stats.Stats.load_avg:type_name -> stats.LoadAvg
-	1, // 2: stats.Stats.cpu:type_name -> stats.CPU
-	2, // 3: stats.Stats.load_disks:type_name -> stats.LoadDisk
-	3, // 4: stats.Stats.used_fs:type_name -> stats.UsedFS
-	5, // 5: stats.Symo.GetStats:input_type -> stats.StatsRequest
-	4, // 6: stats.Symo.GetStats:output_type -> stats.Stats
-	6, // [6:7] is the sub-list for method output_type
-	5, // [5:6] is the sub-list for method input_type
-	5, // [5:5] is the sub-list for extension type_name
-	5, // [5:5] is the sub-list for extension extendee
-	0, // [0:5] is the sub-list for field type_name
+	0,  // 0: stats.MetricState.status:type_name -> stats.Status
+	1,  // 1: stats.Aggregated.aggregation:type_name -> stats.Aggregation
+	4,  // 2: stats.Aggregated.load_avg:type_name -> stats.LoadAvg
+	5,  // 3: stats.Aggregated.cpu:type_name -> stats.CPU
+	6,  // 4: stats.Aggregated.load_disks:type_name -> stats.LoadDisk
+	7,  // 5: stats.Aggregated.used_fs:type_name -> stats.UsedFS
+	21, // 6: stats.Stats.time:type_name -> google.protobuf.Timestamp
+	4,  // 7: stats.Stats.load_avg:type_name -> stats.LoadAvg
+	5,  // 8: stats.Stats.cpu:type_name -> stats.CPU
+	6,  // 9: stats.Stats.load_disks:type_name -> stats.LoadDisk
+	7,  // 10: stats.Stats.used_fs:type_name -> stats.UsedFS
+	8,  // 11: stats.Stats.load_avg_state:type_name -> stats.MetricState
+	8,  // 12: stats.Stats.cpu_state:type_name -> stats.MetricState
+	8,  // 13: stats.Stats.load_disks_state:type_name -> stats.MetricState
+	8,  // 14: stats.Stats.used_fs_state:type_name -> stats.MetricState
+	9,  // 15: stats.Stats.aggregated:type_name -> stats.Aggregated
+	1,  // 16: stats.StatsRequest.aggregations:type_name -> stats.Aggregation
+	2,  // 17: stats.StatsRequest.policy:type_name -> stats.DropPolicy
+	1,  // 18: stats.SnapshotRequest.aggregations:type_name -> stats.Aggregation
+	22, // 19: stats.SamplerStats.latency:type_name -> google.protobuf.Duration
+	22, // 20: stats.SamplerStats.latency_total:type_name -> google.protobuf.Duration
+	22, // 21: stats.ClientInterval.N:type_name -> google.protobuf.Duration
+	22, // 22: stats.ClientInterval.M:type_name -> google.protobuf.Duration
+	22, // 23: stats.SelfStats.tick_duration:type_name -> google.protobuf.Duration
+	22, // 24: stats.SelfStats.tick_lag:type_name -> google.protobuf.Duration
+	14, // 25: stats.SelfStats.samplers:type_name -> stats.SamplerStats
+	15, // 26: stats.SelfStats.intervals:type_name -> stats.ClientInterval
+	22, // 27: stats.SelfStats.send_duration:type_name -> google.protobuf.Duration
+	3,  // 28: stats.ExportRequest.format:type_name -> stats.DumpFormat
+	11, // 29: stats.Symo.GetStats:input_type -> stats.StatsRequest
+	12, // 30: stats.Symo.GetSnapshot:input_type -> stats.SnapshotRequest
+	13, // 31: stats.Symo.GetSelfStats:input_type -> stats.SelfStatsRequest
+	17, // 32: stats.Admin.ExportPoints:input_type -> stats.ExportRequest
+	19, // 33: stats.Admin.ReloadConfig:input_type -> stats.ReloadRequest
+	10, // 34: stats.Symo.GetStats:output_type -> stats.Stats
+	10, // 35: stats.Symo.GetSnapshot:output_type -> stats.Stats
+	16, // 36: stats.Symo.GetSelfStats:output_type -> stats.SelfStats
+	18, // 37: stats.Admin.ExportPoints:output_type -> stats.DumpChunk
+	20, // 38: stats.Admin.ReloadConfig:output_type -> stats.ReloadResponse
+	34, // [34:39] is the sub-list for method output_type
+	29, // [29:34] is the sub-list for method input_type
+	29, // [29:29] is the sub-list for extension type_name
+	29, // [29:29] is the sub-list for extension extendee
+	0,  // [0:29] is the sub-list for field type_name
 }
 
 func init() { file_symo_proto_init() }
@@ -563,7 +1823,7 @@ func file_symo_proto_init() {
 			}
 		}
 		file_symo_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*Stats); i {
+			switch v := v.(*MetricState); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -575,6 +1835,30 @@ func file_symo_proto_init() {
 			}
 		}
 		file_symo_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Aggregated); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_symo_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Stats); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_symo_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*StatsRequest); i {
 			case 0:
 				return &v.state
@@ -586,19 +1870,128 @@ func file_symo_proto_init() {
 				return nil
 			}
 		}
+		file_symo_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SnapshotRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_symo_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SelfStatsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_symo_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SamplerStats); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_symo_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ClientInterval); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_symo_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SelfStats); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_symo_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ExportRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_symo_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DumpChunk); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_symo_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReloadRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_symo_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ReloadResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_symo_proto_rawDesc,
-			NumEnums:      0,
-			NumMessages:   6,
+			NumEnums:      4,
+			NumMessages:   17,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   2,
 		},
 		GoTypes:           file_symo_proto_goTypes,
 		DependencyIndexes: file_symo_proto_depIdxs,
+		EnumInfos:         file_symo_proto_enumTypes,
 		MessageInfos:      file_symo_proto_msgTypes,
 	}.Build()
 	File_symo_proto = out.File
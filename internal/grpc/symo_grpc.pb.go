@@ -18,6 +18,8 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type SymoClient interface {
 	GetStats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (Symo_GetStatsClient, error)
+	GetSnapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (*Stats, error)
+	GetSelfStats(ctx context.Context, in *SelfStatsRequest, opts ...grpc.CallOption) (*SelfStats, error)
 }
 
 type symoClient struct {
@@ -60,11 +62,31 @@ func (x *symoGetStatsClient) Recv() (*Stats, error) {
 	return m, nil
 }
 
+func (c *symoClient) GetSnapshot(ctx context.Context, in *SnapshotRequest, opts ...grpc.CallOption) (*Stats, error) {
+	out := new(Stats)
+	err := c.cc.Invoke(ctx, "/stats.Symo/GetSnapshot", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *symoClient) GetSelfStats(ctx context.Context, in *SelfStatsRequest, opts ...grpc.CallOption) (*SelfStats, error) {
+	out := new(SelfStats)
+	err := c.cc.Invoke(ctx, "/stats.Symo/GetSelfStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SymoServer is the server API for Symo service.
 // All implementations must embed UnimplementedSymoServer
 // for forward compatibility
 type SymoServer interface {
 	GetStats(*StatsRequest, Symo_GetStatsServer) error
+	GetSnapshot(context.Context, *SnapshotRequest) (*Stats, error)
+	GetSelfStats(context.Context, *SelfStatsRequest) (*SelfStats, error)
 	mustEmbedUnimplementedSymoServer()
 }
 
@@ -75,6 +97,12 @@ type UnimplementedSymoServer struct {
 func (UnimplementedSymoServer) GetStats(*StatsRequest, Symo_GetStatsServer) error {
 	return status.Errorf(codes.Unimplemented, "method GetStats not implemented")
 }
+func (UnimplementedSymoServer) GetSnapshot(context.Context, *SnapshotRequest) (*Stats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSnapshot not implemented")
+}
+func (UnimplementedSymoServer) GetSelfStats(context.Context, *SelfStatsRequest) (*SelfStats, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSelfStats not implemented")
+}
 func (UnimplementedSymoServer) mustEmbedUnimplementedSymoServer() {}
 
 // UnsafeSymoServer may be embedded to opt out of forward compatibility for this service.
@@ -109,10 +137,55 @@ func (x *symoGetStatsServer) Send(m *Stats) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _Symo_GetSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SymoServer).GetSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/stats.Symo/GetSnapshot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SymoServer).GetSnapshot(ctx, req.(*SnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Symo_GetSelfStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SelfStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SymoServer).GetSelfStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/stats.Symo/GetSelfStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SymoServer).GetSelfStats(ctx, req.(*SelfStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Symo_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "stats.Symo",
 	HandlerType: (*SymoServer)(nil),
-	Methods:     []grpc.MethodDesc{},
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetSnapshot",
+			Handler:    _Symo_GetSnapshot_Handler,
+		},
+		{
+			MethodName: "GetSelfStats",
+			Handler:    _Symo_GetSelfStats_Handler,
+		},
+	},
 	Streams: []grpc.StreamDesc{
 		{
 			StreamName:    "GetStats",
@@ -122,3 +195,150 @@ var _Symo_serviceDesc = grpc.ServiceDesc{
 	},
 	Metadata: "symo.proto",
 }
+
+// AdminClient is the client API for Admin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AdminClient interface {
+	ExportPoints(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (Admin_ExportPointsClient, error)
+	ReloadConfig(ctx context.Context, in *ReloadRequest, opts ...grpc.CallOption) (*ReloadResponse, error)
+}
+
+type adminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminClient(cc grpc.ClientConnInterface) AdminClient {
+	return &adminClient{cc}
+}
+
+func (c *adminClient) ExportPoints(ctx context.Context, in *ExportRequest, opts ...grpc.CallOption) (Admin_ExportPointsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Admin_serviceDesc.Streams[0], "/stats.Admin/ExportPoints", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &adminExportPointsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Admin_ExportPointsClient interface {
+	Recv() (*DumpChunk, error)
+	grpc.ClientStream
+}
+
+type adminExportPointsClient struct {
+	grpc.ClientStream
+}
+
+func (x *adminExportPointsClient) Recv() (*DumpChunk, error) {
+	m := new(DumpChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *adminClient) ReloadConfig(ctx context.Context, in *ReloadRequest, opts ...grpc.CallOption) (*ReloadResponse, error) {
+	out := new(ReloadResponse)
+	err := c.cc.Invoke(ctx, "/stats.Admin/ReloadConfig", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServer is the server API for Admin service.
+// All implementations must embed UnimplementedAdminServer
+// for forward compatibility
+type AdminServer interface {
+	ExportPoints(*ExportRequest, Admin_ExportPointsServer) error
+	ReloadConfig(context.Context, *ReloadRequest) (*ReloadResponse, error)
+	mustEmbedUnimplementedAdminServer()
+}
+
+// UnimplementedAdminServer must be embedded to have forward compatible implementations.
+type UnimplementedAdminServer struct {
+}
+
+func (UnimplementedAdminServer) ExportPoints(*ExportRequest, Admin_ExportPointsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportPoints not implemented")
+}
+func (UnimplementedAdminServer) ReloadConfig(context.Context, *ReloadRequest) (*ReloadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReloadConfig not implemented")
+}
+func (UnimplementedAdminServer) mustEmbedUnimplementedAdminServer() {}
+
+// UnsafeAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServer will
+// result in compilation errors.
+type UnsafeAdminServer interface {
+	mustEmbedUnimplementedAdminServer()
+}
+
+func RegisterAdminServer(s grpc.ServiceRegistrar, srv AdminServer) {
+	s.RegisterService(&_Admin_serviceDesc, srv)
+}
+
+func _Admin_ExportPoints_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdminServer).ExportPoints(m, &adminExportPointsServer{stream})
+}
+
+type Admin_ExportPointsServer interface {
+	Send(*DumpChunk) error
+	grpc.ServerStream
+}
+
+type adminExportPointsServer struct {
+	grpc.ServerStream
+}
+
+func (x *adminExportPointsServer) Send(m *DumpChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Admin_ReloadConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReloadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ReloadConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/stats.Admin/ReloadConfig",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ReloadConfig(ctx, req.(*ReloadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Admin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "stats.Admin",
+	HandlerType: (*AdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ReloadConfig",
+			Handler:    _Admin_ReloadConfig_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportPoints",
+			Handler:       _Admin_ExportPoints_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "symo.proto",
+}
@@ -0,0 +1,166 @@
+package grpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+// certReloader отдает TLS конфигурацию сервера и перечитывает сертификаты, когда меняются их файлы.
+// Изменения проверяются при каждом новом подключении. Если новые файлы не читаются, остаются прежние сертификаты.
+type certReloader struct {
+	mutex   sync.Mutex
+	conf    symo.TLSConf
+	log     symo.Logger
+	modTime map[string]time.Time
+	tls     *tls.Config
+}
+
+func newCertReloader(log symo.Logger, conf symo.TLSConf) (*certReloader, error) {
+	r := &certReloader{
+		conf: conf,
+		log:  log,
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certReloader) serverConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetConfigForClient: r.getConfigForClient,
+	}
+}
+
+func (r *certReloader) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.changed() {
+		if err := r.load(); err != nil {
+			r.log.Error(fmt.Errorf("unable to reload tls certificates: %w", err))
+		} else {
+			r.log.Info("tls certificates are reloaded")
+		}
+	}
+	return r.tls, nil
+}
+
+func (r *certReloader) files() []string {
+	files := []string{r.conf.Cert, r.conf.Key}
+	if r.conf.ClientCA != "" {
+		files = append(files, r.conf.ClientCA)
+	}
+	return files
+}
+
+func (r *certReloader) changed() bool {
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if !info.ModTime().Equal(r.modTime[file]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *certReloader) load() error {
+	// время изменения берется до чтения, чтобы не пропустить запись файла во время загрузки, а запоминается
+	// только после успешной загрузки, чтобы недописанные файлы перечитывались при следующем подключении
+	modTime := make(map[string]time.Time)
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		modTime[file] = info.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.conf.Cert, r.conf.Key)
+	if err != nil {
+		return err
+	}
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}
+
+	if r.conf.ClientCA != "" {
+		pool, err := loadCertPool(r.conf.ClientCA)
+		if err != nil {
+			return err
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	r.tls = config
+	r.modTime = modTime
+	return nil
+}
+
+func loadCertPool(file string) (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in %s", file)
+	}
+	return pool, nil
+}
+
+// ClientTLS содержит настройки TLS клиента gRPC сервера.
+type ClientTLS struct {
+	Enabled    bool   // подключаться по TLS. Включается и заданием любого из файлов
+	CA         string // сертификат CA, которым подписан сертификат сервера. Если пуст, используются системные
+	Cert       string // сертификат клиента для mTLS
+	Key        string // закрытый ключ клиента для mTLS
+	ServerName string // имя сервера для проверки сертификата, если отличается от адреса
+}
+
+// DialOption возвращает параметр подключения к серверу: TLS или без шифрования.
+func (c ClientTLS) DialOption() (grpc.DialOption, error) {
+	if !c.Enabled && c.CA == "" && c.Cert == "" {
+		return grpc.WithInsecure(), nil
+	}
+	if (c.Cert == "") != (c.Key == "") {
+		return nil, errors.New("tls cert and key must be set together")
+	}
+
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: c.ServerName,
+	}
+	if c.CA != "" {
+		pool, err := loadCertPool(c.CA)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = pool
+	}
+	if c.Cert != "" {
+		cert, err := tls.LoadX509KeyPair(c.Cert, c.Key)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return grpc.WithTransportCredentials(credentials.NewTLS(config)), nil
+}
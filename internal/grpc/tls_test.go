@@ -0,0 +1,248 @@
+package grpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/anfilat/final-stats/internal/mocks"
+	"github.com/anfilat/final-stats/internal/store"
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+func TestTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "ca")
+	conf := symo.TLSConf{
+		Cert:     filepath.Join(dir, "server.crt"),
+		Key:      filepath.Join(dir, "server.key"),
+		ClientCA: filepath.Join(dir, "ca.crt"),
+	}
+	ca.issue(t, "localhost", conf.Cert, conf.Key)
+	ca.writeCert(t, conf.ClientCA)
+
+	clientTLS := ClientTLS{
+		CA:         conf.ClientCA,
+		Cert:       filepath.Join(dir, "client.crt"),
+		Key:        filepath.Join(dir, "client.key"),
+		ServerName: "localhost",
+	}
+	ca.issue(t, "client", clientTLS.Cert, clientTLS.Key)
+
+	log := new(mocks.Logger)
+	log.On("Debug", "export for ", mock.Anything)
+	srv, listener := startTLSServer(t, log, conf)
+	defer stopGRPCServer(srv, listener)
+
+	require.NoError(t, exportOverTLS(listener, clientTLS))
+
+	// без клиентского сертификата сервер не пускает
+	noCert := clientTLS
+	noCert.Cert, noCert.Key = "", ""
+	require.Error(t, exportOverTLS(listener, noCert))
+
+	// без TLS тоже
+	require.Error(t, exportOverTLS(listener, ClientTLS{}))
+}
+
+func TestTLSReload(t *testing.T) {
+	dir := t.TempDir()
+	conf := symo.TLSConf{
+		Cert: filepath.Join(dir, "server.crt"),
+		Key:  filepath.Join(dir, "server.key"),
+	}
+	oldCA := newTestCA(t, "old")
+	oldCA.issue(t, "localhost", conf.Cert, conf.Key)
+
+	newCA := newTestCA(t, "new")
+	newCACert := filepath.Join(dir, "new.crt")
+	newCA.writeCert(t, newCACert)
+	clientTLS := ClientTLS{CA: newCACert, ServerName: "localhost"}
+
+	reloaded := make(chan struct{})
+	log := new(mocks.Logger)
+	log.On("Debug", "export for ", mock.Anything)
+	log.On("Info", "tls certificates are reloaded").Run(func(mock.Arguments) { close(reloaded) })
+	srv, listener := startTLSServer(t, log, conf)
+	defer stopGRPCServer(srv, listener)
+
+	require.Error(t, exportOverTLS(listener, clientTLS))
+
+	// сервер получил сертификат от другого CA
+	newCA.issue(t, "localhost", conf.Cert, conf.Key)
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(conf.Cert, later, later))
+	require.NoError(t, os.Chtimes(conf.Key, later, later))
+
+	require.NoError(t, exportOverTLS(listener, clientTLS))
+	<-reloaded
+}
+
+func TestTLSReloadAfterFailure(t *testing.T) {
+	dir := t.TempDir()
+	conf := symo.TLSConf{
+		Cert: filepath.Join(dir, "server.crt"),
+		Key:  filepath.Join(dir, "server.key"),
+	}
+	ca := newTestCA(t, "ca")
+	ca.issue(t, "localhost", conf.Cert, conf.Key)
+
+	log := new(mocks.Logger)
+	log.On("Error", mock.Anything).Once()
+	log.On("Info", "tls certificates are reloaded").Once()
+	reloader, err := newCertReloader(log, conf)
+	require.NoError(t, err)
+	first, _ := reloader.getConfigForClient(nil)
+	keyInfo, err := os.Stat(conf.Key)
+	require.NoError(t, err)
+
+	// сертификат записан, а ключ еще нет: остаются прежние сертификаты
+	later := time.Now().Add(time.Minute)
+	require.NoError(t, ioutil.WriteFile(conf.Cert, []byte("broken"), 0o600))
+	require.NoError(t, os.Chtimes(conf.Cert, later, later))
+	config, _ := reloader.getConfigForClient(nil)
+	require.Same(t, first, config)
+
+	// файлы дописаны в ту же секунду, время изменения не поменялось, но загрузка повторяется
+	ca.issue(t, "localhost", conf.Cert, conf.Key)
+	require.NoError(t, os.Chtimes(conf.Cert, later, later))
+	require.NoError(t, os.Chtimes(conf.Key, keyInfo.ModTime(), keyInfo.ModTime()))
+	config, _ = reloader.getConfigForClient(nil)
+	require.NotSame(t, first, config)
+	log.AssertExpectations(t)
+}
+
+func TestTLSBadFiles(t *testing.T) {
+	dir := t.TempDir()
+	conf := symo.TLSConf{
+		Cert: filepath.Join(dir, "server.crt"),
+		Key:  filepath.Join(dir, "server.key"),
+	}
+	_, err := newCertReloader(new(mocks.Logger), conf)
+	require.Error(t, err)
+
+	ca := newTestCA(t, "ca")
+	ca.issue(t, "localhost", conf.Cert, conf.Key)
+	conf.ClientCA = conf.Key
+	_, err = newCertReloader(new(mocks.Logger), conf)
+	require.Error(t, err)
+
+	_, err = ClientTLS{Cert: conf.Cert}.DialOption()
+	require.Error(t, err)
+}
+
+func startTLSServer(t *testing.T, log *mocks.Logger, conf symo.TLSConf) (*grpc.Server, *bufconn.Listener) {
+	reloader, err := newCertReloader(log, conf)
+	require.NoError(t, err)
+
+	listener := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer(grpc.Creds(credentials.NewTLS(reloader.serverConfig())))
+
+	config, _ := symo.NewConfig("")
+	RegisterAdminServer(srv, newAdminService(log, symo.NewAppSettings(config.App), clock.NewMock(), store.NewStore(symo.MaxSeconds), nil))
+
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+
+	return srv, listener
+}
+
+func exportOverTLS(listener *bufconn.Listener, clientTLS ClientTLS) error {
+	opt, err := clientTLS.DialOption()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, "", opt, grpc.WithContextDialer(dialer(listener)))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	reqClient, err := NewAdminClient(conn).ExportPoints(ctx, &ExportRequest{Seconds: 1})
+	if err != nil {
+		return err
+	}
+	_, err = reqClient.Recv()
+	return err
+}
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{cert: cert, key: key, der: der}
+}
+
+func (c *testCA) writeCert(t *testing.T, path string) {
+	writePEM(t, path, "CERTIFICATE", c.der)
+}
+
+// issue выпускает сертификат, годный и для сервера, и для клиента.
+func (c *testCA) issue(t *testing.T, name, certPath, keyPath string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, c.cert, &key.PublicKey, c.key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	writePEM(t, certPath, "CERTIFICATE", der)
+	writePEM(t, keyPath, "EC PRIVATE KEY", keyDer)
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	require.NoError(t, ioutil.WriteFile(path, data, 0o600))
+}
@@ -0,0 +1,21 @@
+// +build linux
+
+package logger
+
+import (
+	"fmt"
+	"log/syslog"
+
+	"github.com/sirupsen/logrus"
+	lsyslog "github.com/sirupsen/logrus/hooks/syslog"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+func newSyslogHook(conf symo.SyslogConf) (logrus.Hook, error) {
+	hook, err := lsyslog.NewSyslogHook(conf.Network, conf.Address, syslog.LOG_DAEMON|syslog.LOG_INFO, conf.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return hook, nil
+}
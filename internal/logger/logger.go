@@ -8,41 +8,85 @@ import (
 	"github.com/anfilat/final-stats/internal/symo"
 )
 
-// New возвращает настроенный логгер.
-func New(logLevel string) (symo.Logger, error) {
-	log := logrus.New()
+const megabyte = 1024 * 1024
 
+// New возвращает настроенный логгер.
+func New(conf symo.LoggerConf) (symo.LevelLogger, error) {
+	root := logrus.New()
 	result := logger{
-		logger: log,
+		root:  root,
+		entry: logrus.NewEntry(root),
+	}
+
+	if conf.Level != "" {
+		if err := result.SetLevel(conf.Level); err != nil {
+			return result, err
+		}
 	}
 
-	if logLevel != "" {
-		level, err := logrus.ParseLevel(logLevel)
+	switch conf.Format {
+	case "", "text":
+	case "json":
+		root.SetFormatter(&logrus.JSONFormatter{})
+	default:
+		return result, fmt.Errorf("unknown log format %q", conf.Format)
+	}
+
+	if conf.File != "" {
+		file, err := newRotatingFile(conf.File, int64(conf.MaxSize)*megabyte, conf.MaxBackups)
 		if err != nil {
-			return result, fmt.Errorf("failed to parse log level: %w", err)
+			return result, err
 		}
-		log.SetLevel(level)
+		root.SetOutput(file)
+	}
+
+	if conf.Syslog.Enabled {
+		hook, err := newSyslogHook(conf.Syslog)
+		if err != nil {
+			return result, err
+		}
+		root.AddHook(hook)
 	}
 
 	return result, nil
 }
 
+// logger пишет записи через logrus.Entry, чтобы поля, добавленные With, попадали во все записи.
+// Уровень и вывод общие у всех логгеров, полученных через With.
 type logger struct {
-	logger *logrus.Logger
+	root  *logrus.Logger
+	entry *logrus.Entry
 }
 
 func (l logger) Debug(args ...interface{}) {
-	l.logger.Debug(args...)
+	l.entry.Debug(args...)
 }
 
 func (l logger) Info(args ...interface{}) {
-	l.logger.Info(args...)
+	l.entry.Info(args...)
 }
 
 func (l logger) Error(args ...interface{}) {
-	l.logger.Error(args...)
+	l.entry.Error(args...)
 }
 
 func (l logger) Fatal(args ...interface{}) {
-	l.logger.Fatal(args...)
+	l.entry.Fatal(args...)
+}
+
+func (l logger) With(key string, value interface{}) symo.Logger {
+	return logger{
+		root:  l.root,
+		entry: l.entry.WithField(key, value),
+	}
+}
+
+// SetLevel меняет уровень логирования. logrus меняет его атомарно, поэтому логгер можно продолжать использовать.
+func (l logger) SetLevel(logLevel string) error {
+	level, err := logrus.ParseLevel(logLevel)
+	if err != nil {
+		return fmt.Errorf("failed to parse log level: %w", err)
+	}
+	l.root.SetLevel(level)
+	return nil
 }
@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+func TestLoggerJSON(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "symo.log")
+	log, err := New(symo.LoggerConf{Level: "INFO", Format: "json", File: file})
+	require.NoError(t, err)
+
+	clientsLog := log.With(symo.LogComponent, "clients")
+	clientsLog.With(symo.LogPeer, "127.0.0.1").Info("new client")
+	log.Debug("hidden")
+	clientsLog.Error("failed")
+
+	lines := readJSONLines(t, file)
+	require.Len(t, lines, 2)
+
+	require.Equal(t, "info", lines[0]["level"])
+	require.Equal(t, "new client", lines[0]["msg"])
+	require.Equal(t, "clients", lines[0][symo.LogComponent])
+	require.Equal(t, "127.0.0.1", lines[0][symo.LogPeer])
+
+	require.Equal(t, "error", lines[1]["level"])
+	require.Equal(t, "clients", lines[1][symo.LogComponent])
+	require.NotContains(t, lines[1], symo.LogPeer)
+}
+
+func TestLoggerSetLevel(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "symo.log")
+	log, err := New(symo.LoggerConf{Level: "INFO", Format: "json", File: file})
+	require.NoError(t, err)
+
+	// уровень общий у логгеров, полученных через With
+	collectorLog := log.With(symo.LogCollector, "cpu")
+	require.NoError(t, log.SetLevel("DEBUG"))
+	collectorLog.Debug("tick")
+
+	lines := readJSONLines(t, file)
+	require.Len(t, lines, 1)
+	require.Equal(t, "debug", lines[0]["level"])
+	require.Equal(t, "cpu", lines[0][symo.LogCollector])
+
+	require.Error(t, log.SetLevel("verbose"))
+}
+
+func TestLoggerFails(t *testing.T) {
+	_, err := New(symo.LoggerConf{Level: "verbose"})
+	require.Error(t, err)
+
+	_, err = New(symo.LoggerConf{Format: "xml"})
+	require.Error(t, err)
+}
+
+func readJSONLines(t *testing.T, name string) []map[string]interface{} {
+	file, err := os.Open(name)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var result []map[string]interface{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := make(map[string]interface{})
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		result = append(result, line)
+	}
+	require.NoError(t, scanner.Err())
+	return result
+}
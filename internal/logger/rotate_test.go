@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "symo.log")
+	file, err := newRotatingFile(path, 20, 2)
+	require.NoError(t, err)
+
+	for _, line := range []string{"first line\n", "second line\n", "third line\n", "fourth line\n"} {
+		_, err := file.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	requireContent(t, path, "fourth line\n")
+	requireContent(t, path+".1", "third line\n")
+	requireContent(t, path+".2", "second line\n")
+	_, err = os.Stat(path + ".3")
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestRotatingFileAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "symo.log")
+	require.NoError(t, ioutil.WriteFile(path, []byte("old\n"), 0o644))
+
+	// размер уже записанного файла учитывается при ротации
+	file, err := newRotatingFile(path, 10, 0)
+	require.NoError(t, err)
+	_, err = file.Write([]byte("new\n"))
+	require.NoError(t, err)
+	requireContent(t, path, "old\nnew\n")
+
+	// без копий файл начинается заново
+	_, err = file.Write([]byte("newest\n"))
+	require.NoError(t, err)
+	requireContent(t, path, "newest\n")
+	_, err = os.Stat(path + ".1")
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestRotatingFileWithoutLimit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "symo.log")
+	file, err := newRotatingFile(path, 0, 2)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		_, err := file.Write([]byte("line\n"))
+		require.NoError(t, err)
+	}
+	requireContent(t, path, "line\nline\nline\n")
+}
+
+func requireContent(t *testing.T, path, expected string) {
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(data))
+}
@@ -0,0 +1,15 @@
+// +build windows
+
+package logger
+
+import (
+	"errors"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+func newSyslogHook(symo.SyslogConf) (logrus.Hook, error) {
+	return nil, errors.New("syslog is not supported on windows")
+}
@@ -2,7 +2,10 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	symo "github.com/anfilat/final-stats/internal/symo"
+	mock "github.com/stretchr/testify/mock"
+)
 
 // Logger is an autogenerated mock type for the Logger type
 type Logger struct {
@@ -36,3 +39,19 @@ func (_m *Logger) Info(args ...interface{}) {
 	_ca = append(_ca, args...)
 	_m.Called(_ca...)
 }
+
+// With provides a mock function with given fields: key, value
+func (_m *Logger) With(key string, value interface{}) symo.Logger {
+	ret := _m.Called(key, value)
+
+	var r0 symo.Logger
+	if rf, ok := ret.Get(0).(func(string, interface{}) symo.Logger); ok {
+		r0 = rf(key, value)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(symo.Logger)
+		}
+	}
+
+	return r0
+}
@@ -0,0 +1,222 @@
+package prometheus
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+var labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// writer пишет метрики в текстовом формате Prometheus.
+type writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (w *writer) family(name, help string) {
+	w.typedFamily(name, help, "gauge")
+}
+
+func (w *writer) typedFamily(name, help, metricType string) {
+	w.write("# HELP ", name, " ", help, "\n")
+	w.write("# TYPE ", name, " ", metricType, "\n")
+}
+
+func (w *writer) sample(name, label, labelValue string, value float64) {
+	w.write(name)
+	if label != "" {
+		w.write("{", label, `="`, labelReplacer.Replace(labelValue), `"}`)
+	}
+	w.write(" ", strconv.FormatFloat(value, 'g', -1, 64), "\n")
+}
+
+func (w *writer) write(parts ...string) {
+	for _, part := range parts {
+		if w.err != nil {
+			return
+		}
+		_, w.err = w.w.WriteString(part)
+	}
+}
+
+// writeMetrics выводит метрики, усредненные за M секунд. Имена и метки метрик не меняются между версиями.
+func writeMetrics(out io.Writer, point *symo.Point, m int) error {
+	w := &writer{w: bufio.NewWriter(out)}
+
+	w.family("symo_window_seconds", "Interval in seconds the metrics are averaged over.")
+	w.sample("symo_window_seconds", "", "", float64(m))
+
+	w.family("symo_load_average", "System load average.")
+	if point.LoadAvg != nil {
+		w.sample("symo_load_average", "period", "1m", point.LoadAvg.Load1)
+		w.sample("symo_load_average", "period", "5m", point.LoadAvg.Load5)
+		w.sample("symo_load_average", "period", "15m", point.LoadAvg.Load15)
+	}
+
+	w.family("symo_cpu_percent", "CPU usage in percent.")
+	if point.CPU != nil {
+		w.sample("symo_cpu_percent", "mode", "user", point.CPU.User)
+		w.sample("symo_cpu_percent", "mode", "system", point.CPU.System)
+		w.sample("symo_cpu_percent", "mode", "idle", point.CPU.Idle)
+	}
+
+	w.family("symo_disk_transfers_per_second", "Disk transfers per second.")
+	for _, disk := range point.LoadDisks {
+		w.sample("symo_disk_transfers_per_second", "disk", disk.Name, disk.Tps)
+	}
+	w.family("symo_disk_read_kilobytes_per_second", "Kilobytes read from the disk per second.")
+	for _, disk := range point.LoadDisks {
+		w.sample("symo_disk_read_kilobytes_per_second", "disk", disk.Name, disk.KBRead)
+	}
+	w.family("symo_disk_written_kilobytes_per_second", "Kilobytes written to the disk per second.")
+	for _, disk := range point.LoadDisks {
+		w.sample("symo_disk_written_kilobytes_per_second", "disk", disk.Name, disk.KBWrite)
+	}
+
+	w.family("symo_filesystem_used_space_percent", "Used file system space in percent.")
+	for _, fs := range point.UsedFS {
+		w.sample("symo_filesystem_used_space_percent", "path", fs.Path, fs.UsedSpace)
+	}
+	w.family("symo_filesystem_used_inodes_percent", "Used file system inodes in percent.")
+	for _, fs := range point.UsedFS {
+		w.sample("symo_filesystem_used_inodes_percent", "path", fs.Path, fs.UsedInode)
+	}
+
+	w.family("symo_collector_state", "Collector state in the last second of the interval, 1 for the current one.")
+	writeState(w, "loadavg", point.State.LoadAvg)
+	writeState(w, "cpu", point.State.CPU)
+	writeState(w, "loaddisks", point.State.LoadDisks)
+	writeState(w, "usedfs", point.State.UsedFS)
+
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+var statuses = []symo.MetricStatus{symo.StatusOK, symo.StatusStale, symo.StatusError, symo.StatusDisabled, symo.StatusUnknown}
+
+func writeState(w *writer, metric string, state symo.MetricState) {
+	for _, status := range statuses {
+		value := 0.0
+		if state.Status == status {
+			value = 1
+		}
+		w.write("symo_collector_state{metric=\"", metric, "\",state=\"", status.String(), "\"} ",
+			strconv.FormatFloat(value, 'g', -1, 64), "\n")
+	}
+}
+
+var rejectReasons = []string{"clients", "peer", "n", "rate"}
+
+// writeClientsMetrics выводит ограничения подключений клиентов и их текущее состояние. 0 в ограничении - его нет.
+func writeClientsMetrics(out io.Writer, stats symo.ClientsStats, conf symo.ClientsConf) error {
+	w := &writer{w: bufio.NewWriter(out)}
+
+	w.family("symo_clients_connected", "Connected clients, exporters excluded.")
+	w.sample("symo_clients_connected", "", "", float64(stats.Clients))
+
+	w.family("symo_clients_limit", "Client admission limits, 0 if the limit is off. min_n is in seconds.")
+	w.sample("symo_clients_limit", "limit", "max_clients", float64(conf.MaxClients))
+	w.sample("symo_clients_limit", "limit", "max_per_peer", float64(conf.MaxPerPeer))
+	w.sample("symo_clients_limit", "limit", "min_n", conf.MinN.Seconds())
+	w.sample("symo_clients_limit", "limit", "subscription_rate", conf.SubscriptionRate)
+	w.sample("symo_clients_limit", "limit", "subscription_burst", float64(conf.SubscriptionBurst))
+
+	w.typedFamily("symo_clients_rejected_total", "Client subscriptions rejected by admission limits.", "counter")
+	for _, reason := range rejectReasons {
+		w.sample("symo_clients_rejected_total", "reason", reason, float64(stats.Rejected[reason]))
+	}
+
+	w.typedFamily("symo_clients_dropped_sends_total", "Stats not delivered to slow clients.", "counter")
+	w.sample("symo_clients_dropped_sends_total", "", "", float64(stats.DroppedSends))
+
+	w.family("symo_clients_send_duration_seconds", "How long sending the last tick to clients took.")
+	w.sample("symo_clients_send_duration_seconds", "", "", stats.SendDuration.Seconds())
+
+	w.family("symo_clients_subscribed", "Subscribed clients and exporters by N and M in seconds.")
+	for _, interval := range symo.SortedIntervals(stats.Intervals) {
+		w.write("symo_clients_subscribed{n=\"", seconds(interval.N), "\",m=\"", seconds(interval.M), "\"} ",
+			strconv.Itoa(stats.Intervals[interval]), "\n")
+	}
+
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+// writeSelfMetrics выводит метрики работы сервиса сбора метрик, хранилищ и рантайма Go.
+func writeSelfMetrics(out io.Writer, stats symo.SelfStats) error {
+	w := &writer{w: bufio.NewWriter(out)}
+	collector := stats.Collector
+
+	w.typedFamily("symo_collector_ticks_total", "Collector ticks processed.", "counter")
+	w.sample("symo_collector_ticks_total", "", "", float64(collector.Ticks))
+
+	w.typedFamily("symo_collector_dropped_ticks_total", "Ticks not passed to the busy clients service.", "counter")
+	w.sample("symo_collector_dropped_ticks_total", "", "", float64(collector.DroppedTicks))
+
+	w.family("symo_collector_tick_duration_seconds", "How long processing the last tick took.")
+	w.sample("symo_collector_tick_duration_seconds", "", "", collector.TickDuration.Seconds())
+
+	w.family("symo_collector_tick_lag_seconds", "How late processing the last tick started after the timer fired.")
+	w.sample("symo_collector_tick_lag_seconds", "", "", collector.TickLag.Seconds())
+
+	w.typedFamily("symo_collector_samples_total", "Collector samples taken.", "counter")
+	eachSampler(collector, func(metric string, sampler symo.SamplerStats) {
+		w.sample("symo_collector_samples_total", "metric", metric, float64(sampler.Samples))
+	})
+	w.typedFamily("symo_collector_sample_errors_total", "Collector samples failed, timeouts excluded.", "counter")
+	eachSampler(collector, func(metric string, sampler symo.SamplerStats) {
+		w.sample("symo_collector_sample_errors_total", "metric", metric, float64(sampler.Errors))
+	})
+	w.typedFamily("symo_collector_sample_timeouts_total", "Collector samples timed out.", "counter")
+	eachSampler(collector, func(metric string, sampler symo.SamplerStats) {
+		w.sample("symo_collector_sample_timeouts_total", "metric", metric, float64(sampler.Timeouts))
+	})
+	w.typedFamily("symo_collector_sample_seconds_total", "Total time spent sampling the collector.", "counter")
+	eachSampler(collector, func(metric string, sampler symo.SamplerStats) {
+		w.sample("symo_collector_sample_seconds_total", "metric", metric, sampler.LatencyTotal.Seconds())
+	})
+	w.family("symo_collector_sample_latency_seconds", "How long the last sample of the collector took.")
+	eachSampler(collector, func(metric string, sampler symo.SamplerStats) {
+		w.sample("symo_collector_sample_latency_seconds", "metric", metric, sampler.Latency.Seconds())
+	})
+
+	w.family("symo_store_points", "Points retained in memory.")
+	w.sample("symo_store_points", "store", "seconds", float64(stats.PointsRetained))
+	w.sample("symo_store_points", "store", "ticks", float64(stats.TicksRetained))
+
+	w.typedFamily("symo_store_dropped_writes_total", "Seconds not saved to disk because the write queue was full.", "counter")
+	w.sample("symo_store_dropped_writes_total", "", "", float64(stats.DroppedWrites))
+
+	w.family("symo_heap_bytes", "Heap memory in use.")
+	w.sample("symo_heap_bytes", "", "", float64(stats.HeapBytes))
+
+	w.family("symo_goroutines", "Goroutines running.")
+	w.sample("symo_goroutines", "", "", float64(stats.Goroutines))
+
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+// eachSampler вызывает fn для опрошенных коллекторов в порядке symo.MetricNames.
+func eachSampler(stats symo.CollectorStats, fn func(metric string, sampler symo.SamplerStats)) {
+	for _, metric := range symo.MetricNames {
+		if sampler, ok := stats.Samplers[metric]; ok {
+			fn(metric, sampler)
+		}
+	}
+}
+
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'g', -1, 64)
+}
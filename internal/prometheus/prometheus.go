@@ -0,0 +1,123 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type server struct {
+	mutex  *sync.Mutex
+	srv    *http.Server
+	app    *symo.AppSettings // время хранения метрик, меняется при перезагрузке конфига
+	config symo.Config
+	log    symo.Logger
+	clock  clock.Clock
+}
+
+// NewServer возвращает HTTP сервер, отдающий метрики в формате Prometheus.
+func NewServer(log symo.Logger, config symo.Config, clock clock.Clock) symo.PrometheusServer {
+	return &server{
+		mutex:  &sync.Mutex{},
+		app:    symo.NewAppSettings(config.App),
+		config: config,
+		log:    log,
+		clock:  clock,
+	}
+}
+
+func (s *server) Start(addr string, points symo.PointsReader, self symo.SelfStater) error {
+	s.mutex.Lock()
+	s.srv = &http.Server{
+		Addr:    addr,
+		Handler: s.handler(points, self),
+	}
+	srv := s.srv
+	s.mutex.Unlock()
+
+	s.log.Debug("starting prometheus server on ", addr)
+	err := srv.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Reload применяет новое время хранения метрик. Адрес сервера меняется только перезапуском.
+func (s *server) Reload(_ context.Context, config symo.Config) {
+	s.app.Set(config.App)
+}
+
+func (s *server) Stop(ctx context.Context) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.srv == nil {
+		return
+	}
+	if err := s.srv.Shutdown(ctx); err != nil {
+		_ = s.srv.Close()
+	}
+
+	s.log.Debug("prometheus server is stopped")
+}
+
+func (s *server) handler(points symo.PointsReader, self symo.SelfStater) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		m, err := s.window(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// как и для клиентов, усредняются завершенные секунды до текущей
+		now := s.clock.Now().Truncate(time.Second)
+		point := points.Mean(now, m)
+
+		w.Header().Set("Content-Type", contentType)
+		err = writeMetrics(w, &point, m)
+		if err == nil {
+			stats := self.SelfStats()
+			err = writeClientsMetrics(w, stats.Clients, s.config.Clients)
+			if err == nil {
+				err = writeSelfMetrics(w, stats)
+			}
+		}
+		if err != nil {
+			s.log.Debug(fmt.Errorf("unable to write metrics: %w", err))
+		}
+	})
+	return mux
+}
+
+// window возвращает интервал усреднения из параметра m запроса или из конфига.
+func (s *server) window(r *http.Request) (int, error) {
+	value := r.URL.Query().Get("m")
+	if value == "" {
+		return s.config.Prometheus.M, nil
+	}
+
+	m, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.New("M must be a number")
+	}
+	maxSeconds := s.app.Get().MaxWindow()
+	if m <= 0 {
+		return 0, errors.New("M must be greater than 0 seconds")
+	}
+	if m > maxSeconds {
+		return 0, fmt.Errorf("M must be less than %v seconds", maxSeconds)
+	}
+	return m, nil
+}
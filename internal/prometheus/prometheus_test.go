@@ -0,0 +1,166 @@
+package prometheus
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/anfilat/final-stats/internal/mocks"
+	"github.com/anfilat/final-stats/internal/store"
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+func TestPrometheusStartStop(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	config, _ := symo.NewConfig("")
+
+	log := new(mocks.Logger)
+	log.On("Debug", "prometheus server is stopped")
+	log.On("Debug", "starting prometheus server on ", mock.Anything)
+
+	server := NewServer(log, config, clock.New())
+	go func() {
+		err := server.Start(":"+config.Prometheus.Port, store.NewStore(config.App.MaxSeconds), new(mocks.SelfStater))
+		require.NoError(t, err)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+
+	server.Stop(context.Background())
+
+	log.AssertExpectations(t)
+}
+
+func TestPrometheusMetrics(t *testing.T) {
+	config, _ := symo.NewConfig("")
+	config.Prometheus.M = 2
+
+	now := time.Now().Truncate(time.Second)
+	mockedClock := clock.NewMock()
+	mockedClock.Set(now)
+
+	points := store.NewStore(config.App.MaxSeconds)
+	ok := symo.MetricState{Status: symo.StatusOK}
+	for i, cpu := range []float64{10, 20, 40} {
+		points.Append(now.Add(time.Duration(i-3)*time.Second), symo.Point{
+			LoadAvg: &symo.LoadAvgData{Load1: 1, Load5: 2, Load15: 3},
+			CPU:     &symo.CPUData{User: cpu, System: 5, Idle: 100 - cpu - 5},
+			LoadDisks: symo.LoadDisksData{
+				{Name: "sda", Tps: 4, KBRead: 8, KBWrite: 16},
+			},
+			UsedFS: symo.UsedFSData{
+				{Path: `C:\`, UsedSpace: 50, UsedInode: 25},
+			},
+			State: symo.MetricsState{
+				LoadAvg:   ok,
+				CPU:       ok,
+				LoadDisks: ok,
+				UsedFS:    symo.MetricState{Status: symo.StatusError, Message: "df failed"},
+			},
+		})
+	}
+
+	config.Clients.MaxClients = 1000
+	config.Clients.SubscriptionRate = 0.5
+	self := new(mocks.SelfStater)
+	self.On("SelfStats").Return(symo.SelfStats{
+		Collector: symo.CollectorStats{
+			Ticks:        120,
+			DroppedTicks: 2,
+			TickDuration: 3 * time.Millisecond,
+			TickLag:      time.Millisecond,
+			Samplers: map[string]symo.SamplerStats{
+				"cpu": {Samples: 120, Errors: 1, Timeouts: 4, Latency: 2 * time.Millisecond, LatencyTotal: 300 * time.Millisecond},
+			},
+		},
+		Clients: symo.ClientsStats{
+			Clients:      12,
+			Rejected:     map[string]uint64{"rate": 3},
+			DroppedSends: 7,
+			Intervals: map[symo.ClientInterval]int{
+				{N: time.Second, M: 15 * time.Second}:           2,
+				{N: 500 * time.Millisecond, M: 2 * time.Second}: 1,
+			},
+		},
+		PointsRetained: 600,
+		DroppedWrites:  2,
+		HeapBytes:      1 << 20,
+		Goroutines:     42,
+	})
+
+	server := NewServer(new(mocks.Logger), config, mockedClock).(*server)
+	handler := server.handler(points, self)
+
+	body := request(t, handler, "/metrics", http.StatusOK)
+	require.Contains(t, body, "# TYPE symo_cpu_percent gauge\n")
+	require.Contains(t, body, "symo_window_seconds 2\n")
+	require.Contains(t, body, `symo_load_average{period="5m"} 2`+"\n")
+	require.Contains(t, body, `symo_cpu_percent{mode="user"} 30`+"\n")
+	require.Contains(t, body, `symo_disk_written_kilobytes_per_second{disk="sda"} 16`+"\n")
+	require.Contains(t, body, `symo_filesystem_used_space_percent{path="C:\\"} 50`+"\n")
+	require.Contains(t, body, `symo_collector_state{metric="cpu",state="ok"} 1`+"\n")
+	require.Contains(t, body, `symo_collector_state{metric="usedfs",state="ok"} 0`+"\n")
+	require.Contains(t, body, `symo_collector_state{metric="usedfs",state="error"} 1`+"\n")
+	require.Contains(t, body, "symo_clients_connected 12\n")
+	require.Contains(t, body, `symo_clients_limit{limit="max_clients"} 1000`+"\n")
+	require.Contains(t, body, `symo_clients_limit{limit="max_per_peer"} 0`+"\n")
+	require.Contains(t, body, `symo_clients_limit{limit="subscription_rate"} 0.5`+"\n")
+	require.Contains(t, body, "# TYPE symo_clients_rejected_total counter\n")
+	require.Contains(t, body, `symo_clients_rejected_total{reason="rate"} 3`+"\n")
+	require.Contains(t, body, `symo_clients_rejected_total{reason="peer"} 0`+"\n")
+	require.Contains(t, body, "symo_clients_dropped_sends_total 7\n")
+	require.Contains(t, body, `symo_clients_subscribed{n="0.5",m="2"} 1`+"\n"+`symo_clients_subscribed{n="1",m="15"} 2`+"\n")
+	require.Contains(t, body, "symo_collector_ticks_total 120\n")
+	require.Contains(t, body, "symo_collector_dropped_ticks_total 2\n")
+	require.Contains(t, body, "symo_collector_tick_duration_seconds 0.003\n")
+	require.Contains(t, body, `symo_collector_sample_timeouts_total{metric="cpu"} 4`+"\n")
+	require.Contains(t, body, `symo_collector_sample_seconds_total{metric="cpu"} 0.3`+"\n")
+	require.NotContains(t, body, `symo_collector_samples_total{metric="loadavg"}`)
+	require.Contains(t, body, `symo_store_points{store="seconds"} 600`+"\n")
+	require.Contains(t, body, "symo_store_dropped_writes_total 2\n")
+	require.Contains(t, body, "symo_heap_bytes 1.048576e+06\n")
+	require.Contains(t, body, "symo_goroutines 42\n")
+
+	body = request(t, handler, "/metrics?m=3", http.StatusOK)
+	require.Contains(t, body, `symo_cpu_percent{mode="user"} 23.`)
+
+	request(t, handler, "/metrics?m=0", http.StatusBadRequest)
+	request(t, handler, "/metrics?m=abc", http.StatusBadRequest)
+	request(t, handler, "/metrics?m=100000", http.StatusBadRequest)
+}
+
+func TestPrometheusEmptyStore(t *testing.T) {
+	config, _ := symo.NewConfig("")
+
+	self := new(mocks.SelfStater)
+	self.On("SelfStats").Return(symo.SelfStats{})
+
+	server := NewServer(new(mocks.Logger), config, clock.NewMock()).(*server)
+	body := request(t, server.handler(store.NewStore(config.App.MaxSeconds), self), "/metrics", http.StatusOK)
+
+	require.Contains(t, body, "# TYPE symo_load_average gauge\n")
+	require.NotContains(t, body, "symo_load_average{")
+	require.Contains(t, body, `symo_collector_state{metric="loadavg",state="stale"} 1`+"\n")
+}
+
+func request(t *testing.T, handler http.Handler, url string, code int) string {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+	require.Equal(t, code, rec.Code)
+
+	body, err := ioutil.ReadAll(rec.Body)
+	require.NoError(t, err)
+	if code == http.StatusOK {
+		require.Equal(t, contentType, rec.Header().Get("Content-Type"))
+	}
+	return string(body)
+}
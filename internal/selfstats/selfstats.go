@@ -0,0 +1,52 @@
+package selfstats
+
+import (
+	"runtime"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+type selfStats struct {
+	collector symo.CollectorStater
+	clients   symo.ClientsStater
+	points    symo.PointsStore
+	ticks     symo.PointsStore // nil, если тик не короче секунды
+	disk      symo.StoreStater // nil, если точки не пишутся на диск
+}
+
+// NewSelfStats возвращает метрики самого приложения, собранные из сервисов, хранилищ и рантайма Go.
+// Метрики считаются при каждом запросе.
+func NewSelfStats(
+	collector symo.CollectorStater,
+	clients symo.ClientsStater,
+	points, ticks symo.PointsStore,
+	disk symo.StoreStater,
+) symo.SelfStater {
+	return &selfStats{
+		collector: collector,
+		clients:   clients,
+		points:    points,
+		ticks:     ticks,
+		disk:      disk,
+	}
+}
+
+func (s *selfStats) SelfStats() symo.SelfStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	result := symo.SelfStats{
+		Collector:      s.collector.CollectorStats(),
+		Clients:        s.clients.ClientsStats(),
+		PointsRetained: s.points.Len(),
+		HeapBytes:      mem.HeapAlloc,
+		Goroutines:     runtime.NumGoroutine(),
+	}
+	if s.ticks != nil {
+		result.TicksRetained = s.ticks.Len()
+	}
+	if s.disk != nil {
+		result.DroppedWrites = s.disk.DroppedWrites()
+	}
+	return result
+}
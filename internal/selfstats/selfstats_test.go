@@ -0,0 +1,49 @@
+package selfstats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/anfilat/final-stats/internal/mocks"
+	"github.com/anfilat/final-stats/internal/store"
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+func TestSelfStats(t *testing.T) {
+	collectorStats := symo.CollectorStats{Ticks: 10, DroppedTicks: 1}
+	collector := new(mocks.CollectorStater)
+	collector.On("CollectorStats").Return(collectorStats)
+
+	clientsStats := symo.ClientsStats{Clients: 2, DroppedSends: 3}
+	clients := new(mocks.ClientsStater)
+	clients.On("ClientsStats").Return(clientsStats)
+
+	now := time.Unix(1_600_000_000, 0)
+	points := store.NewStore(symo.MaxSeconds)
+	for i := 0; i < 5; i++ {
+		points.Append(now.Add(time.Duration(i)*time.Second), symo.Point{})
+	}
+
+	stats := NewSelfStats(collector, clients, points, nil, nil).SelfStats()
+	require.Equal(t, collectorStats, stats.Collector)
+	require.Equal(t, clientsStats, stats.Clients)
+	require.Equal(t, 5, stats.PointsRetained)
+	require.Equal(t, 0, stats.TicksRetained)
+	require.Zero(t, stats.DroppedWrites)
+	require.NotZero(t, stats.HeapBytes)
+	require.NotZero(t, stats.Goroutines)
+
+	ticks := store.NewTickStore(250*time.Millisecond, symo.TickSeconds)
+	ticks.Append(now, symo.Point{})
+	disk := new(mocks.StoreStater)
+	disk.On("DroppedWrites").Return(uint64(4))
+	stats = NewSelfStats(collector, clients, points, ticks, disk).SelfStats()
+	require.Equal(t, 1, stats.TicksRetained)
+	require.Equal(t, uint64(4), stats.DroppedWrites)
+
+	collector.AssertExpectations(t)
+	clients.AssertExpectations(t)
+	disk.AssertExpectations(t)
+}
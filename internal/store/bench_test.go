@@ -0,0 +1,201 @@
+package store
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+// Сравнение колоночного хранилища с прежним способом хранения: картой точек по времени, которую коллектор
+// чистил и копировал для сервиса клиентов каждый тик.
+//
+//	go test -run=^$ -bench=. -benchmem ./internal/store/
+
+const benchSeconds = 3600
+
+var benchStart = time.Unix(1_600_000_000, 0)
+
+// mapStore повторяет прежнее хранение точек.
+type mapStore map[time.Time]*symo.Point
+
+// tick добавляет точку, удаляет устаревшие и возвращает копию для сервиса клиентов, как это делал коллектор.
+func (s mapStore) tick(now time.Time, point symo.Point) mapStore {
+	s[now] = &point
+
+	limit := now.Add(-benchSeconds * time.Second)
+	for key := range s {
+		if key.Before(limit) {
+			delete(s, key)
+		}
+	}
+
+	result := make(mapStore, len(s))
+	for key, point := range s {
+		newPoint := *point
+		result[key] = &newPoint
+	}
+	return result
+}
+
+// values собирает значения поля за M секунд до now так, как это делала агрегация клиентов.
+func (s mapStore) values(now time.Time, m int) (cpu []float64, disks map[string][]float64) {
+	from := now.Add(-time.Duration(m) * time.Second)
+	times := make([]time.Time, 0, len(s))
+	for tm := range s {
+		if !tm.Before(from) && tm.Before(now) {
+			times = append(times, tm)
+		}
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Before(times[j])
+	})
+
+	disks = make(map[string][]float64)
+	for _, tm := range times {
+		point := s[tm]
+		if point.CPU != nil {
+			cpu = append(cpu, point.CPU.User)
+		}
+		for _, disk := range point.LoadDisks {
+			disks[disk.Name] = append(disks[disk.Name], disk.Tps)
+		}
+	}
+	return cpu, disks
+}
+
+// benchPoint возвращает точку с новыми строками имен, как после разбора вывода утилит.
+func benchPoint(i int) symo.Point {
+	point := symo.Point{
+		LoadAvg: &symo.LoadAvgData{Load1: 1, Load5: 2, Load15: float64(i)},
+		CPU:     &symo.CPUData{User: float64(i % 100), System: 2, Idle: 3},
+	}
+	for d := 0; d < 4; d++ {
+		point.LoadDisks = append(point.LoadDisks, symo.DiskData{
+			Name: string([]byte{'s', 'd', byte('a' + d)}),
+			Tps:  float64(i + d),
+		})
+	}
+	for _, path := range []string{"/", "/home", "/var"} {
+		point.UsedFS = append(point.UsedFS, symo.FSData{
+			Path:      string([]byte(path)),
+			UsedSpace: float64(i % 100),
+		})
+	}
+	return point
+}
+
+func fillStore(st symo.PointsStore) {
+	for i := 0; i < benchSeconds; i++ {
+		st.Append(benchStart.Add(time.Duration(i)*time.Second), benchPoint(i))
+	}
+}
+
+func fillMapStore() mapStore {
+	st := make(mapStore)
+	for i := 0; i < benchSeconds; i++ {
+		st[benchStart.Add(time.Duration(i)*time.Second)] = func(p symo.Point) *symo.Point { return &p }(benchPoint(i))
+	}
+	return st
+}
+
+// BenchmarkTick - обработка одного тика в заполненном хранилище.
+func BenchmarkTick(b *testing.B) {
+	b.Run("columnar", func(b *testing.B) {
+		st := NewStore(benchSeconds)
+		fillStore(st)
+		points := make([]symo.Point, b.N)
+		for i := range points {
+			points[i] = benchPoint(benchSeconds + i)
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			st.Append(benchStart.Add(time.Duration(benchSeconds+i)*time.Second), points[i])
+		}
+	})
+	b.Run("map", func(b *testing.B) {
+		st := fillMapStore()
+		points := make([]symo.Point, b.N)
+		for i := range points {
+			points[i] = benchPoint(benchSeconds + i)
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			st.tick(benchStart.Add(time.Duration(benchSeconds+i)*time.Second), points[i])
+		}
+	})
+}
+
+// BenchmarkFootprint - память, занятая историей за 3600 секунд.
+func BenchmarkFootprint(b *testing.B) {
+	measure := func(b *testing.B, fill func() interface{}) {
+		var total uint64
+		for i := 0; i < b.N; i++ {
+			before := heapAlloc()
+			st := fill()
+			total += heapAlloc() - before
+			runtime.KeepAlive(st)
+		}
+		b.ReportMetric(float64(total)/float64(b.N), "heap-B/store")
+	}
+
+	b.Run("columnar", func(b *testing.B) {
+		measure(b, func() interface{} {
+			st := NewStore(benchSeconds)
+			fillStore(st)
+			return st
+		})
+	})
+	b.Run("map", func(b *testing.B) {
+		measure(b, func() interface{} {
+			return fillMapStore()
+		})
+	})
+}
+
+func heapAlloc() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+// BenchmarkRead - чтение значений cpu и дисков за M секунд для агрегации.
+func BenchmarkRead(b *testing.B) {
+	now := benchStart.Add(benchSeconds * time.Second)
+
+	for _, m := range []int{60, 3600} {
+		b.Run(fmt.Sprintf("columnar/m=%d", m), func(b *testing.B) {
+			st := NewStore(benchSeconds)
+			fillStore(st)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				sum := 0.0
+				st.Window(now, m, func(window symo.Window) {
+					window.CPU[0].Each(func(value float64) {
+						sum += value
+					})
+					for _, disk := range window.LoadDisks {
+						disk.Columns[0].Each(func(value float64) {
+							sum += value
+						})
+					}
+				})
+			}
+		})
+		b.Run(fmt.Sprintf("map/m=%d", m), func(b *testing.B) {
+			st := fillMapStore()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				st.values(now, m)
+			}
+		})
+	}
+}
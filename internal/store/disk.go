@@ -0,0 +1,395 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+const (
+	segmentSeconds = 60      // сколько секунд пишется в один файл сегмента
+	segmentExt     = ".seg"  // расширение файлов сегментов
+	headerSize     = 8       // длина записи и ее CRC32
+	maxRecordSize  = 1 << 20 // запись больше считается поврежденной
+	queueSize      = 300     // сколько секунд ждет записи, пока диск занят. Секунды сверх очереди не сохраняются
+)
+
+// record - запись сегмента, точка за одну секунду.
+type record struct {
+	Time  int64
+	Point symo.Point
+}
+
+// entry - запись в очереди на диск вместе с временем хранения на момент ее добавления.
+type entry struct {
+	record
+	seconds int64
+}
+
+type diskStore struct {
+	symo.PointsStore // точки в памяти, из которых читают клиенты
+
+	mutex   *sync.Mutex
+	dir     string
+	seconds int64
+	last    int64 // последняя поставленная в очередь секунда
+	dropped uint64
+	stopped bool
+	queue   chan entry
+	stop    chan struct{}
+	done    chan struct{}
+	log     symo.Logger
+
+	// поля ниже принадлежат горутине записи
+	file     *os.File      // текущий сегмент
+	buf      *bufio.Writer // буфер записи текущего сегмента
+	segStart int64         // первая секунда текущего сегмента
+}
+
+// NewDiskStore возвращает хранилище, которое дублирует точки, добавляемые в points, в файлы сегментов
+// в каталоге dir и при создании загружает из них в points сохраненную историю за seconds секунд.
+// Запись сегмента содержит длину, CRC32 и точку в JSON. Запись, оборванная при падении, отбрасывается при загрузке.
+// Точки пишутся на диск в отдельной горутине через буфер, который сбрасывается, когда очередь пуста.
+// Если диск отстал на всю очередь, новые секунды не ждут и не сохраняются, их число отдает DroppedWrites.
+// Сегмент синхронизируется с диском, когда закрывается при переходе на следующий или при остановке.
+func NewDiskStore(log symo.Logger, dir string, seconds int, points symo.PointsStore) (symo.DiskStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %w", err)
+	}
+
+	s := &diskStore{
+		PointsStore: points,
+		mutex:       &sync.Mutex{},
+		dir:         dir,
+		seconds:     int64(seconds),
+		last:        -1,
+		queue:       make(chan entry, queueSize),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+		log:         log,
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	go s.run()
+	return s, nil
+}
+
+func (s *diskStore) Append(tm time.Time, point symo.Point) {
+	s.PointsStore.Append(tm, point)
+
+	sec := tm.Unix()
+	s.mutex.Lock()
+	if s.stopped || sec <= s.last {
+		s.mutex.Unlock()
+		return
+	}
+	s.last = sec
+	seconds := s.seconds
+	s.mutex.Unlock()
+
+	// Append вызывается под мьютексом сборщика метрик, поэтому занятый диск не должен его задерживать
+	select {
+	case s.queue <- entry{record: record{Time: sec, Point: point}, seconds: seconds}:
+	default:
+		s.mutex.Lock()
+		s.dropped++
+		s.mutex.Unlock()
+		s.log.Debug("store queue is full, point is not saved")
+	}
+}
+
+func (s *diskStore) DroppedWrites() uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.dropped
+}
+
+// Resize меняет время хранения точек в памяти и на диске. Лишние сегменты удаляются при открытии следующего.
+func (s *diskStore) Resize(seconds int) {
+	s.PointsStore.Resize(seconds)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.seconds = int64(seconds)
+}
+
+// Stop дописывает очередь на диск и закрывает сегмент.
+func (s *diskStore) Stop(ctx context.Context) {
+	s.mutex.Lock()
+	if !s.stopped {
+		s.stopped = true
+		close(s.stop)
+	}
+	s.mutex.Unlock()
+
+	select {
+	case <-s.done:
+		s.log.Debug("store is stopped")
+	case <-ctx.Done():
+		s.log.Error(errors.New("store is stopped before the queue is saved"))
+	}
+}
+
+// run пишет точки из очереди в сегменты.
+func (s *diskStore) run() {
+	defer close(s.done)
+	defer s.closeSegment()
+
+	for {
+		select {
+		case e := <-s.queue:
+			s.save(e)
+		case <-s.stop:
+			// очередь закрывать нельзя, в нее может писать Append, начатый до остановки
+			for {
+				select {
+				case e := <-s.queue:
+					s.save(e)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// save пишет точку в сегмент и сбрасывает буфер, когда очередь опустела.
+func (s *diskStore) save(e entry) {
+	if err := s.write(e); err != nil {
+		s.log.Error(fmt.Errorf("unable to save point: %w", err))
+		return
+	}
+	if len(s.queue) == 0 {
+		s.flush()
+	}
+}
+
+func (s *diskStore) write(e entry) error {
+	if s.file == nil || e.Time-s.segStart >= segmentSeconds {
+		if err := s.openSegment(e.Time, e.seconds); err != nil {
+			return err
+		}
+	}
+
+	payload, err := json.Marshal(e.record)
+	if err != nil {
+		return err
+	}
+	header := make([]byte, headerSize)
+	binary.LittleEndian.PutUint32(header, uint32(len(payload)))
+	binary.LittleEndian.PutUint32(header[4:], crc32.ChecksumIEEE(payload))
+
+	if _, err := s.buf.Write(header); err != nil {
+		return s.dropSegment(err)
+	}
+	if _, err := s.buf.Write(payload); err != nil {
+		return s.dropSegment(err)
+	}
+	return nil
+}
+
+// flush сбрасывает буфер в файл сегмента. Синхронизация с диском делается при закрытии сегмента.
+func (s *diskStore) flush() {
+	if s.file == nil {
+		return
+	}
+	if err := s.buf.Flush(); err != nil {
+		s.log.Error(fmt.Errorf("unable to save points: %w", s.dropSegment(err)))
+	}
+}
+
+// dropSegment бросает сегмент после ошибки записи. Недописанная запись будет отброшена при загрузке,
+// дальше пишется новый сегмент.
+func (s *diskStore) dropSegment(err error) error {
+	if closeErr := s.file.Close(); closeErr != nil {
+		s.log.Error(fmt.Errorf("unable to close segment: %w", closeErr))
+	}
+	s.file = nil
+	s.buf = nil
+	return err
+}
+
+func (s *diskStore) openSegment(sec, seconds int64) error {
+	s.closeSegment()
+
+	name := filepath.Join(s.dir, fmt.Sprintf("%020d%s", sec, segmentExt))
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	s.file = file
+	s.buf = bufio.NewWriter(file)
+	s.segStart = sec
+
+	s.removeOld(sec, seconds)
+	return nil
+}
+
+// closeSegment дописывает буфер и синхронизирует сегмент с диском перед закрытием.
+func (s *diskStore) closeSegment() {
+	if s.file == nil {
+		return
+	}
+	if err := s.buf.Flush(); err != nil {
+		s.log.Error(fmt.Errorf("unable to save points: %w", s.dropSegment(err)))
+		return
+	}
+	if err := s.file.Sync(); err != nil {
+		s.log.Error(fmt.Errorf("unable to sync segment: %w", err))
+	}
+	if err := s.file.Close(); err != nil {
+		s.log.Error(fmt.Errorf("unable to close segment: %w", err))
+	}
+	s.file = nil
+	s.buf = nil
+}
+
+// removeOld удаляет сегменты, все точки которых старше времени хранения seconds.
+func (s *diskStore) removeOld(now, seconds int64) {
+	segments, err := s.segments()
+	if err != nil {
+		s.log.Error(err)
+		return
+	}
+	for _, seg := range segments {
+		if seg.start+segmentSeconds <= now-seconds {
+			if err := os.Remove(seg.path); err != nil {
+				s.log.Error(fmt.Errorf("unable to remove old segment: %w", err))
+			}
+		}
+	}
+}
+
+type segment struct {
+	path  string
+	start int64
+}
+
+// segments возвращает файлы сегментов, упорядоченные по времени.
+func (s *diskStore) segments() ([]segment, error) {
+	entries, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read store directory: %w", err)
+	}
+
+	var result []segment
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		start, err := strconv.ParseInt(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		result = append(result, segment{path: filepath.Join(s.dir, name), start: start})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].start < result[j].start
+	})
+	return result, nil
+}
+
+// load загружает точки из сегментов в память.
+func (s *diskStore) load() error {
+	segments, err := s.segments()
+	if err != nil {
+		return err
+	}
+
+	var loaded int
+	for _, seg := range segments {
+		n, err := s.loadSegment(seg.path)
+		if err != nil {
+			return err
+		}
+		loaded += n
+	}
+	if s.last >= 0 {
+		s.removeOld(s.last, s.seconds)
+	}
+
+	s.log.Info("loaded ", loaded, " points from ", s.dir)
+	return nil
+}
+
+// loadSegment читает записи сегмента до первой поврежденной и обрезает файл по ней.
+func (s *diskStore) loadSegment(path string) (int, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open segment: %w", err)
+	}
+	defer file.Close()
+
+	var loaded int
+	var offset int64
+	for {
+		rec, size, err := readRecord(file)
+		if errors.Is(err, io.EOF) {
+			return loaded, nil
+		}
+		if err != nil {
+			s.log.Info("segment ", path, " is damaged at ", offset, ": ", err, ", the rest is dropped")
+			if err := file.Truncate(offset); err != nil {
+				return loaded, fmt.Errorf("failed to truncate segment: %w", err)
+			}
+			return loaded, nil
+		}
+		offset += size
+
+		if rec.Time <= s.last {
+			continue
+		}
+		s.PointsStore.Append(time.Unix(rec.Time, 0), rec.Point)
+		s.last = rec.Time
+		loaded++
+	}
+}
+
+// readRecord читает одну запись. io.EOF означает, что сегмент закончился ровно на границе записи.
+func readRecord(r io.Reader) (*record, int64, error) {
+	header := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, 0, errors.New("truncated record header")
+		}
+		return nil, 0, err
+	}
+
+	size := binary.LittleEndian.Uint32(header)
+	if size > maxRecordSize {
+		return nil, 0, fmt.Errorf("record size %d is too large", size)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, 0, errors.New("truncated record")
+	}
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header[4:]) {
+		return nil, 0, errors.New("checksum mismatch")
+	}
+
+	rec := &record{}
+	if err := json.Unmarshal(payload, rec); err != nil {
+		return nil, 0, fmt.Errorf("invalid record: %w", err)
+	}
+	return rec, int64(headerSize + size), nil
+}
@@ -0,0 +1,185 @@
+package store
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/anfilat/final-stats/internal/mocks"
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+func TestDiskStoreReload(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Unix(1_600_000_000, 0)
+
+	st := newTestDiskStore(t, dir, 600)
+	appendPoints(st, start, 0, 5)
+	st.Stop(context.Background())
+
+	st = newTestDiskStore(t, dir, 600)
+	defer st.Stop(context.Background())
+
+	point := st.Mean(start.Add(5*time.Second), 5)
+	require.InDelta(t, 2, point.LoadAvg.Load1, 0.0001)
+	require.Equal(t, "sda", point.LoadDisks[0].Name)
+	require.Equal(t, symo.StatusError, point.State.CPU.Status)
+	require.Equal(t, "cpu error", point.State.CPU.Message)
+
+	// после загрузки запись продолжается, а уже сохраненные секунды не дублируются
+	appendPoints(st, start, 3, 7)
+	st.Stop(context.Background())
+
+	st = newTestDiskStore(t, dir, 600)
+	defer st.Stop(context.Background())
+	require.Len(t, st.Points(start.Add(7*time.Second), 7), 7)
+}
+
+func TestDiskStoreDamagedTail(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Unix(1_600_000_000, 0)
+
+	st := newTestDiskStore(t, dir, 600)
+	appendPoints(st, start, 0, 3)
+	st.Stop(context.Background())
+
+	path := singleSegment(t, dir)
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	goodSize := info.Size()
+
+	// падение посреди записи: заголовок есть, данных меньше
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = file.Write([]byte{100, 0, 0, 0, 1, 2, 3, 4, '{', '"'})
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	st = newTestDiskStore(t, dir, 600)
+	require.Len(t, st.Points(start.Add(3*time.Second), 3), 3)
+	st.Stop(context.Background())
+
+	info, err = os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, goodSize, info.Size())
+}
+
+func TestDiskStoreChecksum(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Unix(1_600_000_000, 0)
+
+	st := newTestDiskStore(t, dir, 600)
+	appendPoints(st, start, 0, 3)
+	st.Stop(context.Background())
+
+	path := singleSegment(t, dir)
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	// порча данных последней записи
+	data[len(data)-2] ^= 0xff
+	require.NoError(t, ioutil.WriteFile(path, data, 0o644))
+
+	st = newTestDiskStore(t, dir, 600)
+	defer st.Stop(context.Background())
+	require.Len(t, st.Points(start.Add(3*time.Second), 3), 2)
+}
+
+func TestDiskStoreRemovesOldSegments(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Unix(1_600_000_000, 0)
+
+	st := newTestDiskStore(t, dir, 10)
+	appendPoints(st, start, 0, 5*segmentSeconds)
+	st.Stop(context.Background())
+
+	// текущий сегмент и предыдущий, в котором еще есть точки за последние 10 секунд
+	files, err := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	st = newTestDiskStore(t, dir, 10)
+	defer st.Stop(context.Background())
+	now := start.Add(5 * segmentSeconds * time.Second)
+	require.Len(t, st.Points(now, 10), 10)
+}
+
+func TestDiskStoreWritesInBackground(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Unix(1_600_000_000, 0)
+
+	st := newTestDiskStore(t, dir, 600)
+	defer st.Stop(context.Background())
+	appendPoints(st, start, 0, 3)
+
+	// точки сразу доступны из памяти, а на диск попадают, когда горутина записи опустошит очередь
+	require.Len(t, st.Points(start.Add(3*time.Second), 3), 3)
+	require.Eventually(t, func() bool {
+		files, err := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+		if err != nil || len(files) != 1 {
+			return false
+		}
+		info, err := os.Stat(files[0])
+		return err == nil && info.Size() > 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestDiskStoreDropsWhenQueueIsFull(t *testing.T) {
+	log := new(mocks.Logger)
+	log.On("Debug", "store queue is full, point is not saved").Once()
+
+	// горутина записи не запущена, как будто диск завис
+	st := &diskStore{
+		PointsStore: NewStore(600),
+		mutex:       &sync.Mutex{},
+		seconds:     600,
+		last:        -1,
+		queue:       make(chan entry, 1),
+		log:         log,
+	}
+	start := time.Unix(1_600_000_000, 0)
+	appendPoints(st, start, 0, 2)
+
+	// в память попадают все точки, на диск - только уместившиеся в очередь
+	require.Len(t, st.Points(start.Add(2*time.Second), 2), 2)
+	require.Len(t, st.queue, 1)
+	require.Equal(t, uint64(1), st.DroppedWrites())
+	log.AssertExpectations(t)
+}
+
+func newTestDiskStore(t *testing.T, dir string, seconds int) symo.DiskStore {
+	log := new(mocks.Logger)
+	log.On("Debug", mock.Anything)
+	log.On("Info", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	log.On("Info", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything)
+
+	st, err := NewDiskStore(log, dir, seconds, NewStore(seconds))
+	require.NoError(t, err)
+	return st
+}
+
+func appendPoints(st symo.PointsStore, start time.Time, from, to int) {
+	for i := from; i < to; i++ {
+		st.Append(start.Add(time.Duration(i)*time.Second), symo.Point{
+			LoadAvg:   &symo.LoadAvgData{Load1: float64(i), Load5: 1, Load15: 2},
+			LoadDisks: symo.LoadDisksData{{Name: "sda", Tps: float64(i)}},
+			State: symo.MetricsState{
+				CPU: symo.MetricState{Status: symo.StatusError, Message: "cpu error"},
+			},
+		})
+	}
+}
+
+func singleSegment(t *testing.T, dir string) string {
+	files, err := filepath.Glob(filepath.Join(dir, "*"+segmentExt))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	return files[0]
+}
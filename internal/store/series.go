@@ -0,0 +1,191 @@
+package store
+
+import (
+	"math"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+// наибольшее количество значений метрики за секунду.
+const maxWidth = 3
+
+// series - колонки одной метрики в кольцевом буфере.
+// Для каждой секунды хранятся значения полей (NaN, если значения не было), а также сумма значений и их
+// количество с начала ряда до этой секунды включительно, поэтому сумма за любой интервал внутри буфера
+// считается за O(1). Раз за оборот буфера суммы отсчитываются заново от вытесняемой секунды, поэтому
+// они не растут и не теряют точность за время работы.
+type series struct {
+	size     int64       // емкость буфера в секундах
+	width    int         // количество значений метрики за секунду
+	first    int64       // секунда, с которой ведется ряд
+	last     int64       // последняя записанная секунда
+	lastSeen int64       // последняя секунда, в которой было значение
+	values   [][]float64 // width колонок по size значений
+	sums     [][]float64 // width колонок по size накопленных сумм
+	counts   []int64     // size накопленных количеств
+}
+
+func newSeries(size int64, width int, first int64) *series {
+	s := &series{
+		size:     size,
+		width:    width,
+		first:    first,
+		last:     first - 1,
+		lastSeen: first - 1,
+		values:   make([][]float64, width),
+		sums:     make([][]float64, width),
+		counts:   make([]int64, size),
+	}
+	for i := 0; i < width; i++ {
+		s.values[i] = make([]float64, size)
+		s.sums[i] = make([]float64, size)
+	}
+	return s
+}
+
+// add записывает секунду sec. values == nil означает отсутствие значения в эту секунду.
+// Пропущенные секунды между последней записанной и sec записываются пустыми.
+func (s *series) add(sec int64, values []float64) {
+	if sec <= s.last {
+		return
+	}
+
+	var sums [maxWidth]float64
+	count := int64(0)
+	if s.last >= s.first {
+		slot := s.last % s.size
+		for i := 0; i < s.width; i++ {
+			sums[i] = s.sums[i][slot]
+		}
+		count = s.counts[slot]
+	}
+
+	from := s.last + 1
+	if sec-from >= s.size {
+		from = sec - s.size + 1
+	}
+	for gap := from; gap < sec; gap++ {
+		s.set(gap, nil, &sums, count)
+	}
+
+	if values != nil {
+		for i, value := range values {
+			sums[i] += value
+		}
+		count++
+		s.lastSeen = sec
+	}
+	s.set(sec, values, &sums, count)
+	s.last = sec
+}
+
+func (s *series) set(sec int64, values []float64, sums *[maxWidth]float64, count int64) {
+	slot := sec % s.size
+	if slot == 0 && sec-s.size >= s.first {
+		s.rebase(sums)
+	}
+	for i := 0; i < s.width; i++ {
+		if values != nil {
+			s.values[i][slot] = values[i]
+		} else {
+			s.values[i][slot] = math.NaN()
+		}
+		s.sums[i][slot] = sums[i]
+	}
+	s.counts[slot] = count
+}
+
+// rebase вычитает из накопленных сумм сумму до вытесняемой секунды в слоте 0. Разности сумм внутри
+// буфера от этого не меняются.
+func (s *series) rebase(sums *[maxWidth]float64) {
+	for i := 0; i < s.width; i++ {
+		base := s.sums[i][0]
+		for slot := range s.sums[i] {
+			s.sums[i][slot] -= base
+		}
+		sums[i] -= base
+	}
+}
+
+// bound ограничивает секунду хранимыми в буфере: более поздние заменяются последней записанной,
+// уже вытесненные - самой старой хранимой. Для секунд до начала ряда, пока из него ничего не вытеснено,
+// возвращается false: суммы в буфере отсчитаны от начала ряда только до первого вытеснения.
+func (s *series) bound(sec int64) (int64, bool) {
+	if s.last < s.first {
+		return 0, false
+	}
+	if sec > s.last {
+		sec = s.last
+	}
+	if oldest := s.last - s.size + 1; sec < oldest {
+		sec = oldest
+	}
+	if sec < s.first {
+		return 0, false
+	}
+	return sec, true
+}
+
+// count возвращает количество значений за секунды (from, to].
+func (s *series) count(from, to int64) int64 {
+	to, ok := s.bound(to)
+	if !ok {
+		return 0
+	}
+	result := s.counts[to%s.size]
+	if from, ok := s.bound(from); ok {
+		result -= s.counts[from%s.size]
+	}
+	return result
+}
+
+// mean возвращает среднее за секунды (from, to]. Если значений нет, возвращается nil.
+func (s *series) mean(from, to int64) []float64 {
+	count := s.count(from, to)
+	if count <= 0 {
+		return nil
+	}
+
+	to, _ = s.bound(to)
+	from, fromOK := s.bound(from)
+	result := make([]float64, s.width)
+	for i := range result {
+		sum := s.sums[i][to%s.size]
+		if fromOK {
+			sum -= s.sums[i][from%s.size]
+		}
+		result[i] = sum / float64(count)
+	}
+	return result
+}
+
+// value возвращает значение поля field за секунду sec и признак того, что оно было.
+func (s *series) value(sec int64, field int) (float64, bool) {
+	if sec < s.first || sec > s.last || sec <= s.last-s.size {
+		return 0, false
+	}
+	value := s.values[field][sec%s.size]
+	return value, !math.IsNaN(value)
+}
+
+// window возвращает колонки значений за секунды [from, to], которые должны быть в буфере.
+// Если значений за интервал нет, возвращается nil.
+func (s *series) window(from, to int64) symo.Columns {
+	if s == nil || s.count(from-1, to) <= 0 {
+		return nil
+	}
+	if from < s.first {
+		from = s.first
+	}
+
+	start, end := from%s.size, to%s.size
+	result := make(symo.Columns, s.width)
+	for i, values := range s.values {
+		if start <= end {
+			result[i] = symo.Column{values[start : end+1]}
+		} else {
+			result[i] = symo.Column{values[start:], values[:end+1]}
+		}
+	}
+	return result
+}
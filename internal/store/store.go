@@ -0,0 +1,415 @@
+package store
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+// запас буфера на случай, если сервис клиентов обрабатывает тик с опозданием.
+const reserveSeconds = 10
+
+type store struct {
+	mutex   *sync.RWMutex
+	unit    time.Duration        // длительность слота буфера: секунда или тик короче секунды
+	size    int64                // емкость буфера в слотах
+	last    int64                // последний записанный слот
+	states  []*symo.MetricsState // состояние коллекторов по секундам, nil - точки не было. Кольцевой буфер
+	loadAvg *series              // колонки метрик
+	cpu     *series
+	disks   map[string]*series // по именам дисков. Имя хранится один раз - ключом
+	fs      map[string]*series // по путям файловых систем
+}
+
+// NewStore возвращает хранилище посекундных метрик за последние seconds секунд.
+// Метрики хранятся колонками чисел фиксированного размера, поэтому добавление точки не выделяет память,
+// пока не появится новый диск или файловая система.
+func NewStore(seconds int) symo.PointsStore {
+	return newStore(time.Second, seconds)
+}
+
+// NewTickStore возвращает хранилище точек тиков длительностью tick за последние seconds секунд.
+// Оно устроено так же, как посекундное, но M в запросах к нему задается в тиках.
+func NewTickStore(tick time.Duration, seconds int) symo.PointsStore {
+	return newStore(tick, seconds)
+}
+
+func newStore(unit time.Duration, seconds int) *store {
+	perSecond := int64(time.Second / unit)
+	size := (int64(seconds)+reserveSeconds)*perSecond + 1
+	return &store{
+		mutex:  &sync.RWMutex{},
+		unit:   unit,
+		size:   size,
+		last:   -1,
+		states: make([]*symo.MetricsState, size),
+		disks:  make(map[string]*series),
+		fs:     make(map[string]*series),
+	}
+}
+
+func (s *store) Append(tm time.Time, point symo.Point) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	sec := s.slot(tm)
+	if s.last >= 0 && sec <= s.last {
+		return
+	}
+
+	from := s.last + 1
+	if s.last < 0 || sec-from >= s.size {
+		from = sec - s.size + 1
+	}
+	if from < 0 {
+		from = 0
+	}
+	state := s.lastStored()
+	for gap := from; gap < sec; gap++ {
+		s.states[gap%s.size] = nil
+	}
+	// состояние меняется редко, поэтому одинаковые состояния подряд хранятся одним экземпляром
+	if state == nil || *state != point.State {
+		changed := point.State
+		state = &changed
+	}
+	s.states[sec%s.size] = state
+	s.last = sec
+
+	s.appendLoadAvg(sec, point.LoadAvg)
+	s.appendCPU(sec, point.CPU)
+	s.appendLoadDisks(sec, point.LoadDisks)
+	s.appendUsedFS(sec, point.UsedFS)
+}
+
+// Resize переносит точки в буфер на seconds секунд. При уменьшении остаются только последние точки.
+func (s *store) Resize(seconds int) {
+	resized := newStore(s.unit, seconds)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.last >= 0 {
+		from, last := s.interval(s.slotTime(s.last+1), int(resized.size))
+		disks := sortedNames(s.disks)
+		paths := sortedNames(s.fs)
+		for sec := from; sec <= last; sec++ {
+			if state := s.states[sec%s.size]; state != nil {
+				resized.Append(s.slotTime(sec), s.point(sec, *state, disks, paths))
+			}
+		}
+	}
+
+	s.size = resized.size
+	s.last = resized.last
+	s.states = resized.states
+	s.loadAvg = resized.loadAvg
+	s.cpu = resized.cpu
+	s.disks = resized.disks
+	s.fs = resized.fs
+}
+
+// Len считает точки в буфере, включая запас сверх времени хранения.
+func (s *store) Len() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	count := 0
+	for _, state := range s.states {
+		if state != nil {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *store) appendLoadAvg(sec int64, data *symo.LoadAvgData) {
+	if data == nil {
+		s.loadAvg = s.appendSeries(s.loadAvg, sec, nil, 3)
+		return
+	}
+	values := [...]float64{data.Load1, data.Load5, data.Load15}
+	s.loadAvg = s.appendSeries(s.loadAvg, sec, values[:], 3)
+}
+
+func (s *store) appendCPU(sec int64, data *symo.CPUData) {
+	if data == nil {
+		s.cpu = s.appendSeries(s.cpu, sec, nil, 3)
+		return
+	}
+	values := [...]float64{data.User, data.System, data.Idle}
+	s.cpu = s.appendSeries(s.cpu, sec, values[:], 3)
+}
+
+func (s *store) appendLoadDisks(sec int64, data symo.LoadDisksData) {
+	for _, disk := range data {
+		values := [...]float64{disk.Tps, disk.KBRead, disk.KBWrite}
+		s.appendNamed(s.disks, disk.Name, sec, values[:], 3)
+	}
+	s.completeNamed(s.disks, sec)
+}
+
+func (s *store) appendUsedFS(sec int64, data symo.UsedFSData) {
+	for _, fs := range data {
+		values := [...]float64{fs.UsedSpace, fs.UsedInode}
+		s.appendNamed(s.fs, fs.Path, sec, values[:], 2)
+	}
+	s.completeNamed(s.fs, sec)
+}
+
+func (s *store) appendSeries(ser *series, sec int64, values []float64, width int) *series {
+	if ser == nil {
+		if values == nil {
+			return nil
+		}
+		ser = newSeries(s.size, width, sec)
+	}
+	ser.add(sec, values)
+	return ser
+}
+
+func (s *store) appendNamed(list map[string]*series, name string, sec int64, values []float64, width int) {
+	ser, ok := list[name]
+	if !ok {
+		ser = newSeries(s.size, width, sec)
+		list[name] = ser
+	}
+	ser.add(sec, values)
+}
+
+// completeNamed дописывает пустую секунду в ряды, для которых в точке не было значений.
+// Ряды дисков и файловых систем, которых нет дольше времени хранения, удаляются.
+func (s *store) completeNamed(list map[string]*series, sec int64) {
+	for name, ser := range list {
+		ser.add(sec, nil)
+		if sec-ser.lastSeen >= s.size {
+			delete(list, name)
+		}
+	}
+}
+
+func (s *store) Mean(to time.Time, m int) symo.Point {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	// слоты (from, last] - последние M слотов перед to
+	last := s.slot(to) - 1
+	from := last - int64(m)
+
+	result := symo.Point{
+		State: s.lastState(from, last),
+	}
+
+	if s.loadAvg != nil {
+		if values := s.loadAvg.mean(from, last); values != nil {
+			result.LoadAvg = &symo.LoadAvgData{
+				Load1:  values[0],
+				Load5:  values[1],
+				Load15: values[2],
+			}
+		}
+	}
+	if s.cpu != nil {
+		if values := s.cpu.mean(from, last); values != nil {
+			result.CPU = &symo.CPUData{
+				User:   values[0],
+				System: values[1],
+				Idle:   values[2],
+			}
+		}
+	}
+	for _, name := range sortedNames(s.disks) {
+		if values := s.disks[name].mean(from, last); values != nil {
+			result.LoadDisks = append(result.LoadDisks, symo.DiskData{
+				Name:    name,
+				Tps:     values[0],
+				KBRead:  values[1],
+				KBWrite: values[2],
+			})
+		}
+	}
+	for _, path := range sortedNames(s.fs) {
+		if values := s.fs[path].mean(from, last); values != nil {
+			result.UsedFS = append(result.UsedFS, symo.FSData{
+				Path:      path,
+				UsedSpace: values[0],
+				UsedInode: values[1],
+			})
+		}
+	}
+
+	return result
+}
+
+// состояние коллекторов берется из последней секунды интервала, за которую есть точка.
+// Если точек за интервал нет, метрики считаются устаревшими.
+func (s *store) lastState(from, last int64) symo.MetricsState {
+	for sec := s.clamp(last); s.last >= 0 && sec > from && sec > s.last-s.size; sec-- {
+		if state := s.states[sec%s.size]; state != nil {
+			return *state
+		}
+	}
+
+	stale := symo.MetricState{Status: symo.StatusStale}
+	return symo.MetricsState{
+		LoadAvg:   stale,
+		CPU:       stale,
+		LoadDisks: stale,
+		UsedFS:    stale,
+	}
+}
+
+// Points собирает точки из колонок. Имена дисков и файловых систем во всех точках - одни и те же строки.
+func (s *store) Points(to time.Time, m int) []symo.TimedPoint {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	from, last := s.interval(to, m)
+	disks := sortedNames(s.disks)
+	paths := sortedNames(s.fs)
+
+	var result []symo.TimedPoint
+	for sec := from; sec <= last; sec++ {
+		if state := s.states[sec%s.size]; state != nil {
+			result = append(result, symo.TimedPoint{
+				Time:  s.slotTime(sec),
+				Point: s.point(sec, *state, disks, paths),
+			})
+		}
+	}
+	return result
+}
+
+func (s *store) point(sec int64, state symo.MetricsState, disks, paths []string) symo.Point {
+	result := symo.Point{State: state}
+
+	if s.loadAvg != nil {
+		if load1, ok := s.loadAvg.value(sec, 0); ok {
+			load5, _ := s.loadAvg.value(sec, 1)
+			load15, _ := s.loadAvg.value(sec, 2)
+			result.LoadAvg = &symo.LoadAvgData{Load1: load1, Load5: load5, Load15: load15}
+		}
+	}
+	if s.cpu != nil {
+		if user, ok := s.cpu.value(sec, 0); ok {
+			system, _ := s.cpu.value(sec, 1)
+			idle, _ := s.cpu.value(sec, 2)
+			result.CPU = &symo.CPUData{User: user, System: system, Idle: idle}
+		}
+	}
+	for _, name := range disks {
+		ser := s.disks[name]
+		if tps, ok := ser.value(sec, 0); ok {
+			kbRead, _ := ser.value(sec, 1)
+			kbWrite, _ := ser.value(sec, 2)
+			result.LoadDisks = append(result.LoadDisks, symo.DiskData{
+				Name:    name,
+				Tps:     tps,
+				KBRead:  kbRead,
+				KBWrite: kbWrite,
+			})
+		}
+	}
+	for _, path := range paths {
+		ser := s.fs[path]
+		if usedSpace, ok := ser.value(sec, 0); ok {
+			usedInode, _ := ser.value(sec, 1)
+			result.UsedFS = append(result.UsedFS, symo.FSData{
+				Path:      path,
+				UsedSpace: usedSpace,
+				UsedInode: usedInode,
+			})
+		}
+	}
+	return result
+}
+
+// Window держит блокировку на чтение, пока fn читает колонки, поэтому новые секунды их не перезаписывают.
+func (s *store) Window(to time.Time, m int, fn func(symo.Window)) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	from, last := s.interval(to, m)
+	if from > last {
+		fn(symo.Window{})
+		return
+	}
+
+	fn(symo.Window{
+		LoadAvg:   s.loadAvg.window(from, last),
+		CPU:       s.cpu.window(from, last),
+		LoadDisks: namedWindow(s.disks, from, last),
+		UsedFS:    namedWindow(s.fs, from, last),
+	})
+}
+
+func namedWindow(list map[string]*series, from, last int64) []symo.NamedColumns {
+	var result []symo.NamedColumns
+	for _, name := range sortedNames(list) {
+		if columns := list[name].window(from, last); columns != nil {
+			result = append(result, symo.NamedColumns{Name: name, Columns: columns})
+		}
+	}
+	return result
+}
+
+// interval возвращает хранимые слоты [from, last] из M слотов до to.
+func (s *store) interval(to time.Time, m int) (int64, int64) {
+	if s.last < 0 {
+		return 0, -1
+	}
+
+	last := s.clamp(s.slot(to) - 1)
+	from := s.slot(to) - int64(m)
+	if oldest := s.last - s.size + 1; from < oldest {
+		from = oldest
+	}
+	// секунд до начала эпохи нет, а их номера дали бы отрицательный индекс в буфере
+	if from < 0 {
+		from = 0
+	}
+	return from, last
+}
+
+// slot возвращает номер слота, в который попадает время.
+func (s *store) slot(tm time.Time) int64 {
+	if s.unit == time.Second {
+		return tm.Unix()
+	}
+	return tm.UnixNano() / int64(s.unit)
+}
+
+// slotTime возвращает время начала слота.
+func (s *store) slotTime(slot int64) time.Time {
+	if s.unit == time.Second {
+		return time.Unix(slot, 0)
+	}
+	return time.Unix(0, slot*int64(s.unit))
+}
+
+// lastStored возвращает состояние коллекторов последней записанной точки.
+func (s *store) lastStored() *symo.MetricsState {
+	if s.last < 0 {
+		return nil
+	}
+	return s.states[s.last%s.size]
+}
+
+// clamp ограничивает секунду последней записанной.
+func (s *store) clamp(sec int64) int64 {
+	if sec > s.last {
+		return s.last
+	}
+	return sec
+}
+
+func sortedNames(list map[string]*series) []string {
+	names := make([]string, 0, len(list))
+	for name := range list {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,342 @@
+package store
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+func TestStoreMean(t *testing.T) {
+	start := time.Unix(1_600_000_000, 0)
+	st := NewStore(10)
+
+	for i := 0; i < 5; i++ {
+		st.Append(start.Add(time.Duration(i)*time.Second), symo.Point{
+			LoadAvg: &symo.LoadAvgData{Load1: float64(i), Load5: 1, Load15: 2},
+			CPU:     &symo.CPUData{User: float64(10 * i), System: 5, Idle: 50},
+		})
+	}
+
+	now := start.Add(5 * time.Second)
+
+	// последние 2 секунды: 3 и 4
+	point := st.Mean(now, 2)
+	require.InDelta(t, 3.5, point.LoadAvg.Load1, 0.0001)
+	require.InDelta(t, 35, point.CPU.User, 0.0001)
+	require.Nil(t, point.LoadDisks)
+	require.Nil(t, point.UsedFS)
+
+	// интервал больше накопленных данных
+	point = st.Mean(now, 10)
+	require.InDelta(t, 2, point.LoadAvg.Load1, 0.0001)
+
+	// интервал в будущем относительно собранных данных не содержит новых точек
+	point = st.Mean(now.Add(2*time.Second), 3)
+	require.InDelta(t, 4, point.LoadAvg.Load1, 0.0001)
+	point = st.Mean(now.Add(5*time.Second), 3)
+	require.Nil(t, point.LoadAvg)
+	require.Equal(t, symo.StatusStale, point.State.CPU.Status)
+}
+
+func TestStoreEmpty(t *testing.T) {
+	st := NewStore(10)
+	now := time.Unix(1_600_000_000, 0)
+
+	point := st.Mean(now, 5)
+	require.Nil(t, point.LoadAvg)
+	require.Nil(t, point.CPU)
+	require.Equal(t, symo.StatusStale, point.State.LoadAvg.Status)
+	require.Empty(t, st.Points(now, 5))
+
+	// время около начала эпохи, например у часов-заглушки
+	require.Empty(t, st.Points(time.Unix(0, 0), 5))
+	require.Empty(t, windowOf(st, time.Unix(0, 0), 5))
+	st.Append(time.Unix(2, 0), symo.Point{CPU: &symo.CPUData{User: 1}})
+	require.Len(t, st.Points(time.Unix(3, 0), 10), 1)
+	require.Equal(t, 1, windowOf(st, time.Unix(3, 0), 10).CPU[0].Len())
+}
+
+func TestStoreState(t *testing.T) {
+	start := time.Unix(1_600_000_000, 0)
+	st := NewStore(10)
+
+	failed := symo.MetricsState{CPU: symo.MetricState{Status: symo.StatusError, Message: "cpu"}}
+	st.Append(start, symo.Point{})
+	st.Append(start.Add(time.Second), symo.Point{State: failed})
+	// пропущенная секунда
+	st.Append(start.Add(3*time.Second), symo.Point{})
+
+	require.Equal(t, symo.MetricsState{}, st.Mean(start.Add(4*time.Second), 2).State)
+	require.Equal(t, failed, st.Mean(start.Add(3*time.Second), 2).State)
+	// в интервале только пропущенная секунда
+	require.Equal(t, symo.StatusStale, st.Mean(start.Add(3*time.Second), 1).State.CPU.Status)
+	require.Len(t, st.Points(start.Add(4*time.Second), 4), 3)
+	require.Len(t, st.Points(start.Add(4*time.Second), 2), 1)
+}
+
+func TestStoreChangedDisks(t *testing.T) {
+	start := time.Unix(1_600_000_000, 0)
+	st := NewStore(5)
+
+	st.Append(start, symo.Point{
+		LoadDisks: symo.LoadDisksData{{Name: "sda", Tps: 1}, {Name: "sdb", Tps: 10}},
+		UsedFS:    symo.UsedFSData{{Path: "/", UsedSpace: 10, UsedInode: 20}},
+	})
+	st.Append(start.Add(time.Second), symo.Point{
+		LoadDisks: symo.LoadDisksData{{Name: "sda", Tps: 3}},
+		UsedFS:    symo.UsedFSData{{Path: "/", UsedSpace: 20, UsedInode: 30}, {Path: "/data", UsedSpace: 1}},
+	})
+
+	point := st.Mean(start.Add(2*time.Second), 2)
+	require.Equal(t, symo.LoadDisksData{{Name: "sda", Tps: 2}, {Name: "sdb", Tps: 10}}, point.LoadDisks)
+	require.Equal(t, symo.UsedFSData{{Path: "/", UsedSpace: 15, UsedInode: 25}, {Path: "/data", UsedSpace: 1}},
+		point.UsedFS)
+
+	point = st.Mean(start.Add(2*time.Second), 1)
+	require.Equal(t, symo.LoadDisksData{{Name: "sda", Tps: 3}}, point.LoadDisks)
+
+	// диск, которого нет дольше времени хранения, удаляется
+	for i := 2; i < 30; i++ {
+		st.Append(start.Add(time.Duration(i)*time.Second), symo.Point{})
+	}
+	require.Empty(t, st.(*store).disks)
+	require.Empty(t, st.(*store).fs)
+}
+
+func TestStoreResize(t *testing.T) {
+	start := time.Unix(1_600_000_000, 0)
+	st := NewStore(30)
+
+	for i := 0; i < 30; i++ {
+		if i == 25 {
+			continue
+		}
+		st.Append(start.Add(time.Duration(i)*time.Second), symo.Point{
+			CPU:       &symo.CPUData{User: float64(i)},
+			LoadDisks: symo.LoadDisksData{{Name: "sda", Tps: float64(i)}},
+		})
+	}
+	now := start.Add(30 * time.Second)
+
+	// остаются последние 5 секунд и запас буфера - секунды 14..29 без пропущенной 25
+	st.Resize(5)
+	points := st.Points(now, 30)
+	require.Len(t, points, 15)
+	require.True(t, start.Add(14*time.Second).Equal(points[0].Time))
+	require.Equal(t, symo.LoadDisksData{{Name: "sda", Tps: 29}}, st.Mean(now, 1).LoadDisks)
+	require.InDelta(t, 28.5, st.Mean(now, 2).CPU.User, 0.0001)
+
+	// при увеличении точки сохраняются, а новые больше не вытесняют старые
+	st.Resize(60)
+	require.Len(t, st.Points(now, 60), 15)
+	for i := 30; i < 60; i++ {
+		st.Append(start.Add(time.Duration(i)*time.Second), symo.Point{CPU: &symo.CPUData{User: float64(i)}})
+	}
+	require.Len(t, st.Points(start.Add(60*time.Second), 60), 45)
+}
+
+func TestStoreWindow(t *testing.T) {
+	start := time.Unix(1_600_000_000, 0)
+	st := NewStore(5)
+
+	// буфер переполняется, окно попадает на стык кольца
+	for i := 0; i < 20; i++ {
+		point := symo.Point{CPU: &symo.CPUData{User: float64(i)}}
+		if i%2 == 0 {
+			point.LoadDisks = symo.LoadDisksData{{Name: "sda", Tps: float64(i)}}
+		}
+		if i == 19 {
+			point.CPU = nil
+		}
+		st.Append(start.Add(time.Duration(i)*time.Second), point)
+	}
+	now := start.Add(20 * time.Second)
+
+	collect := func(column symo.Column) []float64 {
+		var result []float64
+		column.Each(func(value float64) {
+			result = append(result, value)
+		})
+		return result
+	}
+
+	window := windowOf(st, now, 5)
+	require.Nil(t, window.LoadAvg)
+	require.Equal(t, []float64{15, 16, 17, 18}, collect(window.CPU[0]))
+	require.Equal(t, 5, len(window.CPU[0][0])+len(window.CPU[0][1]))
+	require.Len(t, window.LoadDisks, 1)
+	require.Equal(t, "sda", window.LoadDisks[0].Name)
+	require.Equal(t, []float64{16, 18}, collect(window.LoadDisks[0].Columns[0]))
+	require.Equal(t, 2, window.LoadDisks[0].Columns[0].Len())
+
+	// значения, которых нет в интервале, не попадают в окно
+	require.Nil(t, windowOf(st, now, 1).CPU)
+	require.Nil(t, windowOf(st, now, 1).LoadDisks)
+	require.Equal(t, symo.Window{}, windowOf(st, start, 5))
+
+	// точки собираются из тех же колонок
+	points := st.Points(now, 2)
+	require.Equal(t, []symo.TimedPoint{
+		{
+			Time:  start.Add(18 * time.Second),
+			Point: symo.Point{CPU: &symo.CPUData{User: 18}, LoadDisks: symo.LoadDisksData{{Name: "sda", Tps: 18}}},
+		},
+		{Time: start.Add(19 * time.Second)},
+	}, points)
+}
+
+func TestStoreWindowBlocksAppend(t *testing.T) {
+	start := time.Unix(1_600_000_000, 0)
+	st := NewStore(5)
+	st.Append(start, symo.Point{CPU: &symo.CPUData{User: 1}})
+
+	// пока окно читается, новая секунда не пишется в его колонки
+	appended := make(chan struct{})
+	st.Window(start.Add(time.Second), 1, func(window symo.Window) {
+		go func() {
+			st.Append(start.Add(time.Second), symo.Point{CPU: &symo.CPUData{User: 2}})
+			close(appended)
+		}()
+
+		select {
+		case <-appended:
+			t.Error("point is appended while the window is read")
+		case <-time.After(50 * time.Millisecond):
+		}
+		require.Equal(t, 1, window.CPU[0].Len())
+	})
+	<-appended
+	require.Equal(t, 2, windowOf(st, start.Add(2*time.Second), 2).CPU[0].Len())
+}
+
+// сравнение средних из префиксных сумм с прямым подсчетом на случайных данных с пропусками.
+func TestStoreMeanRandom(t *testing.T) {
+	const seconds = 60
+	start := time.Unix(1_600_000_000, 0)
+	st := NewStore(seconds)
+	//nolint:gosec
+	rnd := rand.New(rand.NewSource(1))
+
+	type value struct {
+		tm  time.Time
+		cpu *symo.CPUData
+	}
+	var values []value
+
+	now := start
+	for i := 0; i < 10*seconds; i++ {
+		now = now.Add(time.Duration(1+rnd.Intn(2)) * time.Second)
+
+		var cpu *symo.CPUData
+		if rnd.Float64() < 0.8 {
+			cpu = &symo.CPUData{User: rnd.Float64() * 100, System: rnd.Float64(), Idle: rnd.Float64()}
+		}
+		st.Append(now, symo.Point{CPU: cpu})
+		values = append(values, value{tm: now, cpu: cpu})
+
+		to := now.Add(time.Second)
+		m := 1 + rnd.Intn(seconds)
+		from := to.Add(-time.Duration(m) * time.Second)
+
+		count := 0
+		sum := 0.0
+		for _, v := range values {
+			if v.cpu != nil && !v.tm.Before(from) && v.tm.Before(to) {
+				count++
+				sum += v.cpu.User
+			}
+		}
+
+		point := st.Mean(to, m)
+		if count == 0 {
+			require.Nilf(t, point.CPU, "step %d, m %d", i, m)
+			continue
+		}
+		require.NotNilf(t, point.CPU, "step %d, m %d", i, m)
+		require.InDeltaf(t, sum/float64(count), point.CPU.User, 1e-6, "step %d, m %d", i, m)
+	}
+}
+
+func TestStoreMeanPrecision(t *testing.T) {
+	const seconds = 10
+	start := time.Unix(1_600_000_000, 0)
+	st := NewStore(seconds)
+
+	// большие значения за много оборотов буфера не должны влиять на среднее после их вытеснения,
+	// буфер больше seconds на запас
+	now := start
+	for i := 0; i < 100*seconds; i++ {
+		st.Append(now, symo.Point{CPU: &symo.CPUData{User: 1e15}})
+		now = now.Add(time.Second)
+	}
+	for i := 0; i < 10*seconds; i++ {
+		st.Append(now, symo.Point{CPU: &symo.CPUData{User: 1.5}})
+		now = now.Add(time.Second)
+	}
+
+	require.Equal(t, 1.5, st.Mean(now, 5).CPU.User)
+	require.Equal(t, 1.5, st.Mean(now, 2*seconds).CPU.User)
+}
+
+func BenchmarkStoreMean(b *testing.B) {
+	const seconds = 3600
+	start := time.Unix(1_600_000_000, 0)
+	st := NewStore(seconds)
+
+	for i := 0; i < seconds; i++ {
+		st.Append(start.Add(time.Duration(i)*time.Second), symo.Point{
+			LoadAvg:   &symo.LoadAvgData{Load1: 1, Load5: 2, Load15: 3},
+			CPU:       &symo.CPUData{User: 1, System: 2, Idle: 3},
+			LoadDisks: symo.LoadDisksData{{Name: "sda", Tps: 1}, {Name: "sdb", Tps: 2}},
+			UsedFS:    symo.UsedFSData{{Path: "/", UsedSpace: 1}, {Path: "/data", UsedSpace: 2}},
+		})
+	}
+	now := start.Add(seconds * time.Second)
+
+	for _, m := range []int{1, 60, 600, 3600} {
+		b.Run(fmt.Sprintf("m=%d", m), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				st.Mean(now, m)
+			}
+		})
+	}
+}
+
+func TestTickStore(t *testing.T) {
+	tick := 250 * time.Millisecond
+	start := time.Unix(1_600_000_000, 0)
+	st := NewTickStore(tick, 10)
+
+	for i := 0; i < 8; i++ {
+		st.Append(start.Add(time.Duration(i)*tick), symo.Point{
+			CPU: &symo.CPUData{User: float64(i)},
+		})
+	}
+
+	now := start.Add(8 * tick)
+
+	// последние 2 тика: 6 и 7
+	point := st.Mean(now, 2)
+	require.InDelta(t, 6.5, point.CPU.User, 0.0001)
+
+	points := st.Points(now, 3)
+	require.Len(t, points, 3)
+	require.True(t, start.Add(5*tick).Equal(points[0].Time))
+	require.True(t, start.Add(7*tick).Equal(points[2].Time))
+	require.Equal(t, 2, windowOf(st, now, 2).CPU[0].Len())
+	require.Equal(t, 8, st.Len())
+}
+
+// windowOf возвращает окно хранилища. Тест не пишет в хранилище, пока читает окно.
+func windowOf(st symo.PointsReader, to time.Time, m int) symo.Window {
+	var result symo.Window
+	st.Window(to, m, func(window symo.Window) {
+		result = window
+	})
+	return result
+}
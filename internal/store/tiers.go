@@ -0,0 +1,164 @@
+package store
+
+import (
+	"sync"
+	"time"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+// tier - уровень хранения с разрешением res секунд. Его буфер устроен так же, как посекундный,
+// но считает в слотах по res секунд: слот n содержит точку, усредненную за секунды [n*res, (n+1)*res).
+type tier struct {
+	res       int64
+	retention int64        // секунд
+	points    *store       // усредненные точки завершенных слотов
+	slot      int64        // текущий, еще не завершенный слот
+	acc       *symo.Rollup // накопление точек текущего слота
+}
+
+type tieredStore struct {
+	mutex   *sync.Mutex
+	base    *store // посекундные точки
+	seconds int64
+	tiers   []*tier
+	last    int64
+}
+
+// NewTieredStore возвращает хранилище посекундных метрик за seconds секунд и уровней с усредненными метриками.
+// Точки уровня вычисляются, когда завершается его слот. Mean и Points берут данные с самого подробного уровня,
+// хранящего весь запрошенный интервал. Уровни хранятся только в памяти, после перезапуска они заполняются
+// из посекундных точек, загруженных с диска.
+func NewTieredStore(seconds int, tiers []symo.TierConf) symo.PointsStore {
+	if len(tiers) == 0 {
+		return NewStore(seconds)
+	}
+
+	s := &tieredStore{
+		mutex:   &sync.Mutex{},
+		base:    NewStore(seconds).(*store),
+		seconds: int64(seconds),
+		last:    -1,
+	}
+	for _, conf := range tiers {
+		res := int64(conf.Resolution / time.Second)
+		retention := int64(conf.Retention / time.Second)
+		slots := (retention + res - 1) / res
+		s.tiers = append(s.tiers, &tier{
+			res:       res,
+			retention: retention,
+			points:    NewStore(int(slots)).(*store),
+		})
+	}
+	return s
+}
+
+func (s *tieredStore) Append(tm time.Time, point symo.Point) {
+	s.base.Append(tm, point)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	sec := tm.Unix()
+	if sec <= s.last {
+		return
+	}
+	s.last = sec
+
+	for _, t := range s.tiers {
+		t.add(sec, point)
+	}
+}
+
+func (t *tier) add(sec int64, point symo.Point) {
+	slot := sec / t.res
+	if t.acc != nil && slot != t.slot {
+		t.points.Append(time.Unix(t.slot, 0), t.acc.Mean())
+		t.acc = nil
+	}
+	if t.acc == nil {
+		t.acc = symo.NewRollup()
+		t.slot = slot
+	}
+	t.acc.Add(point)
+}
+
+// Mean для интервала внутри посекундного буфера считается по нему, для большего - по завершенным слотам уровня,
+// поэтому данные текущего слота в таком среднем еще не участвуют.
+func (s *tieredStore) Mean(to time.Time, m int) symo.Point {
+	t := s.pick(m)
+	if t == nil {
+		return s.base.Mean(to, m)
+	}
+	return t.points.Mean(t.slotTime(to), t.slots(m))
+}
+
+// Points уровня возвращает точки слотов со временем их начала.
+func (s *tieredStore) Points(to time.Time, m int) []symo.TimedPoint {
+	t := s.pick(m)
+	if t == nil {
+		return s.base.Points(to, m)
+	}
+
+	points := t.points.Points(t.slotTime(to), t.slots(m))
+	for i := range points {
+		points[i].Time = time.Unix(points[i].Time.Unix()*t.res, 0)
+	}
+	return points
+}
+
+// Window для интервала больше посекундного буфера передает колонки уровня, по значению на слот.
+func (s *tieredStore) Window(to time.Time, m int, fn func(symo.Window)) {
+	t := s.pick(m)
+	if t == nil {
+		s.base.Window(to, m, fn)
+		return
+	}
+	t.points.Window(t.slotTime(to), t.slots(m), fn)
+}
+
+// Resize меняет время хранения посекундных метрик. Уровни не меняются.
+func (s *tieredStore) Resize(seconds int) {
+	s.base.Resize(seconds)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.seconds = int64(seconds)
+}
+
+// Len возвращает число посекундных точек вместе с точками завершенных слотов всех уровней.
+func (s *tieredStore) Len() int {
+	count := s.base.Len()
+	for _, t := range s.tiers {
+		count += t.points.Len()
+	}
+	return count
+}
+
+// pick возвращает самый подробный уровень, хранящий M секунд, или nil, если хватает посекундного буфера.
+func (s *tieredStore) pick(m int) *tier {
+	s.mutex.Lock()
+	seconds := s.seconds
+	s.mutex.Unlock()
+
+	if int64(m) <= seconds {
+		return nil
+	}
+	for _, t := range s.tiers {
+		if int64(m) <= t.retention {
+			return t
+		}
+	}
+	return s.tiers[len(s.tiers)-1]
+}
+
+// slotTime переводит время в номер слота уровня, считая, что слот, в котором находится to, не завершен.
+func (t *tier) slotTime(to time.Time) time.Time {
+	return time.Unix(to.Unix()/t.res, 0)
+}
+
+// slots возвращает количество слотов, покрывающих M секунд.
+func (t *tier) slots(m int) int {
+	return int((int64(m) + t.res - 1) / t.res)
+}
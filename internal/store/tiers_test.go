@@ -0,0 +1,62 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/anfilat/final-stats/internal/symo"
+)
+
+func TestTieredStore(t *testing.T) {
+	// начало минуты, чтобы слоты обоих уровней начинались с первой точки
+	start := time.Unix(1_599_999_960, 0)
+	st := NewTieredStore(10, []symo.TierConf{
+		{Resolution: 10 * time.Second, Retention: 100 * time.Second},
+		{Resolution: time.Minute, Retention: time.Hour},
+	})
+
+	for i := 0; i < 65; i++ {
+		point := symo.Point{
+			CPU:   &symo.CPUData{User: float64(i)},
+			State: symo.MetricsState{CPU: symo.MetricState{Status: symo.StatusOK}},
+		}
+		// диск есть только в четных секундах
+		if i%2 == 0 {
+			point.LoadDisks = symo.LoadDisksData{{Name: "sda", Tps: float64(i)}}
+		}
+		st.Append(start.Add(time.Duration(i)*time.Second), point)
+	}
+	now := start.Add(65 * time.Second)
+
+	// посекундный буфер: секунды 60..64
+	point := st.Mean(now, 5)
+	require.InDelta(t, 62, point.CPU.User, 0.0001)
+
+	// уровень 10 секунд: слоты 30..39, 40..49, 50..59, слот 60..69 еще не завершен
+	point = st.Mean(now, 30)
+	require.InDelta(t, 44.5, point.CPU.User, 0.0001)
+	require.InDelta(t, 44, point.LoadDisks[0].Tps, 0.0001)
+	require.Equal(t, symo.StatusOK, point.State.CPU.Status)
+
+	points := st.Points(now, 30)
+	require.Len(t, points, 3)
+	require.InDelta(t, 34.5, points[0].CPU.User, 0.0001)
+	require.True(t, start.Add(30*time.Second).Equal(points[0].Time))
+
+	// уровень минуты: один завершенный слот 0..59
+	point = st.Mean(now, 120)
+	require.InDelta(t, 29.5, point.CPU.User, 0.0001)
+
+	// интервал больше всех уровней берется с самого грубого
+	require.Len(t, st.Points(now, 7200), 1)
+
+	// посекундный буфер с запасом на 21 секунду, 6 слотов по 10 секунд и слот минуты
+	require.Equal(t, 21+6+1, st.Len())
+}
+
+func TestTieredStoreWithoutTiers(t *testing.T) {
+	_, ok := NewTieredStore(10, nil).(*store)
+	require.True(t, ok)
+}
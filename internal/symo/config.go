@@ -3,7 +3,12 @@ package symo
 import (
 	"errors"
 	"fmt"
+	"net"
+	"os"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -11,6 +16,12 @@ import (
 // MaxSeconds - дефолтное время хранения метрик.
 const MaxSeconds = 600
 
+// TickSeconds - сколько секунд хранятся точки тиков, если тик короче секунды.
+const TickSeconds = 60
+
+// наименьший тик сервиса сбора метрик.
+const minTick = 100 * time.Millisecond
+
 // NewConfig возвращает текущую конфигурацию.
 func NewConfig(configFile string) (Config, error) {
 	config := Config{}
@@ -30,6 +41,10 @@ func NewConfig(configFile string) (Config, error) {
 	if err := v.Unmarshal(&config); err != nil {
 		return config, fmt.Errorf("failed to unmarshal configuration: %w", err)
 	}
+	config.Clients.setDefaults(config.App.Tick)
+	for i := range config.Exporters {
+		config.Exporters[i].setDefaults()
+	}
 
 	if err := config.Validate(); err != nil {
 		return config, fmt.Errorf("failed to validate configuration: %w", err)
@@ -43,8 +58,35 @@ func configure(v *viper.Viper) {
 	v.AutomaticEnv()
 
 	v.SetDefault("app.maxSeconds", MaxSeconds)
+	v.SetDefault("app.tick", time.Second)
 	v.SetDefault("log.level", "INFO")
+	v.SetDefault("log.format", "text")
+	v.SetDefault("log.file", "")
+	v.SetDefault("log.maxSize", 100)
+	v.SetDefault("log.maxBackups", 3)
+	v.SetDefault("log.syslog.enabled", false)
+	v.SetDefault("log.syslog.network", "")
+	v.SetDefault("log.syslog.address", "")
+	v.SetDefault("log.syslog.tag", "symo")
+	v.SetDefault("store.backend", "memory")
+	v.SetDefault("store.dir", "data")
 	v.SetDefault("server.port", "8000")
+	v.SetDefault("server.socketMode", "0660")
+	v.SetDefault("server.reflection", false)
+	v.SetDefault("auth.enabled", false)
+	v.SetDefault("clients.queueSize", 100)
+	v.SetDefault("clients.policy", "drop-newest")
+	v.SetDefault("clients.dropLimit", 10)
+	v.SetDefault("clients.maxClients", 0)
+	v.SetDefault("clients.maxPerPeer", 0)
+	v.SetDefault("clients.subscriptionRate", 0)
+	v.SetDefault("clients.subscriptionBurst", 0)
+	v.SetDefault("http.enabled", false)
+	v.SetDefault("http.port", "8080")
+	v.SetDefault("http.dashboard", true)
+	v.SetDefault("prometheus.enabled", false)
+	v.SetDefault("prometheus.port", "9100")
+	v.SetDefault("prometheus.m", 15)
 	v.SetDefault("metric.loadavg", true)
 	v.SetDefault("metric.cpu", true)
 	v.SetDefault("metric.loaddisks", true)
@@ -53,58 +95,667 @@ func configure(v *viper.Viper) {
 
 // Config содержит конфигурацию программы.
 type Config struct {
-	App    AppConf
-	Log    LoggerConf
-	Server ServerConf
-	Metric MetricConf
+	App        AppConf
+	Log        LoggerConf
+	Store      StoreConf
+	Server     ServerConf
+	Auth       AuthConf
+	Clients    ClientsConf
+	HTTP       HTTPConf
+	Prometheus PrometheusConf
+	Exporters  []ExporterConf
+	Metric     MetricConf
 }
 
 func (c Config) Validate() error {
 	if err := c.App.Validate(); err != nil {
 		return err
 	}
+	if err := c.Log.Validate(); err != nil {
+		return err
+	}
+	if err := c.Store.Validate(); err != nil {
+		return err
+	}
 	if err := c.Server.Validate(); err != nil {
 		return err
 	}
+	if err := c.Auth.Validate(c.Server.TLS); err != nil {
+		return err
+	}
+	// HTTP и Prometheus списком доступа не закрываются, поэтому вместе с ним не запускаются
+	if c.Auth.Enabled && (c.HTTP.Enabled || c.Prometheus.Enabled) {
+		return errors.New("http and prometheus servers have no access control and must be disabled when auth is enabled")
+	}
+	if err := c.Clients.Validate(); err != nil {
+		return err
+	}
+	if err := c.HTTP.Validate(); err != nil {
+		return err
+	}
+	if err := c.Prometheus.Validate(c.App.MaxWindow()); err != nil {
+		return err
+	}
+	for _, exporter := range c.Exporters {
+		if err := exporter.Validate(c.App.MaxWindow()); err != nil {
+			return err
+		}
+	}
+	if err := c.Metric.Validate(c.App.Tick); err != nil {
+		return err
+	}
 
 	return nil
 }
 
 // AppConf содержит общие настройки программы.
 type AppConf struct {
-	MaxSeconds int
+	MaxSeconds int           // сколько секунд хранятся посекундные метрики
+	Tick       time.Duration // как часто сервис сбора метрик опрашивает коллекторы и передает данные клиентам
+	Tiers      []TierConf    // уровни хранения усредненных метрик, от мелкого разрешения к крупному
+}
+
+// TierConf - уровень хранения: метрики, усредненные за Resolution, хранятся Retention.
+type TierConf struct {
+	Resolution time.Duration
+	Retention  time.Duration
 }
 
 func (c AppConf) Validate() error {
 	if c.MaxSeconds <= 0 {
 		return errors.New("time to keep metrics must be greater than zero")
 	}
+	if c.Tick < minTick || c.Tick > time.Second || time.Second%c.Tick != 0 {
+		return fmt.Errorf("tick %v must be between %v and 1s and divide a second evenly", c.Tick, minTick)
+	}
+
+	resolution, retention := time.Second, time.Duration(c.MaxSeconds)*time.Second
+	for _, tier := range c.Tiers {
+		if tier.Resolution%time.Second != 0 {
+			return fmt.Errorf("tier resolution %v must be a whole number of seconds", tier.Resolution)
+		}
+		if tier.Resolution <= resolution {
+			return fmt.Errorf("tier resolution %v must be greater than %v", tier.Resolution, resolution)
+		}
+		if tier.Retention <= retention {
+			return fmt.Errorf("tier retention %v must be greater than %v", tier.Retention, retention)
+		}
+		resolution, retention = tier.Resolution, tier.Retention
+	}
 
 	return nil
 }
 
+// MaxWindow возвращает наибольший интервал в секундах, за который хранятся метрики с учетом всех уровней.
+func (c AppConf) MaxWindow() int {
+	if len(c.Tiers) == 0 {
+		return c.MaxSeconds
+	}
+	return int(c.Tiers[len(c.Tiers)-1].Retention / time.Second)
+}
+
+// AppSettings хранит общие настройки программы, которые меняются при перезагрузке конфига.
+type AppSettings struct {
+	mutex *sync.RWMutex
+	conf  AppConf
+}
+
+// NewAppSettings возвращает настройки, общие для сервисов, с начальным значением conf.
+func NewAppSettings(conf AppConf) *AppSettings {
+	return &AppSettings{
+		mutex: &sync.RWMutex{},
+		conf:  conf,
+	}
+}
+
+func (s *AppSettings) Get() AppConf {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.conf
+}
+
+func (s *AppSettings) Set(conf AppConf) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.conf = conf
+}
+
 // LoggerConf содержит настройки логгера.
 type LoggerConf struct {
-	Level string
+	Level      string
+	Format     string     // text или json
+	File       string     // файл лога вместо stderr
+	MaxSize    int        // размер файла лога в мегабайтах, после которого он ротируется. 0 - без ротации
+	MaxBackups int        // сколько старых файлов лога хранится
+	Syslog     SyslogConf // дополнительная отправка лога в syslog
+}
+
+// SyslogConf содержит настройки отправки лога в syslog.
+type SyslogConf struct {
+	Enabled bool
+	Network string // udp или tcp. Пустой - локальный syslog
+	Address string // адрес удаленного syslog
+	Tag     string
+}
+
+func (c LoggerConf) Validate() error {
+	switch c.Format {
+	case "text", "json":
+	default:
+		return fmt.Errorf("unknown log format %q", c.Format)
+	}
+	if c.MaxSize < 0 {
+		return errors.New("log file max size must not be negative")
+	}
+	if c.MaxBackups < 0 {
+		return errors.New("log file max backups must not be negative")
+	}
+	if !c.Syslog.Enabled {
+		return nil
+	}
+	switch c.Syslog.Network {
+	case "":
+	case "udp", "tcp":
+		if c.Syslog.Address == "" {
+			return errors.New("syslog address is required")
+		}
+	default:
+		return fmt.Errorf("unknown syslog network %q", c.Syslog.Network)
+	}
+	return nil
+}
+
+// StoreConf содержит настройки хранения собранных метрик.
+type StoreConf struct {
+	Backend string // memory или disk. В disk история метрик переживает перезапуск
+	Dir     string // каталог файлов для disk
+}
+
+func (c StoreConf) Validate() error {
+	switch c.Backend {
+	case "memory":
+	case "disk":
+		if c.Dir == "" {
+			return errors.New("store directory is required")
+		}
+	default:
+		return fmt.Errorf("unknown store backend %q", c.Backend)
+	}
+
+	return nil
 }
 
 // ServerConf содержит настройки gRPC сервера.
 type ServerConf struct {
-	Port string
+	Port       string
+	Listen     []string // адреса tcp://host:port и unix:///path. Если пуст, сервер слушает Port на всех интерфейсах
+	SocketMode string   // права на файлы unix сокетов в восьмеричной записи
+	TLS        TLSConf
+	Reflection bool // включить gRPC server reflection, например для grpcurl
 }
 
 func (c ServerConf) Validate() error {
-	if c.Port == "" {
+	if c.Port == "" && len(c.Listen) == 0 {
 		return errors.New("server port is required")
 	}
+	for _, listen := range c.Listen {
+		if _, err := ParseListenAddr(listen); err != nil {
+			return err
+		}
+	}
+	if _, err := c.FileMode(); err != nil {
+		return err
+	}
+	if err := c.TLS.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListenAddrs возвращает адреса, на которых слушает сервер.
+func (c ServerConf) ListenAddrs() []ListenAddr {
+	if len(c.Listen) == 0 {
+		return []ListenAddr{{Network: "tcp", Address: ":" + c.Port}}
+	}
+
+	result := make([]ListenAddr, 0, len(c.Listen))
+	for _, listen := range c.Listen {
+		addr, _ := ParseListenAddr(listen)
+		result = append(result, addr)
+	}
+	return result
+}
+
+// FileMode возвращает права на файлы unix сокетов.
+func (c ServerConf) FileMode() (os.FileMode, error) {
+	mode, err := strconv.ParseUint(c.SocketMode, 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("invalid socket mode %q", c.SocketMode)
+	}
+	return os.FileMode(mode), nil
+}
+
+// ListenAddr - адрес, на котором слушает сервер.
+type ListenAddr struct {
+	Network string // tcp или unix
+	Address string // host:port или путь к сокету
+}
+
+func (a ListenAddr) String() string {
+	return a.Network + "://" + a.Address
+}
+
+// ParseListenAddr разбирает адрес вида tcp://host:port или unix:///path.
+func ParseListenAddr(listen string) (ListenAddr, error) {
+	switch {
+	case strings.HasPrefix(listen, "tcp://"):
+		address := strings.TrimPrefix(listen, "tcp://")
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			return ListenAddr{}, fmt.Errorf("invalid listen address %q: %w", listen, err)
+		}
+		return ListenAddr{Network: "tcp", Address: address}, nil
+	case strings.HasPrefix(listen, "unix://"):
+		path := strings.TrimPrefix(listen, "unix://")
+		if path == "" {
+			return ListenAddr{}, fmt.Errorf("invalid listen address %q: socket path is required", listen)
+		}
+		return ListenAddr{Network: "unix", Address: path}, nil
+	default:
+		return ListenAddr{}, fmt.Errorf("invalid listen address %q: tcp:// or unix:// is required", listen)
+	}
+}
+
+// TLSConf содержит пути к файлам сертификатов gRPC сервера. Измененные файлы подхватываются без перезапуска.
+type TLSConf struct {
+	Cert     string // сертификат сервера в PEM
+	Key      string // закрытый ключ сервера в PEM
+	ClientCA string // сертификаты центров, подписывающих клиентские сертификаты. Если задан, включается mTLS
+}
+
+// Enabled сообщает, включен ли TLS.
+func (c TLSConf) Enabled() bool {
+	return c.Cert != ""
+}
+
+func (c TLSConf) Validate() error {
+	if (c.Cert == "") != (c.Key == "") {
+		return errors.New("tls cert and key must be set together")
+	}
+	if c.ClientCA != "" && c.Cert == "" {
+		return errors.New("tls client CA requires server cert and key")
+	}
+
+	return nil
+}
+
+// MetricNames - имена метрик в конфиге, в том числе в списках доступа.
+var MetricNames = []string{"loadavg", "cpu", "loaddisks", "usedfs"}
+
+// SelfMetric - имя группы метрик самого приложения в списках доступа.
+const SelfMetric = "symo"
+
+// AuthConf содержит список доступа к gRPC серверу. Если он включен, клиент без известного токена
+// или сертификата не обслуживается.
+type AuthConf struct {
+	Enabled    bool
+	Identities []IdentityConf
+}
+
+// IdentityConf описывает клиента: как он опознается и что ему разрешено.
+type IdentityConf struct {
+	Name       string
+	Tokens     []string // bearer токены в заголовке authorization. Несколько - для замены токена без простоя
+	CommonName string   // CN клиентского сертификата при mTLS
+	Metrics    []string // доступные метрики из MetricNames и SelfMetric. Если список пуст, доступны все
+	MaxStreams int      // сколько потоков статистики клиент может держать одновременно, 0 - без ограничения
+	Admin      bool     // доступ к сервису администрирования, например к выгрузке точек
+}
+
+func (c AuthConf) Validate(tls TLSConf) error {
+	if !c.Enabled {
+		return nil
+	}
+	if len(c.Identities) == 0 {
+		return errors.New("auth requires at least one identity")
+	}
+
+	names := make(map[string]bool)
+	tokens := make(map[string]bool)
+	commonNames := make(map[string]bool)
+	for _, identity := range c.Identities {
+		if identity.Name == "" {
+			return errors.New("identity name is required")
+		}
+		if names[identity.Name] {
+			return fmt.Errorf("identity %q is duplicated", identity.Name)
+		}
+		names[identity.Name] = true
+
+		if len(identity.Tokens) == 0 && identity.CommonName == "" {
+			return fmt.Errorf("identity %q: token or common name is required", identity.Name)
+		}
+		for _, token := range identity.Tokens {
+			if token == "" || tokens[token] {
+				return fmt.Errorf("identity %q: tokens must be non-empty and unique", identity.Name)
+			}
+			tokens[token] = true
+		}
+		if identity.CommonName != "" {
+			if tls.ClientCA == "" {
+				return fmt.Errorf("identity %q: common name requires tls client CA", identity.Name)
+			}
+			if commonNames[identity.CommonName] {
+				return fmt.Errorf("identity %q: common name %q is duplicated", identity.Name, identity.CommonName)
+			}
+			commonNames[identity.CommonName] = true
+		}
+		for _, metric := range identity.Metrics {
+			if !isMetricName(metric) && metric != SelfMetric {
+				return fmt.Errorf("identity %q: unknown metric %q", identity.Name, metric)
+			}
+		}
+		if identity.MaxStreams < 0 {
+			return fmt.Errorf("identity %q: max streams must not be negative", identity.Name)
+		}
+	}
+
+	return nil
+}
+
+func isMetricName(name string) bool {
+	for _, metric := range MetricNames {
+		if metric == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientsConf содержит настройки отправки статистики клиентам.
+// Ограничения подключений не касаются экспортеров, нулевое ограничение отключено.
+type ClientsConf struct {
+	QueueSize         int           // размер очереди пакетов клиента
+	Policy            string        // что делать при переполнении очереди: drop-newest, drop-oldest или disconnect
+	DropLimit         int           // для disconnect - сколько пакетов подряд можно пропустить до отключения клиента
+	MaxClients        int           // сколько клиентов может быть подключено одновременно
+	MaxPerPeer        int           // сколько клиентов может быть подключено с одного адреса
+	MinN              time.Duration // наименьший интервал отправки статистики. По умолчанию - тик
+	SubscriptionRate  float64       // сколько новых подключений в секунду принимается в среднем
+	SubscriptionBurst int           // сколько подключений может прийти разом. По умолчанию - SubscriptionRate
+}
+
+// N короче тика запросить нельзя, поэтому ограничение по умолчанию ничего не запрещает.
+func (c *ClientsConf) setDefaults(tick time.Duration) {
+	if c.MinN == 0 {
+		c.MinN = tick
+	}
+}
+
+func (c ClientsConf) Validate() error {
+	if c.QueueSize <= 0 {
+		return errors.New("client queue size must be greater than zero")
+	}
+	if _, err := ParseDropPolicy(c.Policy); err != nil {
+		return err
+	}
+	if c.DropLimit <= 0 {
+		return errors.New("drop limit must be greater than zero")
+	}
+	if c.MaxClients < 0 || c.MaxPerPeer < 0 || c.MinN < 0 || c.SubscriptionRate < 0 || c.SubscriptionBurst < 0 {
+		return errors.New("client limits must not be negative")
+	}
+	// число без единиц, например minN = 1, читается как наносекунды
+	if c.MinN < minTick {
+		return fmt.Errorf("client min N %v is shorter than %v, set it as a duration like \"1s\"", c.MinN, minTick)
+	}
+
+	return nil
+}
+
+// HTTPConf содержит настройки HTTP сервера, отдающего статистику в JSON.
+type HTTPConf struct {
+	Enabled   bool
+	Port      string
+	Dashboard bool // отдавать ли на / страницу с графиками
+}
+
+func (c HTTPConf) Validate() error {
+	if c.Enabled && c.Port == "" {
+		return errors.New("http port is required")
+	}
+
+	return nil
+}
+
+// PrometheusConf содержит настройки HTTP сервера, отдающего метрики в формате Prometheus.
+type PrometheusConf struct {
+	Enabled bool
+	Port    string
+	M       int // за сколько секунд усредняются метрики, если в запросе не указано иное
+}
+
+func (c PrometheusConf) Validate(maxSeconds int) error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Port == "" {
+		return errors.New("prometheus port is required")
+	}
+	if c.M <= 0 || c.M > maxSeconds {
+		return fmt.Errorf("prometheus M must be between 1 and %v seconds", maxSeconds)
+	}
+
+	return nil
+}
+
+// ExporterConf содержит настройки экспортера, периодически отправляющего статистику во внешнюю систему.
+type ExporterConf struct {
+	Name       string
+	Format     string            // формат данных: influx, graphite, statsd, dogstatsd или otlp
+	Protocol   string            // tcp, udp, http или grpc. StatsD отправляется только по udp, otlp - по grpc
+	Address    string            // host:port, для http - URL
+	Headers    map[string]string // для otlp - заголовки (метаданные) запроса
+	Insecure   bool              // для otlp - подключаться без TLS
+	N          int               // статистика отправляется каждые N секунд
+	M          int               // статистика усредняется за M секунд
+	Prefix     string            // префикс имен метрик
+	Tags       map[string]string // теги, добавляемые ко всем метрикам. В statsd не поддерживаются
+	SampleRate float64           // для statsd - доля отправляемых метрик, от 0 до 1
+	QueueSize  int               // сколько неотправленных пакетов хранится, пока приемник недоступен
+	Retries    int               // сколько раз повторяется неудачная отправка
+	RetryDelay time.Duration     // пауза между повторами
+	Timeout    time.Duration     // таймаут подключения и отправки
+}
+
+func (c *ExporterConf) setDefaults() {
+	if c.Protocol == "" && c.isStatsD() {
+		c.Protocol = "udp"
+	}
+	if c.Protocol == "" && c.Format == "otlp" {
+		c.Protocol = "grpc"
+	}
+	if c.SampleRate == 0 {
+		c.SampleRate = 1
+	}
+	if c.N == 0 {
+		c.N = 10
+	}
+	if c.M == 0 {
+		c.M = c.N
+	}
+	if c.Prefix == "" {
+		c.Prefix = "symo"
+	}
+	if c.QueueSize == 0 {
+		c.QueueSize = 100
+	}
+	if c.RetryDelay == 0 {
+		c.RetryDelay = time.Second
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 5 * time.Second
+	}
+}
+
+func (c ExporterConf) Validate(maxSeconds int) error {
+	switch c.Format {
+	case "influx", "graphite", "statsd", "dogstatsd", "otlp":
+	default:
+		return fmt.Errorf("exporter %q: unknown format %q", c.Name, c.Format)
+	}
+	switch c.Protocol {
+	case "tcp", "udp", "http", "grpc":
+	default:
+		return fmt.Errorf("exporter %q: unknown protocol %q", c.Name, c.Protocol)
+	}
+	if c.isStatsD() && c.Protocol != "udp" {
+		return fmt.Errorf("exporter %q: statsd is sent over udp only", c.Name)
+	}
+	if c.Format == "graphite" && c.Protocol == "http" {
+		return fmt.Errorf("exporter %q: graphite is sent over tcp or udp only", c.Name)
+	}
+	if (c.Format == "otlp") != (c.Protocol == "grpc") {
+		return fmt.Errorf("exporter %q: otlp is sent over grpc only", c.Name)
+	}
+	if c.SampleRate <= 0 || c.SampleRate > 1 {
+		return fmt.Errorf("exporter %q: sample rate must be between 0 and 1", c.Name)
+	}
+	if c.Address == "" {
+		return fmt.Errorf("exporter %q: address is required", c.Name)
+	}
+	client := ClientData{N: time.Duration(c.N) * time.Second, M: time.Duration(c.M) * time.Second}
+	err := client.Validate(maxSeconds, time.Second)
+	if err != nil {
+		return fmt.Errorf("exporter %q: %w", c.Name, err)
+	}
+	if c.QueueSize <= 0 {
+		return fmt.Errorf("exporter %q: queue size must be greater than zero", c.Name)
+	}
+	if c.Retries < 0 {
+		return fmt.Errorf("exporter %q: retries must not be negative", c.Name)
+	}
 
 	return nil
 }
 
-// MetricConf позволяет отключить сбор каких-либо метрик.
+func (c ExporterConf) isStatsD() bool {
+	return c.Format == "statsd" || c.Format == "dogstatsd"
+}
+
+// MetricConf позволяет отключить сбор каких-либо метрик и задать частоту их опроса.
 type MetricConf struct {
 	Loadavg   bool
 	CPU       bool
 	Loaddisks bool
 	UsedFS    bool
+	Sampling  SamplingConf
+}
+
+// SamplingConf задает частоту опроса коллекторов по метрикам.
+type SamplingConf struct {
+	Loadavg   MetricSampling
+	CPU       MetricSampling
+	Loaddisks MetricSampling
+	UsedFS    MetricSampling
+}
+
+// MetricSampling - интервал опроса коллектора и время, отведенное на один опрос.
+// Интервал меньше тика дает несколько опросов, усредняемых в точке тика. При интервале больше тика
+// в остальных точках метрики нет, и средние считаются только по опрошенным тикам и секундам.
+type MetricSampling struct {
+	Interval time.Duration // 0 - раз в секунду
+	Timeout  time.Duration // 0 - 95% интервала или тика, если он короче. Должен быть короче их обоих
+}
+
+// наименьший интервал опроса коллектора.
+const minSamplingInterval = 10 * time.Millisecond
+
+func (c MetricConf) Validate(tick time.Duration) error {
+	for _, metric := range MetricNames {
+		settings, err := c.Metric(metric)
+		if err != nil {
+			return err
+		}
+		if err := settings.Sampling.WithDefaults(tick).validate(tick); err != nil {
+			return fmt.Errorf("metric %q: %w", metric, err)
+		}
+	}
+
+	return nil
+}
+
+// MetricSettings - настройки сбора одной метрики.
+type MetricSettings struct {
+	Enabled  bool
+	Sampling MetricSampling // как задана в конфиге, значения по умолчанию дает MetricSampling.WithDefaults
+}
+
+// Metric возвращает настройки сбора метрики с именем из MetricNames.
+func (c MetricConf) Metric(name string) (MetricSettings, error) {
+	switch name {
+	case "loadavg":
+		return MetricSettings{Enabled: c.Loadavg, Sampling: c.Sampling.Loadavg}, nil
+	case "cpu":
+		return MetricSettings{Enabled: c.CPU, Sampling: c.Sampling.CPU}, nil
+	case "loaddisks":
+		return MetricSettings{Enabled: c.Loaddisks, Sampling: c.Sampling.Loaddisks}, nil
+	case "usedfs":
+		return MetricSettings{Enabled: c.UsedFS, Sampling: c.Sampling.UsedFS}, nil
+	}
+	return MetricSettings{}, fmt.Errorf("%w %q", ErrUnknownMetric, name)
+}
+
+// WithDefaults возвращает интервал и время опроса с учетом значений по умолчанию.
+// Время опроса по умолчанию - 95% интервала или тика, если тик короче.
+func (s MetricSampling) WithDefaults(tick time.Duration) MetricSampling {
+	if s.Interval == 0 {
+		s.Interval = time.Second
+	}
+	if s.Timeout == 0 {
+		s.Timeout = s.Interval * 95 / 100
+		if s.Interval > tick {
+			s.Timeout = tick * 95 / 100
+		}
+	}
+	return s
+}
+
+func (s MetricSampling) validate(tick time.Duration) error {
+	switch {
+	case s.Interval < minSamplingInterval:
+		return fmt.Errorf("sampling interval %v must be at least %v", s.Interval, minSamplingInterval)
+	case s.Interval < time.Second && time.Second%s.Interval != 0:
+		return fmt.Errorf("sampling interval %v must divide a second evenly", s.Interval)
+	case s.Interval > time.Second && s.Interval%time.Second != 0:
+		return fmt.Errorf("sampling interval %v must be a whole number of seconds", s.Interval)
+	case s.Interval < tick && tick%s.Interval != 0:
+		return fmt.Errorf("sampling interval %v must divide the tick %v evenly", s.Interval, tick)
+	case s.Interval > tick && s.Interval%tick != 0:
+		return fmt.Errorf("sampling interval %v must be a multiple of the tick %v", s.Interval, tick)
+	case s.Timeout <= 0 || s.Timeout >= s.Interval || s.Timeout >= tick:
+		// результат опроса, не уложившегося в тик, опоздал бы к точке тика
+		return fmt.Errorf("sampling timeout %v must be shorter than the interval %v and the tick %v",
+			s.Timeout, s.Interval, tick)
+	}
+
+	return nil
+}
+
+// Samples возвращает, сколько раз коллектор опрашивается за тик. Для интервала больше тика - 1.
+func (s MetricSampling) Samples(tick time.Duration) int {
+	if s.Interval >= tick {
+		return 1
+	}
+	return int(tick / s.Interval)
+}
+
+// Due сообщает, опрашивается ли коллектор в тик tm.
+func (s MetricSampling) Due(tm time.Time, tick time.Duration) bool {
+	if s.Interval <= tick {
+		return true
+	}
+	return tm.UnixNano()%int64(s.Interval) == 0
 }
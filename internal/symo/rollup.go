@@ -0,0 +1,105 @@
+package symo
+
+import (
+	"sort"
+)
+
+// Rollup накапливает точки и усредняет каждую метрику по точкам, в которых она есть.
+// Точка без метрики не считается нулевым значением, поэтому пропуски не смещают среднее.
+type Rollup struct {
+	loadAvg      [3]float64
+	loadAvgCount int
+	cpu          [3]float64
+	cpuCount     int
+	disks        map[string]*rollupValues
+	fs           map[string]*rollupValues
+	state        MetricsState
+}
+
+type rollupValues struct {
+	sums  []float64
+	count int
+}
+
+func NewRollup() *Rollup {
+	return &Rollup{
+		disks: make(map[string]*rollupValues),
+		fs:    make(map[string]*rollupValues),
+	}
+}
+
+func (r *Rollup) Add(point Point) {
+	if la := point.LoadAvg; la != nil {
+		r.loadAvg[0] += la.Load1
+		r.loadAvg[1] += la.Load5
+		r.loadAvg[2] += la.Load15
+		r.loadAvgCount++
+	}
+	if cpu := point.CPU; cpu != nil {
+		r.cpu[0] += cpu.User
+		r.cpu[1] += cpu.System
+		r.cpu[2] += cpu.Idle
+		r.cpuCount++
+	}
+	for _, disk := range point.LoadDisks {
+		addValues(r.disks, disk.Name, disk.Tps, disk.KBRead, disk.KBWrite)
+	}
+	for _, fs := range point.UsedFS {
+		addValues(r.fs, fs.Path, fs.UsedSpace, fs.UsedInode)
+	}
+	r.state = point.State
+}
+
+func addValues(list map[string]*rollupValues, name string, values ...float64) {
+	acc, ok := list[name]
+	if !ok {
+		acc = &rollupValues{sums: make([]float64, len(values))}
+		list[name] = acc
+	}
+	for i, value := range values {
+		acc.sums[i] += value
+	}
+	acc.count++
+}
+
+// Mean возвращает усредненную точку. Состояние коллекторов берется из последней точки.
+func (r *Rollup) Mean() Point {
+	result := Point{State: r.state}
+	if r.loadAvgCount > 0 {
+		n := float64(r.loadAvgCount)
+		result.LoadAvg = &LoadAvgData{Load1: r.loadAvg[0] / n, Load5: r.loadAvg[1] / n, Load15: r.loadAvg[2] / n}
+	}
+	if r.cpuCount > 0 {
+		n := float64(r.cpuCount)
+		result.CPU = &CPUData{User: r.cpu[0] / n, System: r.cpu[1] / n, Idle: r.cpu[2] / n}
+	}
+	for _, name := range sortedValues(r.disks) {
+		acc := r.disks[name]
+		n := float64(acc.count)
+		result.LoadDisks = append(result.LoadDisks, DiskData{
+			Name:    name,
+			Tps:     acc.sums[0] / n,
+			KBRead:  acc.sums[1] / n,
+			KBWrite: acc.sums[2] / n,
+		})
+	}
+	for _, path := range sortedValues(r.fs) {
+		acc := r.fs[path]
+		n := float64(acc.count)
+		result.UsedFS = append(result.UsedFS, FSData{
+			Path:      path,
+			UsedSpace: acc.sums[0] / n,
+			UsedInode: acc.sums[1] / n,
+		})
+	}
+	return result
+}
+
+func sortedValues(list map[string]*rollupValues) []string {
+	names := make([]string, 0, len(list))
+	for name := range list {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -3,6 +3,9 @@ package symo
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
+	"sort"
 	"time"
 )
 
@@ -10,6 +13,42 @@ import (
 type Collector interface {
 	Start(context.Context, MetricCollectors, chan<- MetricsData)
 	Stop(context.Context)
+	Reloader
+	CollectorStater
+}
+
+// CollectorStater отдает состояние сервиса сбора метрик для метрик самого приложения.
+type CollectorStater interface {
+	CollectorStats() CollectorStats
+}
+
+// CollectorStats - состояние сервиса сбора метрик.
+type CollectorStats struct {
+	Ticks        uint64                  // сколько тиков обработано
+	DroppedTicks uint64                  // сколько тиков не удалось передать сервису клиентов
+	TickDuration time.Duration           // сколько длилась обработка последнего тика
+	TickLag      time.Duration           // насколько обработка последнего тика началась позже срабатывания таймера
+	Samplers     map[string]SamplerStats // опросы коллекторов по именам метрик из MetricNames
+}
+
+// SamplerStats - опросы коллектора одной метрики с запуска приложения.
+type SamplerStats struct {
+	Samples      uint64        // сколько раз опрошен коллектор
+	Errors       uint64        // сколько опросов завершилось ошибкой, кроме таймаутов
+	Timeouts     uint64        // сколько опросов не уложилось в таймаут
+	Latency      time.Duration // длительность последнего опроса
+	LatencyTotal time.Duration // суммарная длительность опросов
+}
+
+// Reloader представляет сервис, применяющий перечитанный конфиг без перезапуска.
+type Reloader interface {
+	Reload(ctx context.Context, config Config)
+}
+
+// ConfigReloader перечитывает файл конфига и применяет изменения, не требующие перезапуска.
+type ConfigReloader interface {
+	// ReloadConfig возвращает секции конфига, изменения которых применятся только после перезапуска.
+	ReloadConfig(ctx context.Context) ([]string, error)
 }
 
 // NewClienter представляет интерфейс для подключения новых клиентов.
@@ -23,33 +62,223 @@ type Clients interface {
 	Start(context.Context, <-chan MetricsData)
 	Stop(context.Context)
 	NewClienter
+	ClientsStater
 }
 
 // ErrStopped ошибка, возвращаемая grpc запросу, если приложение останавливается.
 var ErrStopped = errors.New("service is stopped")
 
+// ErrOverflow ошибка, с которой отключается клиент, не успевающий забирать статистику.
+var ErrOverflow = errors.New("client is too slow")
+
+// ErrLimit ошибка, с которой отклоняется подключение клиента сверх ограничений из конфига.
+var ErrLimit = errors.New("client limit is exceeded")
+
+// ErrUnknownMetric ошибка поиска метрики по имени, которого нет в MetricNames.
+var ErrUnknownMetric = errors.New("unknown metric")
+
+// ClientsStater отдает состояние сервиса клиентов для метрик самого приложения.
+type ClientsStater interface {
+	ClientsStats() ClientsStats
+}
+
+// ClientsStats - состояние сервиса клиентов.
+type ClientsStats struct {
+	Clients      int                    // подключенные клиенты, кроме экспортеров
+	Rejected     map[string]uint64      // сколько подключений отклонено по причинам: clients, peer, n, rate
+	DroppedSends uint64                 // сколько пакетов не попало к клиентам
+	SendDuration time.Duration          // сколько длилась рассылка последнего тика
+	Intervals    map[ClientInterval]int // подписанные клиенты и экспортеры по N и M
+}
+
+// ClientInterval - N и M подписки клиента.
+type ClientInterval struct {
+	N time.Duration
+	M time.Duration
+}
+
+// SortedIntervals возвращает N и M подписок по возрастанию N, затем M.
+func SortedIntervals(list map[ClientInterval]int) []ClientInterval {
+	result := make([]ClientInterval, 0, len(list))
+	for interval := range list {
+		result = append(result, interval)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].N != result[j].N {
+			return result[i].N < result[j].N
+		}
+		return result[i].M < result[j].M
+	})
+	return result
+}
+
+// SelfStater отдает метрики самого приложения, группу "symo".
+type SelfStater interface {
+	SelfStats() SelfStats
+}
+
+// SelfStats - метрики самого приложения: работа сервисов сбора метрик и клиентов, хранилища и рантайм Go.
+type SelfStats struct {
+	Collector      CollectorStats
+	Clients        ClientsStats
+	PointsRetained int    // сколько посекундных точек и точек уровней хранится
+	TicksRetained  int    // сколько хранится точек тиков, если тик короче секунды
+	DroppedWrites  uint64 // сколько секунд не сохранено на диск из-за переполненной очереди записи
+	HeapBytes      uint64 // занятая память кучи
+	Goroutines     int
+}
+
 // CollectorToClientsCh - канал для посекундной передачи накопленных данных сервису клиентов.
 type CollectorToClientsCh chan MetricsData
 
 // MetricsData содержит данные, отсылаемые сервису клиентов.
-// Отсылается текущая секунда и копия всех собранных данных.
-// Отсылается копия, чтобы сервис мог ее обрабатывать, не блокируя мьютекс с собираемыми данными.
+// Отсылается текущий тик и хранилище собранных данных. Завершенными считаются секунды и тики до текущего.
 type MetricsData struct {
-	Time   time.Time
-	Points Points
+	Time         time.Time
+	Points       PointsReader
+	Ticks        PointsReader  // точки тиков, если тик короче секунды. M в запросах к нему задается в тиках
+	Tick         time.Duration // длительность тика
+	DroppedTicks uint64        // сколько всего тиков не удалось передать сервису клиентов, потому что он был занят
+}
+
+// PointsReader предоставляет доступ на чтение к собранным посекундным метрикам.
+type PointsReader interface {
+	// Mean возвращает метрики, усредненные за M секунд до времени to, и состояние коллекторов
+	// в последней из этих секунд. Время вычисления не зависит от M.
+	Mean(to time.Time, m int) Point
+	// Points возвращает посекундные точки за M секунд до времени to с их секундами в порядке времени.
+	Points(to time.Time, m int) []TimedPoint
+	// Window вызывает fn с посекундными значениями метрик за M секунд до времени to без копирования.
+	// Пока fn выполняется, хранилище заблокировано на чтение, после ее возврата окно использовать нельзя.
+	Window(to time.Time, m int, fn func(Window))
+}
+
+// PointsStore хранит собранные посекундные метрики за время хранения.
+type PointsStore interface {
+	// Append добавляет точку за завершенную секунду. Секунды добавляются по возрастанию.
+	Append(tm time.Time, point Point)
+	// Resize меняет время хранения посекундных метрик. Последние точки сохраняются.
+	Resize(seconds int)
+	// Len возвращает, сколько точек сейчас хранится.
+	Len() int
+	PointsReader
+}
+
+// PointsSource возвращает сохраненные посекундные точки в порядке времени, например из файла записи.
+type PointsSource interface {
+	// Next возвращает следующую точку и ее секунду. Когда точки закончились, возвращается io.EOF.
+	Next() (time.Time, Point, error)
+}
+
+// DiskStore представляет хранилище точек, сохраняющее их на диск, чтобы история переживала перезапуск.
+type DiskStore interface {
+	PointsStore
+	StoreStater
+	Stop(ctx context.Context)
+}
+
+// StoreStater отдает состояние хранилища на диске для метрик самого приложения.
+type StoreStater interface {
+	// DroppedWrites возвращает, сколько секунд не сохранено на диск, потому что очередь записи была полна.
+	DroppedWrites() uint64
 }
 
 // Stats содержит данные, отсылаемые каждому клиенту.
 type Stats struct {
+	// номер пакета клиента. Номера идут подряд, пропуск означает отброшенные пакеты
+	Seq uint64
+	// сколько пакетов клиента отброшено с предыдущего пакета, поставленного в очередь. Пакет, вытесненный
+	// из очереди, учитывается в следующем, поэтому сумма по полученным пакетам равна числу отброшенных
+	Dropped uint64
+	// если задана, клиент отключается с этой ошибкой. Это последний пакет в канале
+	Err       error
 	Time      time.Time
 	LoadAvg   *LoadAvgData
 	CPU       *CPUData
 	LoadDisks LoadDisksData
 	UsedFS    UsedFSData
+	State     MetricsState
+	// дополнительные агрегации метрик, запрошенные клиентом. Среднее всегда передается в основных полях
+	Aggregated []AggregatedData
+}
+
+// AggregatedData содержит метрики, агрегированные за M секунд указанным способом.
+type AggregatedData struct {
+	Aggregation Aggregation
+	LoadAvg     *LoadAvgData
+	CPU         *CPUData
+	LoadDisks   LoadDisksData
+	UsedFS      UsedFSData
+}
+
+// Aggregation - способ агрегации посекундных метрик за M секунд.
+type Aggregation int
+
+const (
+	// AggMean - среднее значение.
+	AggMean Aggregation = iota
+	// AggMin - минимальное значение.
+	AggMin
+	// AggMax - максимальное значение.
+	AggMax
+	// AggP50 - медиана.
+	AggP50
+	// AggP95 - 95-й перцентиль.
+	AggP95
+	// AggP99 - 99-й перцентиль.
+	AggP99
+	// AggLast - последнее полученное значение.
+	AggLast
+
+	aggCount
+)
+
+var aggregationNames = [...]string{"mean", "min", "max", "p50", "p95", "p99", "last"}
+
+func (a Aggregation) String() string {
+	if a < 0 || a >= aggCount {
+		return "unknown"
+	}
+	return aggregationNames[a]
 }
 
-// Points хранит собранные посекундные наборы метрик.
-type Points map[time.Time]*Point
+// ParseAggregation возвращает способ агрегации по его имени.
+func ParseAggregation(name string) (Aggregation, error) {
+	for i, aggName := range aggregationNames {
+		if aggName == name {
+			return Aggregation(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown aggregation %q", name)
+}
+
+// Aggregations - набор агрегаций (битовая маска).
+type Aggregations uint
+
+// NewAggregations возвращает набор из перечисленных агрегаций.
+func NewAggregations(list ...Aggregation) Aggregations {
+	var result Aggregations
+	for _, agg := range list {
+		result |= 1 << agg
+	}
+	return result
+}
+
+// Has сообщает, входит ли агрегация в набор.
+func (a Aggregations) Has(agg Aggregation) bool {
+	return a&(1<<agg) != 0
+}
+
+// List возвращает агрегации набора в порядке их объявления.
+func (a Aggregations) List() []Aggregation {
+	var result []Aggregation
+	for agg := AggMean; agg < aggCount; agg++ {
+		if a.Has(agg) {
+			result = append(result, agg)
+		}
+	}
+	return result
+}
 
 // Point содержит набор метрик (снапшот). За секунду или усредненный.
 type Point struct {
@@ -57,6 +286,128 @@ type Point struct {
 	CPU       *CPUData
 	LoadDisks LoadDisksData
 	UsedFS    UsedFSData
+	State     MetricsState
+}
+
+// Window - посекундные значения метрик за интервал, по колонке на каждое поле метрики.
+// Колонки ссылаются на память хранилища, поэтому их нельзя изменять и нельзя сохранять после возврата
+// из функции, переданной в PointsReader.Window.
+type Window struct {
+	LoadAvg   Columns        // Load1, Load5, Load15
+	CPU       Columns        // User, System, Idle
+	LoadDisks []NamedColumns // Tps, KBRead, KBWrite по дискам в порядке имен
+	UsedFS    []NamedColumns // UsedSpace, UsedInode по файловым системам в порядке путей
+}
+
+// Columns - колонки полей одной метрики. nil, если значений метрики в интервале нет.
+type Columns []Column
+
+// NamedColumns - колонки полей метрики диска или файловой системы.
+type NamedColumns struct {
+	Name    string
+	Columns Columns
+}
+
+// Column - значения одного поля метрики по секундам в порядке времени. Интервал в кольцевом буфере
+// хранилища может состоять из двух кусков. NaN означает, что значения в эту секунду не было.
+type Column [2][]float64
+
+// Each вызывает fn для каждого значения колонки по порядку, пропуская секунды без значений.
+func (c Column) Each(fn func(value float64)) {
+	for _, part := range c {
+		for _, value := range part {
+			if !math.IsNaN(value) {
+				fn(value)
+			}
+		}
+	}
+}
+
+// Len возвращает количество секунд со значениями.
+func (c Column) Len() int {
+	count := 0
+	c.Each(func(float64) {
+		count++
+	})
+	return count
+}
+
+// TimedPoint - точка за секунду Time.
+type TimedPoint struct {
+	Time time.Time
+	Point
+}
+
+// MetricStatus - состояние коллектора метрики.
+type MetricStatus int
+
+// Нулевое значение - неизвестное состояние, поэтому точка, в которой состояние не задано (импорт, воспроизведение),
+// не считается здоровой. Значения совпадают с перечислением Status в symo.proto и хранятся в файлах сегментов.
+const (
+	// StatusUnknown - состояние метрики не задано.
+	StatusUnknown MetricStatus = iota
+	// StatusStale - коллектор не успел вернуть метрику.
+	StatusStale
+	// StatusError - коллектор вернул ошибку.
+	StatusError
+	// StatusDisabled - сбор метрики отключен.
+	StatusDisabled
+	// StatusOK - метрика получена.
+	StatusOK
+)
+
+func (s MetricStatus) String() string {
+	switch s {
+	case StatusOK:
+		return "ok"
+	case StatusStale:
+		return "stale"
+	case StatusError:
+		return "error"
+	case StatusDisabled:
+		return "disabled"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseMetricStatus возвращает состояние коллектора по названию.
+func ParseMetricStatus(name string) (MetricStatus, error) {
+	for status := StatusUnknown; status <= StatusOK; status++ {
+		if status.String() == name {
+			return status, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown metric status %q", name)
+}
+
+// MetricState содержит состояние коллектора метрики.
+type MetricState struct {
+	Status  MetricStatus
+	Message string // текст ошибки для StatusError
+}
+
+// MetricsState содержит состояния всех коллекторов.
+type MetricsState struct {
+	LoadAvg   MetricState
+	CPU       MetricState
+	LoadDisks MetricState
+	UsedFS    MetricState
+}
+
+// Metric возвращает состояние метрики с именем из MetricNames.
+func (s *MetricsState) Metric(name string) (*MetricState, error) {
+	switch name {
+	case "loadavg":
+		return &s.LoadAvg, nil
+	case "cpu":
+		return &s.CPU, nil
+	case "loaddisks":
+		return &s.LoadDisks, nil
+	case "usedfs":
+		return &s.UsedFS, nil
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownMetric, name)
 }
 
 // MetricCommand - команды для взаимодействия сервиса метрик и коллекторами, собирающими метрики.
@@ -128,14 +479,108 @@ type FSData struct {
 
 // GRPCServer представляет gRPC сервер.
 type GRPCServer interface {
+	Start(addrs []ListenAddr, clients NewClienter, points PointsReader, self SelfStater, reloader ConfigReloader) error
+	Stop(ctx context.Context)
+	Reloader
+}
+
+// PrometheusServer представляет HTTP сервер, отдающий последние метрики в формате Prometheus.
+type PrometheusServer interface {
+	Start(addr string, points PointsReader, self SelfStater) error
+	Stop(ctx context.Context)
+	Reloader
+}
+
+// HTTPServer представляет HTTP сервер, отдающий статистику в JSON.
+type HTTPServer interface {
 	Start(addr string, clients NewClienter) error
 	Stop(ctx context.Context)
+	Reloader
+}
+
+// Exporter представляет экспортер, периодически отправляющий статистику во внешнюю систему.
+type Exporter interface {
+	Start(clients NewClienter) error
+	Stop(ctx context.Context)
 }
 
-// ClientData - информация, передаваемая из grpc запроса сервису клиентов.
+// ClientData - информация, передаваемая из запроса клиента сервису клиентов.
 type ClientData struct {
-	N int // информация отправляется каждые N секунд
-	M int // информация усредняется за M секунд
+	N         time.Duration // информация отправляется каждые N
+	M         time.Duration // информация усредняется за M
+	Aggs      Aggregations  // дополнительные агрегации за M секунд
+	Policy    DropPolicy    // что делать, если клиент не успевает забирать статистику
+	DropLimit int           // для PolicyDisconnect - сколько пакетов подряд можно пропустить до отключения
+	Once      bool          // клиенту отправляется один пакет на ближайшей секунде, после чего он отключается
+	Peer      string        // адрес клиента для ограничения подключений
+	Exporter  bool          // клиент - экспортер самой программы, ограничения подключений к нему не применяются
+}
+
+// Validate проверяет параметры клиента. Для Once клиента N не используется.
+// N и M должны быть кратны тику сервиса сбора метрик. Если хотя бы один из них не кратен секунде,
+// статистика считается по точкам тиков, которые хранятся TickSeconds секунд.
+func (c ClientData) Validate(maxSeconds int, tick time.Duration) error {
+	limit := time.Duration(maxSeconds) * time.Second
+	if !c.Once {
+		if c.N <= 0 {
+			return errors.New("N must be greater than 0 seconds")
+		}
+		if c.N > limit {
+			return fmt.Errorf("N must be less than %v seconds", maxSeconds)
+		}
+		if c.N%tick != 0 {
+			return fmt.Errorf("N must be a multiple of the tick %v", tick)
+		}
+	}
+	if c.M <= 0 {
+		return errors.New("M must be greater than 0 seconds")
+	}
+	if c.M > limit {
+		return fmt.Errorf("M must be less than %v seconds", maxSeconds)
+	}
+	if c.M%tick != 0 {
+		return fmt.Errorf("M must be a multiple of the tick %v", tick)
+	}
+	if !c.WholeSeconds() && c.M > TickSeconds*time.Second {
+		return fmt.Errorf("M must be less than %v seconds for intervals shorter than a second", TickSeconds)
+	}
+	if c.DropLimit < 0 {
+		return errors.New("drop limit must not be negative")
+	}
+	return nil
+}
+
+// WholeSeconds сообщает, что N и M - целые секунды. Такому клиенту статистика отправляется в начале секунды.
+func (c ClientData) WholeSeconds() bool {
+	return c.N%time.Second == 0 && c.M%time.Second == 0
+}
+
+// DropPolicy - поведение при переполнении очереди клиента.
+type DropPolicy int
+
+const (
+	// PolicyDefault - политика из конфига.
+	PolicyDefault DropPolicy = iota
+	// PolicyDropNewest - новый пакет отбрасывается.
+	PolicyDropNewest
+	// PolicyDropOldest - из очереди отбрасывается самый старый пакет.
+	PolicyDropOldest
+	// PolicyDisconnect - новый пакет отбрасывается, после DropLimit пропусков подряд клиент отключается.
+	PolicyDisconnect
+)
+
+// ParseDropPolicy возвращает политику по ее имени в конфиге.
+func ParseDropPolicy(name string) (DropPolicy, error) {
+	switch name {
+	case "drop-newest":
+		return PolicyDropNewest, nil
+	case "drop-oldest":
+		return PolicyDropOldest, nil
+	case "disconnect":
+		return PolicyDisconnect, nil
+	default:
+		return PolicyDefault, fmt.Errorf("unknown drop policy %q", name)
+	}
 }
 
 // Logger представляет логгер.
@@ -144,4 +589,20 @@ type Logger interface {
 	Info(args ...interface{})
 	Error(args ...interface{})
 	Fatal(args ...interface{})
+	// With возвращает логгер, добавляющий поле ко всем своим записям.
+	With(key string, value interface{}) Logger
+}
+
+// Имена полей структурированного лога.
+const (
+	LogComponent = "component" // сервис приложения
+	LogCollector = "collector" // метрика, которую опрашивает коллектор
+	LogExporter  = "exporter"  // имя экспортера
+	LogPeer      = "peer"      // адрес клиента
+)
+
+// LevelLogger представляет логгер, уровень которого можно изменить без перезапуска.
+type LevelLogger interface {
+	Logger
+	SetLevel(level string) error
 }
@@ -4,13 +4,17 @@ package test
 
 import (
 	"context"
+	"net"
+	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	grpcClient "github.com/anfilat/final-stats/internal/grpc"
 )
@@ -36,19 +40,88 @@ func TestSymo(t *testing.T) {
 	require.NotNil(t, stats.LoadDisks)
 	require.NotNil(t, stats.UsedFs)
 
-	// проверяем, что метрика - "нагрузка процессора" действительно увеличивается при загрузке процессора вычислениями
-	// уровень нагрузки процессора в ненагруженной системе
-	cpuUser1 := getCPU(t)
+	cancel()
+	_ = cmd.Wait()
+}
 
-	// уровень нагрузки процессора в нагруженной системе
-	go load(3 * time.Second)
-	go load(3 * time.Second)
-	cpuUser2 := getCPU(t)
+// воспроизведение записи дает одни и те же значения независимо от нагрузки машины.
+func TestSymoReplay(t *testing.T) {
+	err := compile()
+	require.NoError(t, err)
 
-	require.Greater(t, cpuUser2, cpuUser1)
+	// 10 секунд простоя, затем 10 секунд полной загрузки процессора
+	start := time.Unix(1_600_000_000, 0)
+	input := filepath.Join(t.TempDir(), "incident.rec")
+	writeRecording(t, input, start, 20, func(i int) float64 {
+		if i < 10 {
+			return 1
+		}
+		return 90
+	})
 
-	cancel()
-	_ = cmd.Wait()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "./bin/symo", "replay", "-i", input, "-speed", "2")
+	cmd.Dir = ".."
+	err = cmd.Start()
+	require.NoError(t, err)
+	defer func() {
+		cancel()
+		_ = cmd.Wait()
+	}()
+
+	require.Eventually(t, func() bool {
+		probe, err := net.Dial("tcp", ":8000")
+		if err != nil {
+			return false
+		}
+		_ = probe.Close()
+		return true
+	}, 3*time.Second, 50*time.Millisecond)
+
+	conn, err := grpc.Dial(":8000", grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := grpcClient.NewSymoClient(conn)
+	reqClient, err := client.GetStats(ctx, &grpcClient.StatsRequest{N: 1, M: 5})
+	require.NoError(t, err)
+
+	// среднее за 5 секунд по времени записи: секунды 8..12 - это 1, 1, 90, 90, 90
+	cpu := make(map[int64]float64)
+	for {
+		stats, err := reqClient.Recv()
+		require.NoError(t, err)
+		cpu[stats.Time.AsTime().Unix()-start.Unix()] = stats.Cpu.User
+		if stats.Time.AsTime().Equal(start.Add(20 * time.Second)) {
+			break
+		}
+	}
+	require.InDelta(t, 1, cpu[10], 0.001)
+	require.InDelta(t, 54.4, cpu[13], 0.001)
+	require.InDelta(t, 90, cpu[20], 0.001)
+}
+
+// writeRecording пишет запись, как ее сделал бы `client record` за count секунд от start.
+func writeRecording(t *testing.T, path string, start time.Time, count int, cpuUser func(i int) float64) {
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	writer := grpcClient.NewRecordWriter(file)
+	for i := 0; i < count; i++ {
+		err := writer.Write(&grpcClient.Stats{
+			Time: timestamppb.New(start.Add(time.Duration(i+1) * time.Second)),
+			Cpu: &grpcClient.CPU{
+				User:   cpuUser(i),
+				System: 1,
+				Idle:   99 - cpuUser(i),
+			},
+			CpuState: &grpcClient.MetricState{Status: grpcClient.Status_STATUS_OK},
+		})
+		require.NoError(t, err)
+	}
 }
 
 func compile() error {
@@ -83,35 +156,3 @@ func getMetrics(t *testing.T) *grpcClient.Stats {
 
 	return stats
 }
-
-func getCPU(t *testing.T) float64 {
-	conn, err := grpc.Dial(":8000", grpc.WithInsecure())
-	require.NoError(t, err)
-	defer conn.Close()
-
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	client := grpcClient.NewSymoClient(conn)
-	req := &grpcClient.StatsRequest{
-		N: int32(1),
-		M: int32(3),
-	}
-	reqClient, err := client.GetStats(ctx, req)
-	require.NoError(t, err)
-
-	stats, err := reqClient.Recv()
-	require.NoError(t, err)
-
-	return stats.Cpu.User
-}
-
-func load(dur time.Duration) {
-	begin := time.Now()
-
-	for {
-		if time.Since(begin) > dur {
-			break
-		}
-	}
-}